@@ -11,10 +11,16 @@ import (
 
 func init() {
 	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "./db.yaml", "The path to export the state to")
+	exportCmd.Flags().BoolVar(&exportTemplates, "templates", false, "Factor the leading columns tables share into templates")
+	exportCmd.Flags().BoolVar(&exportSplit, "split", false, "Write one file per namespace and per table into the directory given by --file, ./schema by default")
 	rootCmd.AddCommand(exportCmd)
 }
 
-var exportFile string
+var (
+	exportFile      string
+	exportTemplates bool
+	exportSplit     bool
+)
 
 var exportCmd = &cobra.Command{
 	Use:   "export",
@@ -29,5 +35,13 @@ func export(cmd *cobra.Command, args []string) (err error) {
 		return
 	}
 
-	return db.GetState().ExportYAML(exportFile)
+	req := db.GetState().Request(exportTemplates)
+	if exportSplit {
+		dir := exportFile
+		if !cmd.Flags().Changed("file") {
+			dir = "./schema"
+		}
+		return req.WriteYAMLSplit(dir)
+	}
+	return req.WriteYAML(exportFile)
 }
@@ -14,16 +14,18 @@ import (
 )
 
 func init() {
-	planCmd.Flags().StringVar(&planFile, "file", "./db.yaml", "The path to the desired state YAML")
+	planCmd.Flags().StringVar(&planFile, "file", "./db.yaml", "The path to the desired state YAML, or a directory or glob of YAML files")
 	planCmd.Flags().StringVar(&planDescription, "description", "", "Short description for the migration")
 	planCmd.Flags().StringVar(&planMigrationsDir, "migrations-dir", "./migrations", "The migrations directory")
+	planCmd.Flags().BoolVar(&planWarnColumnOrder, "warn-column-order", false, "Warn when the column order of a table differs from the desired order")
 	rootCmd.AddCommand(planCmd)
 }
 
 var (
-	planFile          string
-	planDescription   string
-	planMigrationsDir string
+	planFile            string
+	planDescription     string
+	planMigrationsDir   string
+	planWarnColumnOrder bool
 )
 
 var planCmd = &cobra.Command{
@@ -45,16 +47,23 @@ func plan(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	for _, namespace := range req.Namespaces {
-		if err := namespace.Valid(); err != nil {
-			return err
+	if err := req.Database().Valid(); err != nil {
+		return err
+	}
+
+	if planWarnColumnOrder {
+		for _, warning := range state.ColumnOrderWarnings(s.Database, req.Database()) {
+			log.Warn(warning)
 		}
 	}
 
-	migrators := state.Compare(s.Database.Namespaces, req.Namespaces)
+	migrators := state.CompareDatabase(s.Database, req.Database())
 
 	var allActions []string
 	for _, m := range migrators {
+		if err := m.Err(); err != nil {
+			return fmt.Errorf("could not plan migration: %v", err)
+		}
 		allActions = append(allActions, m.GetActions()...)
 	}
 
@@ -63,7 +72,7 @@ func plan(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	existingState := buildExistingStateFromNamespaces(s.Database.Namespaces)
+	existingState := buildExistingStateFromDatabase(s.Database)
 	upSQL := strings.Join(allActions, "\n")
 	downSQL := migration.GenerateDownSQL(allActions, existingState)
 
@@ -88,27 +97,106 @@ func plan(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func buildExistingStateFromNamespaces(namespaces []*objects.Namespace) *migration.ExistingState {
+func buildExistingStateFromDatabase(db *objects.Database) *migration.ExistingState {
 	es := &migration.ExistingState{
-		ColumnTypes:    make(map[string]string),
-		ColumnDefaults: make(map[string]string),
-		ColumnNullable: make(map[string]bool),
-		SequenceTypes:  make(map[string]string),
+		ColumnTypes:         make(map[string]string),
+		ColumnDefaults:      make(map[string]string),
+		ColumnNullable:      make(map[string]bool),
+		ColumnIdentities:    make(map[string]*objects.ColumnIdentity),
+		SequenceTypes:       make(map[string]string),
+		SequenceOptions:     make(map[string]objects.SequenceOptions),
+		SequenceOwners:      make(map[string]string),
+		ExtensionSchemas:    make(map[string]string),
+		ExtensionVersions:   make(map[string]string),
+		Comments:            make(map[string]string),
+		ConstraintDeferrals: make(map[string]string),
+		Roles:               make(map[string]*objects.Role),
+		Policies:            make(map[string]*objects.Policy),
+		PartitionBounds:     make(map[string]string),
+		Domains:             make(map[string]*objects.Domain),
+		CompositeTypes:      make(map[string]*objects.CompositeType),
+		Tablespaces:         make(map[string]string),
+		StorageParameters:   make(map[string]map[string]string),
+		Publications:        make(map[string]*objects.Publication),
+		Subscriptions:       make(map[string]*objects.Subscription),
+	}
+
+	addComment := func(target string, comment *string) {
+		if comment != nil && *comment != "" {
+			es.Comments[target] = *comment
+		}
+	}
+
+	for _, role := range db.Roles {
+		es.Roles[objects.QuoteIdent(role.Name)] = role
+	}
+
+	for _, ext := range db.Extensions {
+		name := objects.QuoteIdent(ext.Name)
+		es.ExtensionSchemas[name] = objects.QuoteIdent(ext.Schema)
+		es.ExtensionVersions[name] = ext.Version
+		addComment("EXTENSION "+name, ext.Comment)
 	}
 
-	for _, ns := range namespaces {
+	for _, pub := range db.Publications {
+		name := objects.QuoteIdent(pub.Name)
+		es.Publications[name] = pub
+		addComment("PUBLICATION "+name, pub.Comment)
+	}
+
+	for _, sub := range db.Subscriptions {
+		name := objects.QuoteIdent(sub.Name)
+		es.Subscriptions[name] = sub
+		addComment("SUBSCRIPTION "+name, sub.Comment)
+	}
+
+	for _, ns := range db.Namespaces {
+		addComment("SCHEMA "+objects.QuoteIdent(ns.Name), ns.Comment)
 		for _, t := range ns.Tables {
-			fullName := fmt.Sprintf("%s.%s", ns.Name, t.Name)
+			fullName := objects.QualifiedName(ns.Name, t.Name)
+			addComment("TABLE "+fullName, t.Comment)
+			es.Tablespaces[fullName] = t.GetTablespace()
+			es.StorageParameters[fullName] = t.GetWith()
 			for _, col := range t.Columns {
-				key := fullName + "." + col.Name
-				es.ColumnTypes[key] = col.Type
+				key := fullName + "." + objects.QuoteIdent(col.Name)
+				es.ColumnTypes[key] = col.TypeSQL() + col.CollationSQL()
 				es.ColumnDefaults[key] = col.Default
 				es.ColumnNullable[key] = col.Nullable
+				es.ColumnIdentities[key] = col.Identity
+				addComment("COLUMN "+key, col.Comment)
+			}
+			for _, con := range t.Constraints {
+				es.ConstraintDeferrals[fullName+"."+objects.QuoteIdent(con.Name)] = con.DeferralSQL()
+				addComment(fmt.Sprintf("CONSTRAINT %s ON %s", objects.QuoteIdent(con.Name), fullName), con.Comment)
+			}
+			for _, idx := range t.Indices {
+				addComment("INDEX "+objects.QualifiedName(ns.Name, idx.Name), idx.Comment)
 			}
+			for _, pol := range t.Policies {
+				es.Policies[fullName+"."+objects.QuoteIdent(pol.Name)] = pol
+			}
+			for _, part := range t.Partitions {
+				es.PartitionBounds[objects.QualifiedName(ns.Name, part.Name)] = part.BoundSQL()
+			}
+		}
+		for _, dom := range ns.Domains {
+			fullName := objects.QualifiedName(ns.Name, dom.Name)
+			es.Domains[fullName] = dom
+			addComment("DOMAIN "+fullName, dom.Comment)
+		}
+		for _, typ := range ns.CompositeTypes {
+			fullName := objects.QualifiedName(ns.Name, typ.Name)
+			es.CompositeTypes[fullName] = typ
+			addComment("TYPE "+fullName, typ.Comment)
 		}
 		for _, seq := range ns.Sequences {
-			fullName := fmt.Sprintf("%s.%s", ns.Name, seq.Name)
+			fullName := objects.QualifiedName(ns.Name, seq.Name)
 			es.SequenceTypes[fullName] = seq.Type
+			es.SequenceOptions[fullName] = seq.SequenceOptions
+			if seq.OwnedBy != "" {
+				es.SequenceOwners[fullName] = seq.OwnedBySQL(ns.Name)
+			}
+			addComment("SEQUENCE "+fullName, seq.Comment)
 		}
 	}
 
@@ -122,12 +210,26 @@ func generateDescription(actions []string) string {
 
 	first := strings.ToLower(actions[0])
 	switch {
-	case strings.Contains(first, "create table"):
+	case strings.HasPrefix(first, "comment on"):
+		return "update_comments"
+	case strings.Contains(first, "create table"), strings.Contains(first, "create unlogged table"):
 		return "schema_changes"
 	case strings.Contains(first, "alter table"):
 		return "table_alterations"
 	case strings.Contains(first, "create schema"):
 		return "create_schema"
+	case strings.Contains(first, "create extension"):
+		return "create_extensions"
+	case strings.Contains(first, "publication"):
+		return "update_publications"
+	case strings.Contains(first, "subscription"):
+		return "update_subscriptions"
+	case strings.Contains(first, "policy"), strings.Contains(first, "row level security"):
+		return "update_policies"
+	case strings.HasPrefix(first, "create role"), strings.HasPrefix(first, "alter role"):
+		return "update_roles"
+	case strings.HasPrefix(first, "grant"), strings.HasPrefix(first, "revoke"), strings.HasPrefix(first, "alter default privileges"):
+		return "update_privileges"
 	default:
 		return "migration"
 	}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"stijntratsaertit/terramigrate/state"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	schemaJSONSchemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "The path to write the JSON Schema to, stdout by default")
+	schemaCmd.AddCommand(schemaJSONSchemaCmd)
+	rootCmd.AddCommand(schemaCmd)
+}
+
+var (
+	schemaOutput string
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Describe the format of the desired state",
+}
+
+var schemaJSONSchemaCmd = &cobra.Command{
+	Use:   "jsonschema",
+	Short: "Print the JSON Schema of the desired state YAML",
+	RunE:  jsonSchema,
+}
+
+func jsonSchema(cmd *cobra.Command, args []string) error {
+	schema, err := state.JSONSchema()
+	if err != nil {
+		return err
+	}
+
+	if schemaOutput == "" {
+		_, err = os.Stdout.Write(schema)
+		return err
+	}
+	if err := os.WriteFile(schemaOutput, schema, 0644); err != nil {
+		return fmt.Errorf("could not write JSON schema: %v", err)
+	}
+	return nil
+}
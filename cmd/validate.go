@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"stijntratsaertit/terramigrate/state"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	validateCmd.Flags().StringVar(&validateFile, "file", "./db.yaml", "The path to the desired state YAML, or a directory or glob of YAML files")
+	rootCmd.AddCommand(validateCmd)
+}
+
+var (
+	validateFile string
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the desired state without connecting to the database",
+	RunE:  validate,
+}
+
+func validate(cmd *cobra.Command, args []string) error {
+	req, err := state.LoadYAML(validateFile)
+	if err != nil {
+		return err
+	}
+
+	if err := req.Database().Valid(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s is valid\n", validateFile)
+	return nil
+}
@@ -7,7 +7,6 @@ import (
 	"stijntratsaertit/terramigrate/database/adapter"
 	"stijntratsaertit/terramigrate/objects"
 	"stijntratsaertit/terramigrate/state"
-	"strings"
 
 	"github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
@@ -146,20 +145,400 @@ func (db *database) GetState() *state.State {
 }
 
 func (db *database) LoadState() error {
+	roles, err := db.getRoles()
+	if err != nil {
+		return fmt.Errorf("could not load state: %v", err)
+	}
+
+	extensions, err := db.getExtensions()
+	if err != nil {
+		return fmt.Errorf("could not load state: %v", err)
+	}
+
 	namespaces, err := db.getNamespaces()
 	if err != nil {
 		return fmt.Errorf("could not load state: %v", err)
 	}
 
-	db.state = &state.State{Database: &objects.Database{Name: db.Name, Namespaces: namespaces}}
+	publications, err := db.getPublications()
+	if err != nil {
+		return fmt.Errorf("could not load state: %v", err)
+	}
+
+	subscriptions, err := db.getSubscriptions()
+	if err != nil {
+		return fmt.Errorf("could not load state: %v", err)
+	}
+
+	db.state = &state.State{Database: &objects.Database{Name: db.Name, Roles: roles, Extensions: extensions, Namespaces: namespaces, Publications: publications, Subscriptions: subscriptions}}
 	return nil
 }
 
+// getRoles returns the roles that can be managed: superusers, the predefined
+// pg_* roles, the connected user and the database owner are left out.
+func (db *database) getRoles() ([]*objects.Role, error) {
+	q := `
+		SELECT r.rolname, r.rolcanlogin, r.rolinherit,
+			ARRAY(
+				SELECT g.rolname
+				FROM pg_catalog.pg_auth_members m
+				JOIN pg_catalog.pg_roles g ON g.oid = m.roleid
+				WHERE m.member = r.oid
+				ORDER BY g.rolname
+			)
+		FROM pg_catalog.pg_roles r
+		WHERE NOT r.rolsuper AND r.rolname NOT LIKE 'pg\_%' AND r.rolname <> current_user
+			AND r.oid <> (SELECT datdba FROM pg_catalog.pg_database WHERE datname = current_database())
+		ORDER BY r.rolname;
+	`
+	rows, err := db.connection.Query(q)
+	if err != nil {
+		return nil, fmt.Errorf("could not get roles: %v", err)
+	}
+	defer rows.Close()
+
+	roles := []*objects.Role{}
+	for rows.Next() {
+		var (
+			role    = &objects.Role{}
+			inherit bool
+		)
+		err := rows.Scan(&role.Name, &role.Login, &inherit, (*pq.StringArray)(&role.MemberOf))
+		if err != nil {
+			return nil, fmt.Errorf("could not read role: %v", err)
+		}
+		if !inherit {
+			role.Inherit = &inherit
+		}
+		if len(role.MemberOf) == 0 {
+			role.MemberOf = nil
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// getGrants reads the (grantee, privilege) rows of q, ordered by grantee, into
+// one grant per role.
+func (db *database) getGrants(q string, args ...interface{}) ([]*objects.Grant, error) {
+	rows, err := db.connection.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get grants: %v", err)
+	}
+	defer rows.Close()
+
+	var grants []*objects.Grant
+	for rows.Next() {
+		var role, privilege string
+		if err := rows.Scan(&role, &privilege); err != nil {
+			return nil, fmt.Errorf("could not read grant: %v", err)
+		}
+		if len(grants) == 0 || grants[len(grants)-1].Role != role {
+			grants = append(grants, &objects.Grant{Role: role})
+		}
+		grants[len(grants)-1].Privileges = append(grants[len(grants)-1].Privileges, privilege)
+	}
+	return grants, nil
+}
+
+// getRelationGrants returns the grants on a table or sequence, leaving out
+// those of its owner. kind is the ACL object type: 'r' or 's'.
+func (db *database) getRelationGrants(namespace, name, kind string) ([]*objects.Grant, error) {
+	q := `
+		SELECT CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(acl.grantee) END, acl.privilege_type
+		FROM pg_catalog.pg_class cls
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = cls.relnamespace
+		CROSS JOIN LATERAL aclexplode(COALESCE(cls.relacl, acldefault($3::"char", cls.relowner))) acl
+		WHERE nsp.nspname = $1 AND cls.relname = $2 AND acl.grantee <> cls.relowner
+		ORDER BY 1, 2;
+	`
+	return db.getGrants(q, namespace, name, kind)
+}
+
+func (db *database) getNamespaceGrants(namespace string) ([]*objects.Grant, error) {
+	q := `
+		SELECT CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(acl.grantee) END, acl.privilege_type
+		FROM pg_catalog.pg_namespace nsp
+		CROSS JOIN LATERAL aclexplode(COALESCE(nsp.nspacl, acldefault('n', nsp.nspowner))) acl
+		WHERE nsp.nspname = $1 AND acl.grantee <> nsp.nspowner
+		ORDER BY 1, 2;
+	`
+	return db.getGrants(q, namespace)
+}
+
+// getFunctions returns the functions of the namespace with their grants.
+// Functions belonging to an extension are left out.
+func (db *database) getFunctions(namespace string) ([]*objects.Function, error) {
+	q := `
+		SELECT p.oid, p.proname, pg_get_function_identity_arguments(p.oid)
+		FROM pg_catalog.pg_proc p
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = p.pronamespace
+		WHERE nsp.nspname = $1 AND p.prokind = 'f'
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_catalog.pg_depend dep
+				WHERE dep.classid = 'pg_catalog.pg_proc'::regclass AND dep.objid = p.oid AND dep.deptype = 'e'
+			)
+		ORDER BY 2, 3;
+	`
+	rows, err := db.connection.Query(q, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not get functions: %v", err)
+	}
+	defer rows.Close()
+
+	functions := []*objects.Function{}
+	oids := []int64{}
+	for rows.Next() {
+		var (
+			function = &objects.Function{}
+			oid      int64
+		)
+		if err := rows.Scan(&oid, &function.Name, &function.Arguments); err != nil {
+			return nil, fmt.Errorf("could not read function: %v", err)
+		}
+		functions = append(functions, function)
+		oids = append(oids, oid)
+	}
+
+	grantsQuery := `
+		SELECT CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(acl.grantee) END, acl.privilege_type
+		FROM pg_catalog.pg_proc p
+		CROSS JOIN LATERAL aclexplode(COALESCE(p.proacl, acldefault('f', p.proowner))) acl
+		WHERE p.oid = $1 AND acl.grantee <> p.proowner
+		ORDER BY 1, 2;
+	`
+	for i, function := range functions {
+		grants, err := db.getGrants(grantsQuery, oids[i])
+		if err != nil {
+			return nil, fmt.Errorf("could not get grants for function %s: %v", function.Name, err)
+		}
+		function.Grants = grants
+	}
+	return functions, nil
+}
+
+// getDefaultPrivileges returns the default privileges set for the namespace
+// itself; defaults set for the whole database are not included.
+func (db *database) getDefaultPrivileges(namespace string) ([]*objects.DefaultPrivilege, error) {
+	q := `
+		SELECT pg_get_userbyid(d.defaclrole),
+			CASE d.defaclobjtype WHEN 'r' THEN 'TABLES' WHEN 'S' THEN 'SEQUENCES' WHEN 'f' THEN 'FUNCTIONS' ELSE 'TYPES' END,
+			CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(acl.grantee) END, acl.privilege_type
+		FROM pg_catalog.pg_default_acl d
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = d.defaclnamespace
+		CROSS JOIN LATERAL aclexplode(d.defaclacl) acl
+		WHERE nsp.nspname = $1 AND d.defaclobjtype IN ('r', 'S', 'f', 'T')
+		ORDER BY 1, 2, 3, 4;
+	`
+	rows, err := db.connection.Query(q, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not get default privileges: %v", err)
+	}
+	defer rows.Close()
+
+	var defaults []*objects.DefaultPrivilege
+	for rows.Next() {
+		var role, on, grantee, privilege string
+		if err := rows.Scan(&role, &on, &grantee, &privilege); err != nil {
+			return nil, fmt.Errorf("could not read default privilege: %v", err)
+		}
+		if len(defaults) == 0 || defaults[len(defaults)-1].Role != role || defaults[len(defaults)-1].On != on {
+			defaults = append(defaults, &objects.DefaultPrivilege{Role: role, On: on})
+		}
+		current := defaults[len(defaults)-1]
+		if len(current.Grants) == 0 || current.Grants[len(current.Grants)-1].Role != grantee {
+			current.Grants = append(current.Grants, &objects.Grant{Role: grantee})
+		}
+		current.Grants[len(current.Grants)-1].Privileges = append(current.Grants[len(current.Grants)-1].Privileges, privilege)
+	}
+	return defaults, nil
+}
+
+func (db *database) getExtensions() ([]*objects.Extension, error) {
+	q := `
+		SELECT ext.extname, nsp.nspname, ext.extversion, obj_description(ext.oid, 'pg_extension')
+		FROM pg_extension ext
+		JOIN pg_namespace nsp ON nsp.oid = ext.extnamespace
+		WHERE ext.extname <> 'plpgsql'
+		ORDER BY ext.extname;
+	`
+	rows, err := db.connection.Query(q)
+	if err != nil {
+		return nil, fmt.Errorf("could not get extensions: %v", err)
+	}
+	defer rows.Close()
+
+	extensions := []*objects.Extension{}
+	for rows.Next() {
+		extension := &objects.Extension{}
+		rows.Scan(&extension.Name, &extension.Schema, &extension.Version, &extension.Comment)
+		extensions = append(extensions, extension)
+	}
+	return extensions, nil
+}
+
+// getPublications returns the publications of the database with their
+// tables. Row filters and column lists are only read from PostgreSQL 15 on,
+// as older servers do not support them.
+func (db *database) getPublications() ([]*objects.Publication, error) {
+	var version int
+	if err := db.connection.QueryRow("SELECT current_setting('server_version_num')::int;").Scan(&version); err != nil {
+		return nil, fmt.Errorf("could not get server version: %v", err)
+	}
+
+	q := `
+		SELECT
+			pub.oid, pub.pubname, pub.puballtables,
+			pub.pubinsert, pub.pubupdate, pub.pubdelete, pub.pubtruncate,
+			obj_description(pub.oid, 'pg_publication')
+		FROM pg_catalog.pg_publication pub
+		ORDER BY pub.pubname;
+	`
+	rows, err := db.connection.Query(q)
+	if err != nil {
+		return nil, fmt.Errorf("could not get publications: %v", err)
+	}
+	defer rows.Close()
+
+	oids := []int64{}
+	publications := []*objects.Publication{}
+	for rows.Next() {
+		var (
+			oid                                  int64
+			inserts, updates, deletes, truncates bool
+		)
+		publication := &objects.Publication{}
+		rows.Scan(&oid, &publication.Name, &publication.AllTables, &inserts, &updates, &deletes, &truncates, &publication.Comment)
+
+		for i, published := range []bool{inserts, updates, deletes, truncates} {
+			if published {
+				publication.Publish = append(publication.Publish, objects.PublicationOperations()[i])
+			}
+		}
+		if len(publication.Publish) == len(objects.PublicationOperations()) {
+			publication.Publish = nil
+		}
+
+		oids = append(oids, oid)
+		publications = append(publications, publication)
+	}
+	rows.Close()
+
+	for i, publication := range publications {
+		if publication.AllTables {
+			continue
+		}
+		tables, err := db.getPublicationTables(oids[i], version >= 150000)
+		if err != nil {
+			return nil, fmt.Errorf("could not get tables of publication %s: %v", publication.Name, err)
+		}
+		publication.Tables = tables
+	}
+	return publications, nil
+}
+
+// getSubscriptions returns the subscriptions of the database. Only
+// superusers may read their connections; for other users the connection is
+// left empty, and it is never returned with its password.
+func (db *database) getSubscriptions() ([]*objects.Subscription, error) {
+	var readable bool
+	if err := db.connection.QueryRow("SELECT has_column_privilege('pg_catalog.pg_subscription', 'subconninfo', 'SELECT');").Scan(&readable); err != nil {
+		return nil, fmt.Errorf("could not check access to subscriptions: %v", err)
+	}
+	connection := "''"
+	if readable {
+		connection = "sub.subconninfo"
+	}
+
+	q := fmt.Sprintf(`
+		SELECT
+			sub.subname, %s, sub.subpublications,
+			COALESCE(sub.subslotname::text, ''), sub.subenabled,
+			obj_description(sub.oid, 'pg_subscription')
+		FROM pg_catalog.pg_subscription sub
+		WHERE sub.subdbid = (SELECT oid FROM pg_catalog.pg_database WHERE datname = current_database())
+		ORDER BY sub.subname;
+	`, connection)
+	rows, err := db.connection.Query(q)
+	if err != nil {
+		return nil, fmt.Errorf("could not get subscriptions: %v", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []*objects.Subscription{}
+	for rows.Next() {
+		var (
+			subscription = &objects.Subscription{}
+			enabled      bool
+		)
+		err := rows.Scan(&subscription.Name, &subscription.Connection, (*pq.StringArray)(&subscription.Publications), &subscription.SlotName, &enabled, &subscription.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("could not read subscription: %v", err)
+		}
+		subscription.Connection = objects.ConnectionWithoutPassword(subscription.Connection)
+		if subscription.SlotName == subscription.Name {
+			subscription.SlotName = ""
+		}
+		if !enabled {
+			subscription.Enabled = &enabled
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, nil
+}
+
+func (db *database) getPublicationTables(publication int64, filters bool) ([]*objects.PublicationTable, error) {
+	q := `
+		SELECT format('%s.%s', nsp.nspname, cls.relname), '', ARRAY[]::text[]
+		FROM pg_catalog.pg_publication_rel rel
+		JOIN pg_catalog.pg_class cls ON cls.oid = rel.prrelid
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = cls.relnamespace
+		WHERE rel.prpubid = $1
+		ORDER BY 1;
+	`
+	if filters {
+		q = `
+			SELECT
+				format('%s.%s', nsp.nspname, cls.relname),
+				COALESCE(pg_get_expr(rel.prqual, rel.prrelid, true), ''),
+				ARRAY(
+					SELECT a.attname
+					FROM unnest(rel.prattrs::int2[]) k
+					JOIN pg_catalog.pg_attribute a ON a.attrelid = rel.prrelid AND a.attnum = k
+					ORDER BY a.attnum
+				)
+			FROM pg_catalog.pg_publication_rel rel
+			JOIN pg_catalog.pg_class cls ON cls.oid = rel.prrelid
+			JOIN pg_catalog.pg_namespace nsp ON nsp.oid = cls.relnamespace
+			WHERE rel.prpubid = $1
+			ORDER BY 1;
+		`
+	}
+
+	rows, err := db.connection.Query(q, publication)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []*objects.PublicationTable{}
+	for rows.Next() {
+		table := &objects.PublicationTable{}
+		rows.Scan(&table.Table, &table.Where, (*pq.StringArray)(&table.Columns))
+		if len(table.Columns) == 0 {
+			table.Columns = nil
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
 func (db *database) getNamespaces() ([]*objects.Namespace, error) {
 	q := `
-		SELECT schema_name
+		SELECT schema_name, obj_description(format('%I', schema_name)::regnamespace, 'pg_namespace')
 		FROM information_schema.schemata
-		WHERE schema_name NOT LIKE 'pg_%' AND schema_name NOT LIKE 'information_schema';
+		WHERE schema_name NOT LIKE 'pg_%' AND schema_name NOT LIKE 'information_schema'
+		ORDER BY schema_name;
 	`
 
 	rows, err := db.connection.Query(q)
@@ -171,7 +550,7 @@ func (db *database) getNamespaces() ([]*objects.Namespace, error) {
 	namespaces := []*objects.Namespace{}
 	for rows.Next() {
 		namespace := &objects.Namespace{}
-		rows.Scan(&namespace.Name)
+		rows.Scan(&namespace.Name, &namespace.Comment)
 
 		tables, err := db.GetTables(namespace.Name)
 		if err != nil {
@@ -183,8 +562,38 @@ func (db *database) getNamespaces() ([]*objects.Namespace, error) {
 			return nil, err
 		}
 
+		domains, err := db.getDomains(namespace.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		compositeTypes, err := db.getCompositeTypes(namespace.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		grants, err := db.getNamespaceGrants(namespace.Name)
+		if err != nil {
+			return nil, fmt.Errorf("could not get grants for namespace %s: %v", namespace.Name, err)
+		}
+
+		functions, err := db.getFunctions(namespace.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		defaults, err := db.getDefaultPrivileges(namespace.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		namespace.Domains = domains
+		namespace.CompositeTypes = compositeTypes
 		namespace.Tables = tables
 		namespace.Sequences = sequences
+		namespace.Grants = grants
+		namespace.Functions = functions
+		namespace.DefaultPrivileges = defaults
 		namespaces = append(namespaces, namespace)
 	}
 	return namespaces, nil
@@ -192,9 +601,20 @@ func (db *database) getNamespaces() ([]*objects.Namespace, error) {
 
 func (db *database) GetTables(namespace string) ([]*objects.Table, error) {
 	q := `
-		SELECT tablename
+		SELECT tablename, obj_description(format('%I.%I', schemaname, tablename)::regclass, 'pg_class'),
+			CASE WHEN NOT rowsecurity THEN '' WHEN cls.relforcerowsecurity THEN 'forced' ELSE 'enabled' END,
+			cls.relpersistence = 'u', COALESCE(tablespace, ''),
+			ARRAY(
+				SELECT unnest(cls.reloptions)
+				UNION ALL
+				SELECT 'toast.' || unnest(toast.reloptions)
+				FROM pg_catalog.pg_class toast
+				WHERE toast.oid = cls.reltoastrelid
+			)
 		FROM pg_tables
-		WHERE schemaname = $1;
+		JOIN pg_catalog.pg_class cls ON cls.oid = format('%I.%I', schemaname, tablename)::regclass
+		WHERE schemaname = $1 AND NOT cls.relispartition
+		ORDER BY tablename;
 	`
 	rows, err := db.connection.Query(q, namespace)
 	if err != nil {
@@ -204,9 +624,11 @@ func (db *database) GetTables(namespace string) ([]*objects.Table, error) {
 
 	tables := []*objects.Table{}
 	for rows.Next() {
+		var storageParameters []string
 		table := &objects.Table{}
 
-		rows.Scan(&table.Name)
+		rows.Scan(&table.Name, &table.Comment, &table.RowLevelSecurity, &table.Unlogged, &table.Tablespace, (*pq.StringArray)(&storageParameters))
+		table.With = reloptions(storageParameters)
 		columns, err := db.getColumns(namespace, table.Name)
 		if err != nil {
 			return nil, fmt.Errorf("could not get columns for table %s: %v", table.Name, err)
@@ -222,19 +644,260 @@ func (db *database) GetTables(namespace string) ([]*objects.Table, error) {
 			return nil, fmt.Errorf("could not get indices for table %s: %v", table.Name, err)
 		}
 
+		grants, err := db.getRelationGrants(namespace, table.Name, "r")
+		if err != nil {
+			return nil, fmt.Errorf("could not get grants for table %s: %v", table.Name, err)
+		}
+
 		table.Columns = columns
 		table.Constraints = constraints
+		table.NormalizePrimaryKey()
 		table.Indices = indices
+		policies, err := db.getPolicies(namespace, table.Name)
+		if err != nil {
+			return nil, fmt.Errorf("could not get policies for table %s: %v", table.Name, err)
+		}
+
+		if err := db.getPartitioning(namespace, table); err != nil {
+			return nil, fmt.Errorf("could not get partitions for table %s: %v", table.Name, err)
+		}
+
+		table.Grants = grants
+		table.Policies = policies
 		tables = append(tables, table)
 	}
 	return tables, nil
 }
 
+// getDomains returns the domains of the namespace with their CHECK
+// constraints. Domains belonging to an extension are left out.
+func (db *database) getDomains(namespace string) ([]*objects.Domain, error) {
+	q := `
+		SELECT
+			typ.typname, format_type(typ.typbasetype, typ.typtypmod), COALESCE(typ.typdefault, ''), typ.typnotnull,
+			ARRAY(
+				SELECT con.conname
+				FROM pg_catalog.pg_constraint con
+				WHERE con.contypid = typ.oid AND con.contype = 'c'
+				ORDER BY con.conname
+			),
+			ARRAY(
+				SELECT pg_get_expr(con.conbin, 0, true)
+				FROM pg_catalog.pg_constraint con
+				WHERE con.contypid = typ.oid AND con.contype = 'c'
+				ORDER BY con.conname
+			),
+			obj_description(typ.oid, 'pg_type')
+		FROM pg_catalog.pg_type typ
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = typ.typnamespace
+		WHERE nsp.nspname = $1 AND typ.typtype = 'd'
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_catalog.pg_depend dep
+				WHERE dep.classid = 'pg_catalog.pg_type'::regclass AND dep.objid = typ.oid AND dep.deptype = 'e'
+			)
+		ORDER BY typ.typname;
+	`
+	rows, err := db.connection.Query(q, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not get domains: %v", err)
+	}
+	defer rows.Close()
+
+	domains := []*objects.Domain{}
+	for rows.Next() {
+		var (
+			domain             = &objects.Domain{}
+			names, expressions []string
+		)
+		err := rows.Scan(&domain.Name, &domain.Type, &domain.Default, &domain.NotNull,
+			(*pq.StringArray)(&names), (*pq.StringArray)(&expressions), &domain.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("could not read domain: %v", err)
+		}
+		for i, name := range names {
+			domain.Checks = append(domain.Checks, &objects.DomainCheck{Name: name, Expression: expressions[i]})
+		}
+		domains = append(domains, domain)
+	}
+	return domains, nil
+}
+
+// getCompositeTypes returns the standalone composite types of the namespace;
+// the row types of tables are left out.
+func (db *database) getCompositeTypes(namespace string) ([]*objects.CompositeType, error) {
+	q := `
+		SELECT
+			typ.typname,
+			ARRAY(
+				SELECT a.attname
+				FROM pg_catalog.pg_attribute a
+				WHERE a.attrelid = typ.typrelid AND a.attnum > 0 AND NOT a.attisdropped
+				ORDER BY a.attnum
+			),
+			ARRAY(
+				SELECT format_type(a.atttypid, a.atttypmod)
+				FROM pg_catalog.pg_attribute a
+				WHERE a.attrelid = typ.typrelid AND a.attnum > 0 AND NOT a.attisdropped
+				ORDER BY a.attnum
+			),
+			obj_description(typ.oid, 'pg_type')
+		FROM pg_catalog.pg_type typ
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = typ.typnamespace
+		JOIN pg_catalog.pg_class cls ON cls.oid = typ.typrelid
+		WHERE nsp.nspname = $1 AND typ.typtype = 'c' AND cls.relkind = 'c'
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_catalog.pg_depend dep
+				WHERE dep.classid = 'pg_catalog.pg_type'::regclass AND dep.objid = typ.oid AND dep.deptype = 'e'
+			)
+		ORDER BY typ.typname;
+	`
+	rows, err := db.connection.Query(q, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not get composite types: %v", err)
+	}
+	defer rows.Close()
+
+	compositeTypes := []*objects.CompositeType{}
+	for rows.Next() {
+		var (
+			compositeType = &objects.CompositeType{}
+			names, types  []string
+		)
+		err := rows.Scan(&compositeType.Name, (*pq.StringArray)(&names), (*pq.StringArray)(&types), &compositeType.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("could not read composite type: %v", err)
+		}
+		for i, name := range names {
+			compositeType.Attributes = append(compositeType.Attributes, &objects.Attribute{Name: name, Type: types[i]})
+		}
+		compositeTypes = append(compositeTypes, compositeType)
+	}
+	return compositeTypes, nil
+}
+
+// getPartitioning sets the partition key and partitions of a partitioned
+// table. Partitions in another namespace than their table are left out.
+func (db *database) getPartitioning(namespace string, table *objects.Table) error {
+	q := `
+		SELECT pt.partstrat,
+			ARRAY(
+				SELECT COALESCE(a.attname, '')
+				FROM generate_series(1, pt.partnatts) AS k
+				LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = pt.partrelid AND a.attnum = pt.partattrs[k - 1]
+				ORDER BY k
+			)
+		FROM pg_catalog.pg_partitioned_table pt
+		JOIN pg_catalog.pg_class tbl ON tbl.oid = pt.partrelid
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = tbl.relnamespace
+		WHERE nsp.nspname = $1 AND tbl.relname = $2;
+	`
+	var (
+		strategy string
+		columns  []string
+	)
+	err := db.connection.QueryRow(q, namespace, table.Name).Scan(&strategy, (*pq.StringArray)(&columns))
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not read partition key: %v", err)
+	}
+	table.PartitionBy = &objects.PartitionBy{Strategy: objects.GetPartitionStrategyFromCode(strategy), Columns: columns}
+
+	q = `
+		SELECT child.relname, pg_get_expr(child.relpartbound, child.oid)
+		FROM pg_catalog.pg_inherits inh
+		JOIN pg_catalog.pg_class child ON child.oid = inh.inhrelid
+		JOIN pg_catalog.pg_class parent ON parent.oid = inh.inhparent
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = parent.relnamespace
+		WHERE nsp.nspname = $1 AND parent.relname = $2
+			AND child.relispartition AND child.relnamespace = parent.relnamespace
+		ORDER BY child.relname;
+	`
+	rows, err := db.connection.Query(q, namespace, table.Name)
+	if err != nil {
+		return fmt.Errorf("could not get partitions: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, bound string
+		if err := rows.Scan(&name, &bound); err != nil {
+			return fmt.Errorf("could not read partition: %v", err)
+		}
+		table.Partitions = append(table.Partitions, partitionFromBound(name, bound))
+	}
+	return nil
+}
+
+func (db *database) getPolicies(namespace, tableName string) ([]*objects.Policy, error) {
+	q := `
+		SELECT
+			pol.polname, pol.polcmd, NOT pol.polpermissive,
+			ARRAY(
+				SELECT CASE WHEN r.oid = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(r.oid) END
+				FROM unnest(pol.polroles) AS r(oid)
+				ORDER BY 1
+			),
+			COALESCE(pg_get_expr(pol.polqual, pol.polrelid, true), ''),
+			COALESCE(pg_get_expr(pol.polwithcheck, pol.polrelid, true), '')
+		FROM pg_catalog.pg_policy pol
+		JOIN pg_catalog.pg_class tbl ON tbl.oid = pol.polrelid
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = tbl.relnamespace
+		WHERE nsp.nspname = $1 AND tbl.relname = $2
+		ORDER BY pol.polname;
+	`
+	rows, err := db.connection.Query(q, namespace, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("could not get policies for table %s: %v", tableName, err)
+	}
+	defer rows.Close()
+
+	policies := []*objects.Policy{}
+	for rows.Next() {
+		var (
+			policy  = &objects.Policy{}
+			command string
+		)
+		err := rows.Scan(&policy.Name, &command, &policy.Restrictive, (*pq.StringArray)(&policy.Roles), &policy.Using, &policy.WithCheck)
+		if err != nil {
+			return nil, fmt.Errorf("could not read policy of table %s: %v", tableName, err)
+		}
+		if command != "*" {
+			policy.Command = objects.GetPolicyCommandFromCode(command)
+		}
+		if len(policy.Roles) == 1 && policy.Roles[0] == "PUBLIC" {
+			policy.Roles = nil
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
 func (db *database) getSequences(namespace string) ([]*objects.Sequence, error) {
 	q := `
-		SELECT sequence_name, data_type
-		FROM information_schema.sequences
-		WHERE sequence_schema = $1;
+		SELECT
+			seq.sequencename, seq.data_type::text,
+			seq.start_value, seq.increment_by, seq.min_value, seq.max_value, seq.cache_size, seq.cycle,
+			COALESCE(CASE
+				WHEN tnsp.nspname = seq.schemaname THEN format('%s.%s', tbl.relname, a.attname)
+				ELSE format('%s.%s.%s', tnsp.nspname, tbl.relname, a.attname)
+			END, '') AS owned_by,
+			obj_description(cls.oid, 'pg_class') AS comment
+		FROM pg_catalog.pg_sequences seq
+		JOIN pg_catalog.pg_namespace nsp ON nsp.nspname = seq.schemaname
+		JOIN pg_catalog.pg_class cls ON cls.relnamespace = nsp.oid AND cls.relname = seq.sequencename
+		LEFT JOIN pg_catalog.pg_depend dep
+			ON dep.classid = 'pg_catalog.pg_class'::regclass AND dep.objid = cls.oid
+			AND dep.refclassid = 'pg_catalog.pg_class'::regclass AND dep.refobjsubid > 0 AND dep.deptype = 'a'
+		LEFT JOIN pg_catalog.pg_class tbl ON tbl.oid = dep.refobjid
+		LEFT JOIN pg_catalog.pg_namespace tnsp ON tnsp.oid = tbl.relnamespace
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = dep.refobjid AND a.attnum = dep.refobjsubid
+		WHERE seq.schemaname = $1
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_catalog.pg_depend idep
+				WHERE idep.classid = 'pg_catalog.pg_class'::regclass AND idep.objid = cls.oid AND idep.deptype = 'i'
+			)
+		ORDER BY seq.sequencename;
 	`
 	rows, err := db.connection.Query(q, namespace)
 	if err != nil {
@@ -243,20 +906,58 @@ func (db *database) getSequences(namespace string) ([]*objects.Sequence, error)
 	defer rows.Close()
 
 	sequences := []*objects.Sequence{}
-	var seqName, seqType string
-
 	for rows.Next() {
-		rows.Scan(&seqName, &seqType)
-		sequences = append(sequences, &objects.Sequence{Name: seqName, Type: seqType})
+		var (
+			sequence                   = &objects.Sequence{}
+			start, increment, minValue int64
+			maxValue, cache            int64
+		)
+
+		err := rows.Scan(&sequence.Name, &sequence.Type, &start, &increment, &minValue, &maxValue, &cache, &sequence.Cycle, &sequence.OwnedBy, &sequence.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("could not read sequence: %v", err)
+		}
+
+		options := objects.SequenceOptions{Start: &start, Increment: increment, MinValue: &minValue, MaxValue: &maxValue, Cache: cache, Cycle: sequence.Cycle}
+		sequence.SequenceOptions = options.Simplify(sequence.Type)
+		sequences = append(sequences, sequence)
+	}
+
+	for _, sequence := range sequences {
+		grants, err := db.getRelationGrants(namespace, sequence.Name, "s")
+		if err != nil {
+			return nil, fmt.Errorf("could not get grants for sequence %s: %v", sequence.Name, err)
+		}
+		sequence.Grants = grants
 	}
 	return sequences, nil
 }
 
+// getColumns returns the columns of the table in the order they are stored,
+// which is the order they are exported in.
 func (db *database) getColumns(namespace, table string) ([]*objects.Column, error) {
 	q := `
-		SELECT column_name, data_type, column_default, is_nullable, character_maximum_length
-		FROM information_schema.columns
-		WHERE table_schema = $1 AND table_name = $2;
+		SELECT
+			a.attname, format_type(a.atttypid, a.atttypmod), a.attndims,
+			pg_get_expr(def.adbin, def.adrelid), NOT a.attnotnull,
+			a.attidentity, a.attgenerated,
+			seq.seqstart, seq.seqincrement, seq.seqmin, seq.seqmax, seq.seqcache, seq.seqcycle,
+			col_description(a.attrelid, a.attnum),
+			COALESCE((
+				SELECT CASE WHEN collnsp.nspname = 'pg_catalog' THEN coll.collname ELSE collnsp.nspname || '.' || coll.collname END
+				FROM pg_catalog.pg_collation coll
+				JOIN pg_catalog.pg_namespace collnsp ON collnsp.oid = coll.collnamespace
+				WHERE coll.oid = a.attcollation AND a.attcollation <> typ.typcollation
+			), '')
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_type typ ON typ.oid = a.atttypid
+		JOIN pg_catalog.pg_class cls ON cls.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = cls.relnamespace
+		LEFT JOIN pg_catalog.pg_attrdef def ON def.adrelid = a.attrelid AND def.adnum = a.attnum
+		LEFT JOIN pg_catalog.pg_sequence seq
+			ON a.attidentity <> '' AND seq.seqrelid = pg_get_serial_sequence(format('%I.%I', nsp.nspname, cls.relname), a.attname)::regclass
+		WHERE nsp.nspname = $1 AND cls.relname = $2 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum;
 	`
 	rows, err := db.connection.Query(q, namespace, table)
 	if err != nil {
@@ -266,27 +967,70 @@ func (db *database) getColumns(namespace, table string) ([]*objects.Column, erro
 	columns := []*objects.Column{}
 	for rows.Next() {
 		var (
-			columnDefault                    string
-			columnDefaultRef                 sql.NullString
-			characterMaximumLengthRef        sql.NullInt64
-			columnName, dataType, isNullable string
+			expression                string
+			expressionRef             sql.NullString
+			columnName, formattedType string
+			arrayDimensions           int
+			nullable                  bool
+			identity, generated       string
+			seqStart, seqIncrement    sql.NullInt64
+			seqMin, seqMax, seqCache  sql.NullInt64
+			seqCycle                  sql.NullBool
+			comment                   *string
+			collation                 string
 		)
 
-		rows.Scan(&columnName, &dataType, &columnDefaultRef, &isNullable, &characterMaximumLengthRef)
+		rows.Scan(&columnName, &formattedType, &arrayDimensions, &expressionRef, &nullable,
+			&identity, &generated,
+			&seqStart, &seqIncrement, &seqMin, &seqMax, &seqCache, &seqCycle, &comment, &collation)
+
+		dataType, err := objects.ParseType(formattedType)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("could not parse type of column %s: %v", columnName, err)
+		}
+		dataType.ArrayDimensions = max(dataType.ArrayDimensions, arrayDimensions)
 
-		if columnDefaultRef.Valid {
-			columnDefault = strings.Replace(columnDefaultRef.String, "::"+dataType, "", -1)
-		} else {
-			columnDefault = ""
+		if expressionRef.Valid {
+			expression = expressionRef.String
 		}
 
-		columns = append(columns, &objects.Column{
-			Name:      columnName,
-			Type:      strings.ToUpper(dataType),
-			Default:   columnDefault,
-			Nullable:  isNullable == "YES",
-			MaxLength: int(characterMaximumLengthRef.Int64),
-		})
+		column := &objects.Column{
+			Name:            columnName,
+			Type:            dataType.NameSQL(),
+			MaxLength:       dataType.MaxLength,
+			Precision:       dataType.Precision,
+			Scale:           dataType.Scale,
+			ArrayDimensions: dataType.ArrayDimensions,
+			Default:         expression,
+			Nullable:        nullable,
+			Collation:       collation,
+			Comment:         comment,
+		}
+
+		if generated == "s" {
+			column.Default = ""
+			column.Generated = expression
+		}
+
+		if identity != "" {
+			column.Identity = &objects.ColumnIdentity{Generation: objects.IdentityGenerationAlways}
+			if identity == "d" {
+				column.Identity.Generation = objects.IdentityGenerationByDefault
+			}
+			if seqIncrement.Valid {
+				column.Identity.SequenceOptions = objects.SequenceOptions{
+					Start:     &seqStart.Int64,
+					Increment: seqIncrement.Int64,
+					MinValue:  &seqMin.Int64,
+					MaxValue:  &seqMax.Int64,
+					Cache:     seqCache.Int64,
+					Cycle:     seqCycle.Bool,
+				}.Simplify(dataType.Name)
+			}
+		}
+
+		columns = append(columns, column)
 	}
 
 	return columns, nil
@@ -300,25 +1044,60 @@ func (db *database) getConstraints(namespace, tableName string) ([]*objects.Cons
 			confupdtype AS update_action,
 			confdeltype AS delete_action,
 			ARRAY(
-				SELECT column_name 
-				FROM information_schema.columns
-				WHERE table_name = rel2.relname AND ordinal_position IN (
-					SELECT ord_pos FROM UNNEST(con.conkey) ord_pos
-				)
-				) AS source_columns,
-			rel1.relname AS referenced_table,
+				SELECT a.attname
+				FROM UNNEST(con.conkey) WITH ORDINALITY AS k(attnum, n)
+				JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+				ORDER BY k.n
+			) AS source_columns,
+			COALESCE(rnsp.nspname, '') AS referenced_schema,
+			COALESCE(rel1.relname, '') AS referenced_table,
 			ARRAY(
-				SELECT column_name 
-				FROM information_schema.columns
-				WHERE table_name = rel1.relname AND ordinal_position IN (
-					SELECT ord_pos FROM UNNEST(con.confkey) ord_pos
-				)
-			) AS referenced_columns
+				SELECT a.attname
+				FROM UNNEST(con.confkey) WITH ORDINALITY AS k(attnum, n)
+				JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+				ORDER BY k.n
+			) AS referenced_columns,
+			con.confmatchtype = 'f' AS match_full,
+			con.condeferrable, con.condeferred, NOT con.convalidated AS not_valid,
+			COALESCE(am.amname, '') AS exclusion_using,
+			ARRAY(
+				SELECT COALESCE(a.attname, '')
+				FROM generate_series(1, ix.indnkeyatts) AS k
+				LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = ix.indkey[k - 1]
+				WHERE con.contype = 'x'
+				ORDER BY k
+			) AS exclusion_columns,
+			ARRAY(
+				SELECT pg_get_indexdef(ix.indexrelid, k, true)
+				FROM generate_series(1, ix.indnkeyatts) AS k
+				WHERE con.contype = 'x'
+				ORDER BY k
+			) AS exclusion_definitions,
+			ARRAY(
+				SELECT CASE WHEN opc.opcdefault THEN '' ELSE opc.opcname END
+				FROM generate_series(1, ix.indnkeyatts) AS k
+				JOIN pg_catalog.pg_opclass opc ON opc.oid = ix.indclass[k - 1]
+				WHERE con.contype = 'x'
+				ORDER BY k
+			) AS exclusion_opclasses,
+			ARRAY(
+				SELECT op.oprname
+				FROM UNNEST(con.conexclop) WITH ORDINALITY AS e(oid, k)
+				JOIN pg_catalog.pg_operator op ON op.oid = e.oid
+				ORDER BY e.k
+			) AS exclusion_operators,
+			COALESCE(pg_get_expr(ix.indpred, ix.indrelid, true), '') AS exclusion_where,
+			obj_description(con.oid, 'pg_constraint') AS comment
 		FROM pg_constraint con
 		LEFT JOIN pg_catalog.pg_class rel1 ON rel1.oid = con.confrelid
+		LEFT JOIN pg_catalog.pg_namespace rnsp ON rnsp.oid = rel1.relnamespace
 		JOIN pg_catalog.pg_class rel2 ON rel2.oid = con.conrelid
 		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = connamespace
-		WHERE nspname = $1 AND rel2.relname = $2;
+		LEFT JOIN pg_catalog.pg_index ix ON con.contype = 'x' AND ix.indexrelid = con.conindid
+		LEFT JOIN pg_catalog.pg_class idx ON idx.oid = ix.indexrelid
+		LEFT JOIN pg_catalog.pg_am am ON am.oid = idx.relam
+		WHERE nspname = $1 AND rel2.relname = $2
+		ORDER BY con.conname;
 	`
 
 	rows, err := db.connection.Query(q, namespace, tableName)
@@ -330,21 +1109,45 @@ func (db *database) getConstraints(namespace, tableName string) ([]*objects.Cons
 	for rows.Next() {
 		var (
 			cName, cType, cUpdate, cDelete, cRefTable string
+			cRefSchema                                string
 			cSourceColumns, cRefColumns               []string
+			cMatchFull, cDeferrable, cDeferred        bool
+			cNotValid                                 bool
+			cComment                                  *string
+			xUsing, xWhere                            string
+			xColumns, xDefinitions, xOpclasses        []string
+			xOperators                                []string
 		)
 
-		rows.Scan(&cName, &cType, &cUpdate, &cDelete, (*pq.StringArray)(&cSourceColumns), &cRefTable, (*pq.StringArray)(&cRefColumns))
-		constraints = append(constraints, &objects.Constraint{
-			Name:    cName,
-			Type:    objects.GetConstraintTypeFromCode(cType),
-			Targets: cSourceColumns,
-			Reference: &objects.ConstraintReference{
+		rows.Scan(&cName, &cType, &cUpdate, &cDelete, (*pq.StringArray)(&cSourceColumns), &cRefSchema, &cRefTable, (*pq.StringArray)(&cRefColumns),
+			&cMatchFull, &cDeferrable, &cDeferred, &cNotValid,
+			&xUsing, (*pq.StringArray)(&xColumns), (*pq.StringArray)(&xDefinitions), (*pq.StringArray)(&xOpclasses), (*pq.StringArray)(&xOperators), &xWhere, &cComment)
+		constraint := &objects.Constraint{
+			Name:              cName,
+			Type:              objects.GetConstraintTypeFromCode(cType),
+			Targets:           cSourceColumns,
+			OnDelete:          objects.GetConstraintActionFromCode(cDelete),
+			OnUpdate:          objects.GetConstraintActionFromCode(cUpdate),
+			MatchFull:         cMatchFull,
+			Deferrable:        cDeferrable,
+			InitiallyDeferred: cDeferred,
+			NotValid:          cNotValid,
+			Comment:           cComment,
+		}
+		if constraint.Type == objects.ConstraintTypeForeignKey {
+			constraint.Reference = &objects.ConstraintReference{
+				Schema:  cRefSchema,
 				Table:   cRefTable,
 				Columns: cRefColumns,
-			},
-			OnDelete: objects.GetConstraintActionFromCode(cDelete),
-			OnUpdate: objects.GetConstraintActionFromCode(cUpdate),
-		})
+			}
+		}
+		if constraint.Type == objects.ConstraintTypeExclusion {
+			constraint.Targets = nil
+			constraint.Using = objects.IndexAlgorithm(xUsing)
+			constraint.Where = xWhere
+			constraint.Exclusions = exclusionElements(xColumns, xDefinitions, xOpclasses, xOperators)
+		}
+		constraints = append(constraints, constraint)
 	}
 
 	return constraints, nil
@@ -352,27 +1155,71 @@ func (db *database) getConstraints(namespace, tableName string) ([]*objects.Cons
 
 func (db *database) getIndices(namespace, tableName string) ([]*objects.Index, error) {
 	q := `
-		SELECT indexdef
-		FROM pg_indexes
-		WHERE schemaname = $1 AND tablename = $2;
+		SELECT
+			idx.relname, ix.indisunique, am.amname, ix.indnkeyatts,
+			ARRAY(
+				SELECT COALESCE(a.attname, '')
+				FROM generate_series(1, ix.indnatts) AS k
+				LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = ix.indkey[k - 1]
+				ORDER BY k
+			),
+			ARRAY(
+				SELECT pg_get_indexdef(ix.indexrelid, k, true)
+				FROM generate_series(1, ix.indnatts) AS k
+				ORDER BY k
+			),
+			ARRAY(
+				SELECT CASE WHEN opc.opcdefault THEN '' ELSE opc.opcname END
+				FROM generate_series(1, ix.indnkeyatts) AS k
+				JOIN pg_catalog.pg_opclass opc ON opc.oid = ix.indclass[k - 1]
+				ORDER BY k
+			),
+			ARRAY(
+				SELECT ix.indoption[k - 1]
+				FROM generate_series(1, ix.indnkeyatts) AS k
+				ORDER BY k
+			),
+			COALESCE(pg_get_expr(ix.indpred, ix.indrelid, true), ''),
+			COALESCE(idx.reloptions, '{}'),
+			obj_description(ix.indexrelid, 'pg_class')
+		FROM pg_catalog.pg_index ix
+		JOIN pg_catalog.pg_class idx ON idx.oid = ix.indexrelid
+		JOIN pg_catalog.pg_class tbl ON tbl.oid = ix.indrelid
+		JOIN pg_catalog.pg_namespace nsp ON nsp.oid = tbl.relnamespace
+		JOIN pg_catalog.pg_am am ON am.oid = idx.relam
+		WHERE nsp.nspname = $1 AND tbl.relname = $2
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_catalog.pg_constraint con
+				WHERE con.conindid = ix.indexrelid AND con.conrelid = ix.indrelid AND con.contype IN ('p', 'u', 'x')
+			)
+		ORDER BY idx.relname;
 	`
 
 	rows, err := db.connection.Query(q, namespace, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("could not get indices for table %s: %v", tableName, err)
 	}
+	defer rows.Close()
 
 	indices := []*objects.Index{}
 	for rows.Next() {
-		var definition string
-		rows.Scan(&definition)
+		var (
+			index                           = &objects.Index{}
+			keyCount                        int
+			columns, definitions, opclasses []string
+			options                         []int64
+			storageParameters               []string
+		)
 
-		index, err := parseIndexDefinition(definition)
+		err := rows.Scan(&index.Name, &index.Unique, &index.Algorithm, &keyCount,
+			(*pq.StringArray)(&columns), (*pq.StringArray)(&definitions), (*pq.StringArray)(&opclasses),
+			(*pq.Int64Array)(&options), &index.Where, (*pq.StringArray)(&storageParameters), &index.Comment)
 		if err != nil {
-			rows.Close()
-			return nil, fmt.Errorf("could not parse index definition %s: %v", definition, err)
+			return nil, fmt.Errorf("could not read index of table %s: %v", tableName, err)
 		}
 
+		setIndexKeys(index, keyCount, columns, definitions, opclasses, options)
+		index.With = reloptions(storageParameters)
 		indices = append(indices, index)
 	}
 
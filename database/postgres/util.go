@@ -1,26 +1,103 @@
 package postgres
 
 import (
-	"fmt"
-	"regexp"
 	"stijntratsaertit/terramigrate/objects"
 	"strings"
 )
 
-var (
-	indexDefinitionRegex = regexp.MustCompile(`CREATE( UNIQUE)? INDEX (\w+) ON (\w+)\.(\w+) USING (\w+) \((.+)\)`)
+const (
+	indexOptionDesc       = 1
+	indexOptionNullsFirst = 2
 )
 
-func parseIndexDefinition(indexDef string) (*objects.Index, error) {
-	matches := indexDefinitionRegex.FindStringSubmatch(indexDef)
-	if len(matches) != 7 {
-		return nil, fmt.Errorf("could not extract index definition from %s", indexDef)
+// setIndexKeys fills the keys and INCLUDE columns of an index from the
+// per-column details read from pg_index. Indexes whose keys are all bare
+// columns keep the compact Columns form.
+func setIndexKeys(index *objects.Index, keyCount int, columns, definitions, opclasses []string, options []int64) {
+	keys := []*objects.IndexKey{}
+	include := []string{}
+
+	for i, definition := range definitions {
+		if i >= keyCount {
+			include = append(include, columns[i])
+			continue
+		}
+
+		key := &objects.IndexKey{Column: columns[i]}
+		if key.Column == "" {
+			key.Expression = definition
+		}
+		if i < len(opclasses) {
+			key.Opclass = opclasses[i]
+		}
+		if i < len(options) {
+			desc := options[i]&indexOptionDesc != 0
+			nullsFirst := options[i]&indexOptionNullsFirst != 0
+			if desc {
+				key.Order = objects.IndexOrderDesc
+			}
+			if nullsFirst && !desc {
+				key.Nulls = objects.IndexNullsFirst
+			} else if !nullsFirst && desc {
+				key.Nulls = objects.IndexNullsLast
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	if len(include) > 0 {
+		index.Include = include
 	}
+	for _, key := range keys {
+		if !key.IsPlain() {
+			index.Keys = keys
+			return
+		}
+	}
+	for _, key := range keys {
+		index.Columns = append(index.Columns, key.Column)
+	}
+}
 
-	return &objects.Index{
-		Name:      matches[2],
-		Unique:    matches[1] != "",
-		Algorithm: objects.IndexAlgorithm(matches[5]),
-		Columns:   strings.Split(matches[6], ", "),
-	}, nil
+// reloptions parses the name=value entries of the storage parameters of a
+// table or index.
+func reloptions(options []string) map[string]string {
+	if len(options) == 0 {
+		return nil
+	}
+	params := map[string]string{}
+	for _, option := range options {
+		name, value, _ := strings.Cut(option, "=")
+		params[name] = value
+	}
+	return params
+}
+
+// exclusionElements pairs the keys of the index behind an exclusion
+// constraint with the operators of the constraint.
+func exclusionElements(columns, definitions, opclasses, operators []string) []*objects.ExclusionElement {
+	elements := []*objects.ExclusionElement{}
+	for i, definition := range definitions {
+		element := &objects.ExclusionElement{IndexKey: objects.IndexKey{Column: columns[i]}}
+		if element.Column == "" {
+			element.Expression = definition
+		}
+		if i < len(opclasses) {
+			element.Opclass = opclasses[i]
+		}
+		if i < len(operators) {
+			element.Operator = operators[i]
+		}
+		elements = append(elements, element)
+	}
+	return elements
+}
+
+// partitionFromBound builds a partition from its bounds as printed by
+// pg_get_expr, e.g. "FOR VALUES IN ('eu')" or "DEFAULT".
+func partitionFromBound(name, bound string) *objects.Partition {
+	if bound == "DEFAULT" {
+		return &objects.Partition{Name: name, Default: true}
+	}
+	return &objects.Partition{Name: name, Values: strings.TrimPrefix(bound, "FOR VALUES ")}
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"stijntratsaertit/terramigrate/migration"
@@ -25,6 +27,9 @@ func validateNamespaces(t *testing.T, namespaces []*objects.Namespace) {
 			t.Fatalf("validation failed for namespace %s: %v", ns.Name, err)
 		}
 	}
+	if err := (&objects.Database{Namespaces: namespaces}).Valid(); err != nil {
+		t.Fatalf("validation failed: %v", err)
+	}
 }
 
 func diffActions(t *testing.T, existing, desired []*objects.Namespace) []string {
@@ -140,7 +145,7 @@ func TestE2E_Blog_FreshDeploy(t *testing.T) {
 	assertContainsE2E(t, actions, "CREATE SEQUENCE public.posts_id_seq")
 	assertContainsE2E(t, actions, "CREATE SEQUENCE public.comments_id_seq")
 	assertContainsE2E(t, actions, "CONSTRAINT posts_author_fk FOREIGN KEY")
-	assertContainsE2E(t, actions, "REFERENCES users (id)")
+	assertContainsE2E(t, actions, "REFERENCES public.users (id)")
 	assertContainsE2E(t, actions, "ON DELETE CASCADE")
 }
 
@@ -351,8 +356,10 @@ func TestE2E_DownMigration_ReversedOrder(t *testing.T) {
 	if seqIdx == -1 || tableIdx == -1 {
 		t.Fatalf("missing expected down actions in:\n%s", downSQL)
 	}
-	if seqIdx >= tableIdx {
-		t.Errorf("sequence drop (line %d) should come before table drop (line %d) in reversed order", seqIdx, tableIdx)
+	// Sequences are created before the tables whose defaults use them, so the
+	// down migration drops them only after the tables.
+	if seqIdx <= tableIdx {
+		t.Errorf("sequence drop (line %d) should come after table drop (line %d) in reversed order", seqIdx, tableIdx)
 	}
 }
 
@@ -380,3 +387,456 @@ func TestE2E_ExportRoundtrip(t *testing.T) {
 			len(actions), strings.Join(actions, "\n  "))
 	}
 }
+
+// --- Default normalization ---
+
+func TestE2E_Ecommerce_NoopAgainstReportedDefaults(t *testing.T) {
+	desired := loadExample(t, "ecommerce.yaml")
+	existing := loadExample(t, "ecommerce.yaml")
+
+	for _, ns := range existing {
+		for _, tbl := range ns.Tables {
+			for _, col := range tbl.Columns {
+				switch {
+				case strings.HasPrefix(col.Default, "nextval("):
+					col.Default = strings.Replace(col.Default, "')", "'::regclass)", 1)
+				case col.Default == "NOW()":
+					col.Default = "now()"
+				case strings.HasPrefix(col.Default, "'") && col.Type == "CHARACTER VARYING":
+					col.Default += "::character varying"
+				}
+			}
+		}
+	}
+
+	actions := diffActions(t, existing, desired)
+	if len(actions) != 0 {
+		t.Errorf("expected no actions against defaults as reported by PostgreSQL, got %d:\n  %s",
+			len(actions), strings.Join(actions, "\n  "))
+	}
+}
+
+func TestE2E_ExportRoundtrip_Roles(t *testing.T) {
+	inherit := false
+	s := &state.State{Database: &objects.Database{
+		Name: "test",
+		Roles: []*objects.Role{
+			{Name: "readers", Inherit: &inherit},
+			{Name: "app", Login: true, MemberOf: []string{"readers"}},
+		},
+		Namespaces: loadExample(t, "simple.yaml"),
+	}}
+
+	exportPath := filepath.Join(t.TempDir(), "exported.yaml")
+	if err := s.ExportYAML(exportPath); err != nil {
+		t.Fatalf("could not export: %v", err)
+	}
+
+	reloaded, err := state.LoadYAML(exportPath)
+	if err != nil {
+		t.Fatalf("could not reload exported YAML: %v", err)
+	}
+
+	actions := []string{}
+	for _, m := range state.CompareDatabase(s.Database, reloaded.Database()) {
+		actions = append(actions, m.GetActions()...)
+	}
+	if len(reloaded.Roles) != 2 || len(actions) != 0 {
+		t.Errorf("expected roles to survive the export roundtrip, got %d roles and actions:\n  %s",
+			len(reloaded.Roles), strings.Join(actions, "\n  "))
+	}
+}
+
+func TestE2E_ExportRoundtrip_Publications(t *testing.T) {
+	comment := "Feeds the warehouse"
+	s := &state.State{Database: &objects.Database{
+		Name:       "test",
+		Namespaces: loadExample(t, "simple.yaml"),
+		Publications: []*objects.Publication{
+			{Name: "cdc", Tables: []*objects.PublicationTable{{Table: "public.users", Columns: []string{"id"}, Where: "id > 0"}}, Publish: []string{"insert"}},
+			{Name: "everything", AllTables: true, Comment: &comment},
+		},
+	}}
+
+	exportPath := filepath.Join(t.TempDir(), "exported.yaml")
+	if err := s.ExportYAML(exportPath); err != nil {
+		t.Fatalf("could not export: %v", err)
+	}
+
+	reloaded, err := state.LoadYAML(exportPath)
+	if err != nil {
+		t.Fatalf("could not reload exported YAML: %v", err)
+	}
+
+	actions := []string{}
+	for _, m := range state.CompareDatabase(s.Database, reloaded.Database()) {
+		actions = append(actions, m.GetActions()...)
+	}
+	if len(reloaded.Publications) != 2 || len(actions) != 0 {
+		t.Errorf("expected publications to survive the export roundtrip, got %d publications and actions:\n  %s",
+			len(reloaded.Publications), strings.Join(actions, "\n  "))
+	}
+}
+
+func TestE2E_ExportRoundtrip_Subscriptions(t *testing.T) {
+	disabled := false
+	comment := "Paused during maintenance"
+	s := &state.State{Database: &objects.Database{
+		Name:       "test",
+		Namespaces: loadExample(t, "simple.yaml"),
+		Subscriptions: []*objects.Subscription{
+			{Name: "orders_sub", Connection: "host=primary dbname=app user=replicator", Publications: []string{"cdc", "audit"}},
+			{Name: "standby", Connection: "host=standby dbname=app", Publications: []string{"cdc"}, SlotName: "standby_slot", Enabled: &disabled, Comment: &comment},
+		},
+	}}
+
+	exportPath := filepath.Join(t.TempDir(), "exported.yaml")
+	if err := s.ExportYAML(exportPath); err != nil {
+		t.Fatalf("could not export: %v", err)
+	}
+
+	reloaded, err := state.LoadYAML(exportPath)
+	if err != nil {
+		t.Fatalf("could not reload exported YAML: %v", err)
+	}
+
+	actions := []string{}
+	for _, m := range state.CompareDatabase(s.Database, reloaded.Database()) {
+		actions = append(actions, m.GetActions()...)
+	}
+	if len(reloaded.Subscriptions) != 2 || len(actions) != 0 {
+		t.Errorf("expected subscriptions to survive the export roundtrip, got %d subscriptions and actions:\n  %s",
+			len(reloaded.Subscriptions), strings.Join(actions, "\n  "))
+	}
+}
+
+func TestE2E_ExportRoundtrip_Templates(t *testing.T) {
+	for _, example := range []string{"simple.yaml", "blog.yaml", "ecommerce.yaml"} {
+		original := loadExample(t, example)
+
+		s := &state.State{Database: &objects.Database{Name: "test", Namespaces: original}}
+		exportPath := filepath.Join(t.TempDir(), "exported.yaml")
+		if err := s.Request(true).WriteYAML(exportPath); err != nil {
+			t.Fatalf("could not export %s: %v", example, err)
+		}
+
+		reloaded, err := state.LoadYAML(exportPath)
+		if err != nil {
+			t.Fatalf("could not reload %s exported with templates: %v", example, err)
+		}
+
+		actions := diffActions(t, original, reloaded.Namespaces)
+		if len(actions) != 0 {
+			t.Errorf("expected no diff after exporting %s with templates, got %d actions:\n  %s",
+				example, len(actions), strings.Join(actions, "\n  "))
+		}
+		if warnings := state.ColumnOrderWarnings(s.Database, reloaded.Database()); len(warnings) != 0 {
+			t.Errorf("expected column order to survive exporting %s with templates, got: %v", example, warnings)
+		}
+	}
+}
+
+func TestE2E_ExportRoundtrip_Split(t *testing.T) {
+	for _, example := range []string{"simple.yaml", "blog.yaml", "ecommerce.yaml"} {
+		original := loadExample(t, example)
+
+		s := &state.State{Database: &objects.Database{Name: "test", Namespaces: original}}
+		dir := filepath.Join(t.TempDir(), "schema")
+		if err := s.Request(true).WriteYAMLSplit(dir); err != nil {
+			t.Fatalf("could not export %s split: %v", example, err)
+		}
+
+		for _, path := range []string{dir, filepath.Join(dir, "*")} {
+			reloaded, err := state.LoadYAML(path)
+			if err != nil {
+				t.Fatalf("could not reload %s from %s: %v", example, path, err)
+			}
+
+			actions := diffActions(t, original, reloaded.Namespaces)
+			if len(actions) != 0 {
+				t.Errorf("expected no diff after reloading %s from %s, got %d actions:\n  %s",
+					example, path, len(actions), strings.Join(actions, "\n  "))
+			}
+		}
+	}
+}
+
+func TestE2E_ExportSplit_RefusesNonEmptyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stale.yaml"), []byte("namespaces: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &state.State{Database: &objects.Database{Name: "test", Namespaces: loadExample(t, "simple.yaml")}}
+	if err := s.Request(false).WriteYAMLSplit(dir); err == nil {
+		t.Error("expected an error when splitting into a non-empty directory")
+	}
+}
+
+func writeSchemaFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestE2E_LoadDirectory_MergesNamespaces(t *testing.T) {
+	dir := writeSchemaFiles(t, map[string]string{
+		"database.yaml":         "extensions:\n  - name: pgcrypto\n",
+		"public/namespace.yaml": "namespaces:\n  - name: public\n    comment: application data\n",
+		"public/users.yaml":     "namespaces:\n  - name: public\n    tables:\n      - name: users\n        columns:\n          - name: id\n            type: INTEGER\n",
+		"public/posts.yml":      "namespaces:\n  - name: public\n    tables:\n      - name: posts\n        columns:\n          - name: id\n            type: INTEGER\n",
+		"README.md":             "not a schema file",
+	})
+
+	req, err := state.LoadYAML(dir)
+	if err != nil {
+		t.Fatalf("could not load directory: %v", err)
+	}
+	if len(req.Extensions) != 1 || len(req.Namespaces) != 1 {
+		t.Fatalf("expected 1 extension and 1 namespace, got %d and %d", len(req.Extensions), len(req.Namespaces))
+	}
+	ns := req.Namespaces[0]
+	if ns.Comment == nil || *ns.Comment != "application data" || len(ns.Tables) != 2 {
+		t.Errorf("expected the namespace comment and both tables to be merged, got %v and %d tables", ns.Comment, len(ns.Tables))
+	}
+	if ns.Tables[0].Name != "posts" || ns.Tables[1].Name != "users" {
+		t.Errorf("expected tables in lexical file order, got %s, %s", ns.Tables[0].Name, ns.Tables[1].Name)
+	}
+}
+
+func TestE2E_LoadDirectory_Conflicts(t *testing.T) {
+	users := "namespaces:\n  - name: public\n    tables:\n      - name: users\n        columns:\n          - name: id\n            type: INTEGER\n"
+	comment := "namespaces:\n  - name: public\n    comment: %s\n"
+
+	cases := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"table", map[string]string{"a.yaml": users, "b.yaml": users}, "table public.users is declared in both"},
+		{"namespace", map[string]string{"a.yaml": strings.Replace(comment, "%s", "one", 1), "b.yaml": strings.Replace(comment, "%s", "two", 1)}, "comment of schema public is declared in both"},
+		{"same file", map[string]string{"a.yaml": users + strings.TrimPrefix(users, "namespaces:\n")}, "table public.users is declared twice in"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeSchemaFiles(t, tc.files)
+			_, err := state.LoadYAML(dir)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+			for file := range tc.files {
+				if !strings.Contains(err.Error(), filepath.Join(dir, file)) {
+					t.Errorf("expected the error to name %s, got %v", file, err)
+				}
+			}
+		})
+	}
+}
+
+func TestE2E_LoadGlob_NoMatches(t *testing.T) {
+	if _, err := state.LoadYAML(filepath.Join(t.TempDir(), "*.yaml")); err == nil || !strings.Contains(err.Error(), "no files match") {
+		t.Errorf("expected an error for a glob without matches, got %v", err)
+	}
+}
+
+func TestE2E_LoadYAML_RejectsUnknownKeys(t *testing.T) {
+	dir := writeSchemaFiles(t, map[string]string{
+		"db.yaml": "namespaces:\n  - name: public\n    tables:\n      - name: users\n        columns:\n          - name: id\n            type: INTEGER\n            nulable: false\n            primary-key: true\n",
+	})
+
+	_, err := state.LoadYAML(dir)
+	errs, ok := err.(objects.ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected 2 errors for unknown keys, got: %v", err)
+	}
+	file := filepath.Join(dir, "db.yaml")
+	for i, want := range []string{file + ":8: unknown field nulable", file + ":9: unknown field primary-key"} {
+		if errs[i].Error() != want {
+			t.Errorf("expected %q, got %q", want, errs[i].Error())
+		}
+	}
+}
+
+func TestE2E_LoadYAML_ReportsAllProblems(t *testing.T) {
+	users := "namespaces:\n  - name: public\n    tables:\n      - name: users\n        columns:\n          - name: id\n            type: INTEGER\n"
+	dir := writeSchemaFiles(t, map[string]string{
+		"a.yaml": users + "    sequences:\n      - name: users_id_seq\n",
+		"b.yaml": users + "    sequences:\n      - name: users_id_seq\n",
+		"c.yaml": "namespaces:\n  - name: public\n    tables:\n      - name: orders\n        columns:\n          - name: id\n            type: INTEGER\n            nullable: maybe\n            primary-key: true\n",
+	})
+
+	_, err := state.LoadYAML(dir)
+	errs, ok := err.(objects.ValidationErrors)
+	if !ok || len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got: %v", err)
+	}
+	a, b, c := filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml"), filepath.Join(dir, "c.yaml")
+	for i, want := range []string{
+		c + ":8: cannot unmarshal",
+		c + ":9: unknown field primary-key",
+		b + ":4: table public.users is declared in both " + a + " and " + b,
+		b + ":9: sequence public.users_id_seq is declared in both " + a + " and " + b,
+	} {
+		if !strings.HasPrefix(errs[i].Error(), want) {
+			t.Errorf("expected error starting with %q, got %q", want, errs[i].Error())
+		}
+	}
+}
+
+func TestE2E_LoadYAML_ReportsAllTemplateErrors(t *testing.T) {
+	dir := writeSchemaFiles(t, map[string]string{
+		"db.yaml": "namespaces:\n  - name: public\n    tables:\n      - name: users\n        extends: [timestamps]\n      - name: orders\n        extends: [audited]\n",
+	})
+
+	_, err := state.LoadYAML(dir)
+	errs, ok := err.(objects.ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected 2 template errors, got: %v", err)
+	}
+	file := filepath.Join(dir, "db.yaml")
+	for i, want := range []string{
+		file + ":4: public.users: extends unknown template timestamps",
+		file + ":6: public.orders: extends unknown template audited",
+	} {
+		if errs[i].Error() != want {
+			t.Errorf("expected %q, got %q", want, errs[i].Error())
+		}
+	}
+}
+
+func TestE2E_LoadYAML_ReportsAllTypeErrors(t *testing.T) {
+	table := "namespaces:\n  - name: public\n    tables:\n      - name: %s\n        columns:\n          - name: id\n            type: integer\n            primary_key: true\n          - name: code\n            type: %s\n"
+	dir := writeSchemaFiles(t, map[string]string{
+		"a.yaml": fmt.Sprintf(table, "orders", "varchar(abc)"),
+		"b.yaml": fmt.Sprintf(table, "users", "varchar(10)") + "            max_length: 20\n",
+	})
+
+	_, err := state.LoadYAML(dir)
+	errs, ok := err.(objects.ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected 2 type errors, got: %v", err)
+	}
+	for i, want := range []string{
+		filepath.Join(dir, "a.yaml") + `:9: public.orders.columns[1]: type "varchar(abc)" has an invalid modifier "abc"`,
+		filepath.Join(dir, "b.yaml") + ":9: public.users.columns[1]: column code has length 10 in its type but max_length 20",
+	} {
+		if errs[i].Error() != want {
+			t.Errorf("expected %q, got %q", want, errs[i].Error())
+		}
+	}
+}
+
+func TestE2E_Validate_LocatesErrors(t *testing.T) {
+	dir := writeSchemaFiles(t, map[string]string{
+		"public/orders.yaml": "namespaces:\n  - name: public\n    tables:\n      - name: orders\n        columns:\n          - name: id\n            type: INTEGER\n            primary_key: true\n          - name: total\n            type: INTEGER\n          - name: note\n            type: TEXT\n            collation: C\n            nullable: true\n",
+		"public/users.yaml":  "namespaces:\n  - name: public\n    tables:\n      - name: users\n        columns:\n          - name: id\n            type: INTEGER\n            primary_key: true\n        indices:\n          - name: users_id_idx\n            columns: []\n",
+	})
+
+	req, err := state.LoadYAML(dir)
+	if err != nil {
+		t.Fatalf("could not load: %v", err)
+	}
+
+	err = req.Database().Valid()
+	errs, ok := err.(objects.ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected 2 validation errors, got: %v", err)
+	}
+	orders, users := filepath.Join(dir, "public", "orders.yaml"), filepath.Join(dir, "public", "users.yaml")
+	for i, want := range []string{
+		orders + ":9: public.orders.columns[1]: column total is not nullable",
+		users + ":10: public.users.indices[0]: index users_id_idx has no columns",
+	} {
+		if !strings.HasPrefix(errs[i].Error(), want) {
+			t.Errorf("expected error starting with %q, got %q", want, errs[i].Error())
+		}
+	}
+}
+
+// The validate command loads the desired state and checks the database it
+// describes, as these tests do.
+func TestE2E_Validate_Examples(t *testing.T) {
+	for _, example := range []string{"simple.yaml", "blog.yaml", "ecommerce.yaml"} {
+		req, err := state.LoadYAML(filepath.Join("examples", example))
+		if err != nil {
+			t.Fatalf("could not load %s: %v", example, err)
+		}
+		if err := req.Database().Valid(); err != nil {
+			t.Errorf("expected %s to be valid, got:\n%v", example, err)
+		}
+	}
+}
+
+func TestE2E_Validate_ReportsAllErrors(t *testing.T) {
+	users := "namespaces:\n  - name: public\n    tables:\n      - name: users\n        columns:\n          - name: id\n            type: integer\n            primary_key: true\n          - name: email\n            type: text\n            nullable: true\n        constraints:\n          - name: users_email_key\n            type: UNIQUE\n            targets: [email]\n"
+	orders := "namespaces:\n  - name: public\n    tables:\n      - name: orders\n        columns:\n          - name: id\n            type: bigint\n            primary_key: true\n          - name: user_id\n            type: bigint\n            nullable: true\n          - name: user_email\n            type: varchar(255)\n            nullable: true\n          - name: user_name\n            type: text\n            nullable: true\n        constraints:\n          - name: orders_user_id_fkey\n            type: FOREIGN KEY\n            targets: [user_id]\n            reference:\n              table: users\n              columns: [id]\n          - name: orders_user_email_fkey\n            type: FOREIGN KEY\n            targets: [user_email]\n            reference:\n              table: users\n              columns: [email]\n          - name: orders_user_name_fkey\n            type: FOREIGN KEY\n            targets: [user_name]\n            reference:\n              table: users\n              columns: [id]\n        indices:\n          - name: orders_idx\n            columns: []\n"
+	dir := writeSchemaFiles(t, map[string]string{"public/users.yaml": users, "public/orders.yaml": orders})
+
+	req, err := state.LoadYAML(dir)
+	if err != nil {
+		t.Fatalf("could not load: %v", err)
+	}
+	err = req.Database().Valid()
+	errs, ok := err.(objects.ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected 2 validation errors, got: %v", err)
+	}
+	file := filepath.Join(dir, "public", "orders.yaml")
+	for i, want := range []string{
+		file + ":38: public.orders.indices[0]: index orders_idx has no columns",
+		file + ":31: public.orders.constraints[3]: foreign key orders_user_name_fkey on table orders has column user_name of type TEXT, which does not match public.users.id of type INTEGER",
+	} {
+		if errs[i].Error() != want {
+			t.Errorf("expected %q, got %q", want, errs[i].Error())
+		}
+	}
+}
+
+func TestE2E_PrimaryKeyForms_Equivalent(t *testing.T) {
+	columns := "namespaces:\n  - name: public\n    tables:\n      - name: users\n        columns:\n          - name: id\n            type: INTEGER\n"
+	dir := writeSchemaFiles(t, map[string]string{
+		"flag/db.yaml":       columns + "            primary_key: true\n",
+		"constraint/db.yaml": columns + "        constraints:\n          - name: users_pkey\n            type: PRIMARY KEY\n            targets: [id]\n",
+	})
+
+	flag, err := state.LoadYAML(filepath.Join(dir, "flag"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	constraint, err := state.LoadYAML(filepath.Join(dir, "constraint"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	validateNamespaces(t, flag.Namespaces)
+	validateNamespaces(t, constraint.Namespaces)
+
+	for _, actions := range [][]string{diffActions(t, flag.Namespaces, constraint.Namespaces), diffActions(t, constraint.Namespaces, flag.Namespaces)} {
+		if len(actions) != 0 {
+			t.Errorf("expected both forms of the primary key to be equivalent, got:\n  %s", strings.Join(actions, "\n  "))
+		}
+	}
+}
+
+func TestE2E_JSONSchema_UpToDate(t *testing.T) {
+	schema, err := state.JSONSchema()
+	if err != nil {
+		t.Fatalf("could not generate JSON schema: %v", err)
+	}
+	published, err := os.ReadFile("db.schema.json")
+	if err != nil {
+		t.Fatalf("could not read db.schema.json: %v", err)
+	}
+	if string(schema) != string(published) {
+		t.Error("db.schema.json is out of date, regenerate it with make schema")
+	}
+}
@@ -5,6 +5,7 @@ go 1.26
 require (
 	github.com/lib/pq v1.11.2
 	github.com/sirupsen/logrus v1.9.4
+	go.yaml.in/yaml/v3 v3.0.4
 )
 
 require (
@@ -16,7 +17,6 @@ require (
 	github.com/spf13/afero v1.15.0 // indirect
 	github.com/spf13/cast v1.10.0 // indirect
 	github.com/subosito/gotenv v1.6.0 // indirect
-	go.yaml.in/yaml/v3 v3.0.4 // indirect
 	golang.org/x/text v0.34.0 // indirect
 )
 
@@ -26,5 +26,4 @@ require (
 	github.com/spf13/pflag v1.0.10 // indirect
 	github.com/spf13/viper v1.21.0
 	golang.org/x/sys v0.41.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0
 )
@@ -2,6 +2,8 @@ package main
 
 import "stijntratsaertit/terramigrate/cmd"
 
+//go:generate go run . schema jsonschema --output db.schema.json
+
 func main() {
 	cmd.Execute()
 }
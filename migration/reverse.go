@@ -3,32 +3,127 @@ package migration
 import (
 	"fmt"
 	"regexp"
+	"stijntratsaertit/terramigrate/objects"
 	"strings"
 )
 
 var (
-	reCreateTable      = regexp.MustCompile(`(?i)^CREATE TABLE (\S+)\s`)
-	reDropTable        = regexp.MustCompile(`(?i)^DROP TABLE (\S+);`)
-	reAddColumn        = regexp.MustCompile(`(?i)^ALTER TABLE (\S+) ADD COLUMN (\S+)\s`)
-	reDropColumn       = regexp.MustCompile(`(?i)^ALTER TABLE (\S+) DROP COLUMN (\S+);`)
-	reAlterColumnType  = regexp.MustCompile(`(?i)^ALTER TABLE (\S+) ALTER COLUMN (\S+) TYPE (\S+);`)
-	reAlterColumnSet   = regexp.MustCompile(`(?i)^ALTER TABLE (\S+) ALTER COLUMN (\S+) (SET DEFAULT .+|DROP DEFAULT|SET NOT NULL|DROP NOT NULL);`)
-	reCreateSequence   = regexp.MustCompile(`(?i)^CREATE SEQUENCE (\S+);`)
-	reDropSequence     = regexp.MustCompile(`(?i)^DROP SEQUENCE (\S+);`)
-	reAlterSequence    = regexp.MustCompile(`(?i)^ALTER SEQUENCE (\S+) AS (\S+);`)
-	reAddConstraint    = regexp.MustCompile(`(?i)^ALTER TABLE (\S+) ADD CONSTRAINT (\S+)\s`)
-	reDropConstraint   = regexp.MustCompile(`(?i)^ALTER TABLE (\S+) DROP CONSTRAINT (\S+);`)
-	reCreateIndex      = regexp.MustCompile(`(?i)^CREATE (?:UNIQUE )?INDEX (\S+) ON (\S+)`)
-	reDropIndex        = regexp.MustCompile(`(?i)^DROP INDEX (\S+);`)
-	reCreateSchema     = regexp.MustCompile(`(?i)^CREATE SCHEMA (\S+);`)
-	reDropSchema       = regexp.MustCompile(`(?i)^DROP SCHEMA (\S+)`)
+	reCreateTable        = identRegexp(`(?i)^CREATE (?:UNLOGGED )?TABLE {ident}\s`)
+	reDropTable          = identRegexp(`(?i)^DROP TABLE {ident};`)
+	reAddColumn          = identRegexp(`(?i)^ALTER TABLE {ident} ADD COLUMN {ident}\s`)
+	reDropColumn         = identRegexp(`(?i)^ALTER TABLE {ident} DROP COLUMN {ident};`)
+	reAlterColumnType    = identRegexp(`(?i)^ALTER TABLE {ident} ALTER COLUMN {ident} TYPE (.+);`)
+	reAddIdentity        = identRegexp(`(?i)^ALTER TABLE {ident} ALTER COLUMN {ident} ADD GENERATED .+;`)
+	reDropIdentity       = identRegexp(`(?i)^ALTER TABLE {ident} ALTER COLUMN {ident} DROP IDENTITY;`)
+	reAlterIdentity      = identRegexp(`(?i)^ALTER TABLE {ident} ALTER COLUMN {ident} SET (?:GENERATED|START|INCREMENT|MINVALUE|MAXVALUE|CACHE|CYCLE|NO CYCLE)\b.*;`)
+	reDropExpression     = identRegexp(`(?i)^ALTER TABLE {ident} ALTER COLUMN {ident} DROP EXPRESSION;`)
+	reAlterColumnSet     = identRegexp(`(?i)^ALTER TABLE {ident} ALTER COLUMN {ident} (SET DEFAULT .+|DROP DEFAULT|SET NOT NULL|DROP NOT NULL);`)
+	reCreateSequence     = identRegexp(`(?i)^CREATE SEQUENCE {ident}[\s;]`)
+	reDropSequence       = identRegexp(`(?i)^DROP SEQUENCE {ident};`)
+	reAlterSequence      = identRegexp(`(?i)^ALTER SEQUENCE {ident} AS (\S+);`)
+	reSequenceOptions    = identRegexp(`(?i)^ALTER SEQUENCE {ident} (?:START|INCREMENT|MINVALUE|MAXVALUE|CACHE|CYCLE|NO CYCLE)\b.*;`)
+	reSequenceOwnedBy    = identRegexp(`(?i)^ALTER SEQUENCE {ident} OWNED BY {ident};`)
+	reAddConstraint      = identRegexp(`(?i)^ALTER TABLE {ident} ADD CONSTRAINT {ident}\s`)
+	reDropConstraint     = identRegexp(`(?i)^ALTER TABLE {ident} DROP CONSTRAINT {ident};`)
+	reAlterConstraint    = identRegexp(`(?i)^ALTER TABLE {ident} ALTER CONSTRAINT {ident} (.+);`)
+	reValidateConstraint = identRegexp(`(?i)^ALTER TABLE {ident} VALIDATE CONSTRAINT {ident};`)
+	reCreateIndex        = identRegexp(`(?i)^CREATE (?:UNIQUE )?INDEX {ident} ON {ident}`)
+	reDropIndex          = identRegexp(`(?i)^DROP INDEX {ident};`)
+	reCreateSchema       = identRegexp(`(?i)^CREATE SCHEMA {ident};`)
+	reDropSchema         = identRegexp(`(?i)^DROP SCHEMA {ident}`)
+	reCreateExtension    = identRegexp(`(?i)^CREATE EXTENSION {ident}[\s;]`)
+	reDropExtension      = identRegexp(`(?i)^DROP EXTENSION {ident};`)
+	reUpdateExtension    = identRegexp(`(?i)^ALTER EXTENSION {ident} UPDATE TO '([^']*)';`)
+	reExtensionSchema    = identRegexp(`(?i)^ALTER EXTENSION {ident} SET SCHEMA {ident};`)
+	reComment            = identRegexp(`(?is)^COMMENT ON (.+?) IS (?:NULL|'(?:[^']|'')*');$`)
+	reCreateRole         = identRegexp(`(?i)^CREATE ROLE {ident}[\s;]`)
+	reDropRole           = identRegexp(`(?i)^DROP ROLE {ident};`)
+	reAlterRole          = identRegexp(`(?i)^ALTER ROLE {ident} (NO)?(LOGIN|INHERIT);`)
+	reGrant              = identRegexp(`(?is)^((?:ALTER DEFAULT PRIVILEGES .+? )?)GRANT (.+) TO {ident};$`)
+	reRevoke             = identRegexp(`(?is)^((?:ALTER DEFAULT PRIVILEGES .+? )?)REVOKE (.+) FROM {ident};$`)
+	reCreatePolicy       = identRegexp(`(?i)^CREATE POLICY {ident} ON {ident}\s`)
+	reAlterPolicy        = identRegexp(`(?i)^ALTER POLICY {ident} ON {ident}\s`)
+	reDropPolicy         = identRegexp(`(?i)^DROP POLICY {ident} ON {ident};`)
+	reAttachPartition    = identRegexp(`(?i)^ALTER TABLE {ident} ATTACH PARTITION {ident}\s`)
+	reDetachPartition    = identRegexp(`(?i)^ALTER TABLE {ident} DETACH PARTITION {ident};`)
+	reCreateDomain       = identRegexp(`(?i)^CREATE DOMAIN {ident}\s`)
+	reDropDomain         = identRegexp(`(?i)^DROP DOMAIN {ident};`)
+	reDomainDefault      = identRegexp(`(?i)^ALTER DOMAIN {ident} (?:SET|DROP) DEFAULT\b.*;`)
+	reDomainNotNull      = identRegexp(`(?i)^ALTER DOMAIN {ident} (SET|DROP) NOT NULL;`)
+	reDomainAddCheck     = identRegexp(`(?i)^ALTER DOMAIN {ident} ADD CONSTRAINT {ident}\s`)
+	reDomainDropCheck    = identRegexp(`(?i)^ALTER DOMAIN {ident} DROP CONSTRAINT {ident};`)
+	reCreateType         = identRegexp(`(?i)^CREATE TYPE {ident} AS\s`)
+	reDropType           = identRegexp(`(?i)^DROP TYPE {ident};`)
+	reAddAttribute       = identRegexp(`(?i)^ALTER TYPE {ident} ADD ATTRIBUTE {ident}\s`)
+	reDropAttribute      = identRegexp(`(?i)^ALTER TYPE {ident} DROP ATTRIBUTE {ident};`)
+	reAlterAttribute     = identRegexp(`(?i)^ALTER TYPE {ident} ALTER ATTRIBUTE {ident} TYPE\s`)
+	reTablePersistence   = identRegexp(`(?i)^ALTER TABLE {ident} SET (LOGGED|UNLOGGED);`)
+	reTablespace         = identRegexp(`(?i)^ALTER TABLE {ident} SET TABLESPACE {ident};`)
+	reStorageSet         = identRegexp(`(?i)^ALTER TABLE {ident} SET \((.+)\);`)
+	reStorageReset       = identRegexp(`(?i)^ALTER TABLE {ident} RESET \((.+)\);`)
+	reCreatePublication  = identRegexp(`(?i)^CREATE PUBLICATION {ident}[\s;]`)
+	reDropPublication    = identRegexp(`(?i)^DROP PUBLICATION {ident};`)
+	rePublicationAdd     = identRegexp(`(?i)^ALTER PUBLICATION {ident} ADD TABLE {ident}`)
+	rePublicationDrop    = identRegexp(`(?i)^ALTER PUBLICATION {ident} DROP TABLE {ident};`)
+	rePublicationPublish = identRegexp(`(?i)^ALTER PUBLICATION {ident} SET \(publish = '[^']*'\);`)
+	reCreateSubscription = identRegexp(`(?i)^CREATE SUBSCRIPTION {ident}\s`)
+	reDropSubscription   = identRegexp(`(?i)^DROP SUBSCRIPTION {ident};`)
+	reSubscriptionSlot   = identRegexp(`(?i)^ALTER SUBSCRIPTION {ident} SET \(slot_name = [^)]*\);`)
+	reSubscriptionConn   = identRegexp(`(?i)^ALTER SUBSCRIPTION {ident} CONNECTION\s`)
+	reSubscriptionPubs   = identRegexp(`(?i)^ALTER SUBSCRIPTION {ident} SET PUBLICATION\s`)
+	reSubscriptionState  = identRegexp(`(?i)^ALTER SUBSCRIPTION {ident} (ENABLE|DISABLE);`)
+	reRowLevelSecurity   = identRegexp(`(?i)^ALTER TABLE {ident} (ENABLE|DISABLE|FORCE|NO FORCE) ROW LEVEL SECURITY;`)
 )
 
+// identifier matches a possibly qualified identifier as written in SQL. Quoted
+// parts may contain spaces, dots and doubled quotes.
+const identifier = `((?:"(?:[^"]|"")*"|[^\s."(),;]+)(?:\.(?:"(?:[^"]|"")*"|[^\s."(),;]+))*)`
+
+// identRegexp compiles pattern with every {ident} standing for a captured
+// identifier.
+func identRegexp(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(strings.ReplaceAll(pattern, "{ident}", identifier))
+}
+
 type ExistingState struct {
-	ColumnTypes    map[string]string
-	ColumnDefaults map[string]string
-	ColumnNullable map[string]bool
-	SequenceTypes  map[string]string
+	ColumnTypes       map[string]string
+	ColumnDefaults    map[string]string
+	ColumnNullable    map[string]bool
+	ColumnIdentities  map[string]*objects.ColumnIdentity
+	SequenceTypes     map[string]string
+	SequenceOptions   map[string]objects.SequenceOptions
+	ExtensionSchemas  map[string]string
+	ExtensionVersions map[string]string
+	// SequenceOwners holds the qualified "schema.table.column" owning each
+	// sequence, keyed by the qualified sequence name.
+	SequenceOwners map[string]string
+	// Comments holds the comment of every object that has one, keyed by the
+	// COMMENT ON target, e.g. "COLUMN public.users.email".
+	Comments map[string]string
+	// ConstraintDeferrals holds how each constraint is checked, e.g.
+	// "NOT DEFERRABLE", keyed by table and constraint name.
+	ConstraintDeferrals map[string]string
+	// Roles holds the existing roles by name, so that dropped roles can be
+	// recreated with their memberships.
+	Roles map[string]*objects.Role
+	// Policies holds the existing row level security policies, keyed by
+	// table and policy name.
+	Policies map[string]*objects.Policy
+	// PartitionBounds holds the bounds of every partition, e.g.
+	// "FOR VALUES IN ('eu')", keyed by the qualified partition name.
+	PartitionBounds map[string]string
+	// Domains and CompositeTypes hold the existing types, keyed by their
+	// qualified name.
+	Domains        map[string]*objects.Domain
+	CompositeTypes map[string]*objects.CompositeType
+	// Tablespaces and StorageParameters hold how every table is stored,
+	// keyed by the qualified table name.
+	Tablespaces       map[string]string
+	StorageParameters map[string]map[string]string
+	// Publications holds the existing publications by name.
+	Publications map[string]*objects.Publication
+	// Subscriptions holds the existing subscriptions by name.
+	Subscriptions map[string]*objects.Subscription
 }
 
 func tableColKey(table, col string) string {
@@ -38,10 +133,26 @@ func tableColKey(table, col string) string {
 func GenerateDownSQL(upActions []string, existing *ExistingState) string {
 	if existing == nil {
 		existing = &ExistingState{
-			ColumnTypes:    make(map[string]string),
-			ColumnDefaults: make(map[string]string),
-			ColumnNullable: make(map[string]bool),
-			SequenceTypes:  make(map[string]string),
+			ColumnTypes:         make(map[string]string),
+			ColumnDefaults:      make(map[string]string),
+			ColumnNullable:      make(map[string]bool),
+			ColumnIdentities:    make(map[string]*objects.ColumnIdentity),
+			SequenceTypes:       make(map[string]string),
+			SequenceOptions:     make(map[string]objects.SequenceOptions),
+			SequenceOwners:      make(map[string]string),
+			ExtensionSchemas:    make(map[string]string),
+			ExtensionVersions:   make(map[string]string),
+			Comments:            make(map[string]string),
+			ConstraintDeferrals: make(map[string]string),
+			Roles:               make(map[string]*objects.Role),
+			Policies:            make(map[string]*objects.Policy),
+			PartitionBounds:     make(map[string]string),
+			Domains:             make(map[string]*objects.Domain),
+			CompositeTypes:      make(map[string]*objects.CompositeType),
+			Tablespaces:         make(map[string]string),
+			StorageParameters:   make(map[string]map[string]string),
+			Publications:        make(map[string]*objects.Publication),
+			Subscriptions:       make(map[string]*objects.Subscription),
 		}
 	}
 
@@ -67,6 +178,277 @@ func reverseAction(action string, existing *ExistingState) string {
 		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP SCHEMA %s. Manual intervention required.", m[1])
 	}
 
+	if m := reCreateExtension.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("DROP EXTENSION %s;", m[1])
+	}
+
+	if m := reDropExtension.FindStringSubmatch(action); m != nil {
+		name := m[1]
+		if schema, ok := existing.ExtensionSchemas[name]; ok {
+			create := fmt.Sprintf("CREATE EXTENSION %s SCHEMA %s", name, schema)
+			if version := existing.ExtensionVersions[name]; version != "" {
+				create += fmt.Sprintf(" VERSION '%s'", version)
+			}
+			return create + ";"
+		}
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP EXTENSION %s. Manual intervention required.", name)
+	}
+
+	if m := reUpdateExtension.FindStringSubmatch(action); m != nil {
+		name := m[1]
+		if version, ok := existing.ExtensionVersions[name]; ok && version != "" {
+			return fmt.Sprintf("ALTER EXTENSION %s UPDATE TO '%s';", name, version)
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original version for extension %s. Manual intervention required.", name)
+	}
+
+	if m := reExtensionSchema.FindStringSubmatch(action); m != nil {
+		name := m[1]
+		if schema, ok := existing.ExtensionSchemas[name]; ok {
+			return fmt.Sprintf("ALTER EXTENSION %s SET SCHEMA %s;", name, schema)
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original schema for extension %s. Manual intervention required.", name)
+	}
+
+	if m := reCreateRole.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("DROP ROLE %s;", m[1])
+	}
+
+	if m := reDropRole.FindStringSubmatch(action); m != nil {
+		if role, ok := existing.Roles[m[1]]; ok {
+			create := []string{role.SQL()}
+			for _, parent := range role.MemberOf {
+				create = append(create, fmt.Sprintf("GRANT %s TO %s;", objects.QuoteIdent(parent), m[1]))
+			}
+			return strings.Join(create, "\n")
+		}
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP ROLE %s. Manual intervention required.", m[1])
+	}
+
+	if m := reAlterRole.FindStringSubmatch(action); m != nil {
+		if m[2] == "" {
+			return fmt.Sprintf("ALTER ROLE %s NO%s;", m[1], strings.ToUpper(m[3]))
+		}
+		return fmt.Sprintf("ALTER ROLE %s %s;", m[1], strings.ToUpper(m[3]))
+	}
+
+	if m := reGrant.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("%sREVOKE %s FROM %s;", m[1], m[2], m[3])
+	}
+
+	if m := reRevoke.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("%sGRANT %s TO %s;", m[1], m[2], m[3])
+	}
+
+	if m := reCreatePublication.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("DROP PUBLICATION %s;", m[1])
+	}
+
+	if m := reDropPublication.FindStringSubmatch(action); m != nil {
+		if publication, ok := existing.Publications[m[1]]; ok {
+			return publication.SQL()
+		}
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP PUBLICATION %s. Manual intervention required.", m[1])
+	}
+
+	if m := rePublicationAdd.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("ALTER PUBLICATION %s DROP TABLE %s;", m[1], m[2])
+	}
+
+	if m := rePublicationDrop.FindStringSubmatch(action); m != nil {
+		if publication, ok := existing.Publications[m[1]]; ok {
+			if table := publication.GetTable(objects.UnquoteIdent(m[2])); table != nil {
+				return fmt.Sprintf("ALTER PUBLICATION %s ADD TABLE %s;", m[1], table.SQL())
+			}
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine how table %s was published by %s. Manual intervention required.", m[2], m[1])
+	}
+
+	if m := rePublicationPublish.FindStringSubmatch(action); m != nil {
+		if publication, ok := existing.Publications[m[1]]; ok {
+			return fmt.Sprintf("ALTER PUBLICATION %s SET (publish = %s);", m[1], publication.PublishSQL())
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original operations of publication %s. Manual intervention required.", m[1])
+	}
+
+	if m := reCreateSubscription.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("ALTER SUBSCRIPTION %s SET (slot_name = NONE);\nDROP SUBSCRIPTION %s;", m[1], m[1])
+	}
+
+	if m := reDropSubscription.FindStringSubmatch(action); m != nil {
+		if subscription, ok := existing.Subscriptions[m[1]]; ok && subscription.Connection != "" {
+			return subscription.SQL()
+		}
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP SUBSCRIPTION %s. Manual intervention required.", m[1])
+	}
+
+	if m := reSubscriptionSlot.FindStringSubmatch(action); m != nil {
+		if subscription, ok := existing.Subscriptions[m[1]]; ok {
+			return fmt.Sprintf("ALTER SUBSCRIPTION %s SET (slot_name = %s);", m[1], objects.QuoteLiteral(subscription.GetSlotName()))
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original replication slot of subscription %s. Manual intervention required.", m[1])
+	}
+
+	if m := reSubscriptionConn.FindStringSubmatch(action); m != nil {
+		if subscription, ok := existing.Subscriptions[m[1]]; ok && subscription.Connection != "" {
+			return fmt.Sprintf("ALTER SUBSCRIPTION %s CONNECTION %s;", m[1], objects.QuoteLiteral(subscription.Connection))
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original connection of subscription %s. Manual intervention required.", m[1])
+	}
+
+	if m := reSubscriptionPubs.FindStringSubmatch(action); m != nil {
+		if subscription, ok := existing.Subscriptions[m[1]]; ok {
+			return fmt.Sprintf("ALTER SUBSCRIPTION %s SET PUBLICATION %s WITH (refresh = false);", m[1], subscription.PublicationsSQL())
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original publications of subscription %s. Manual intervention required.", m[1])
+	}
+
+	if m := reSubscriptionState.FindStringSubmatch(action); m != nil {
+		if strings.EqualFold(m[2], "ENABLE") {
+			return fmt.Sprintf("ALTER SUBSCRIPTION %s DISABLE;", m[1])
+		}
+		return fmt.Sprintf("ALTER SUBSCRIPTION %s ENABLE;", m[1])
+	}
+
+	if m := reCreatePolicy.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("DROP POLICY %s ON %s;", m[1], m[2])
+	}
+
+	if m := reAlterPolicy.FindStringSubmatch(action); m != nil {
+		if policy, ok := existing.Policies[tableColKey(m[2], m[1])]; ok {
+			return policy.AlterSQL(m[2])
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original definition of policy %s on %s. Manual intervention required.", m[1], m[2])
+	}
+
+	if m := reDropPolicy.FindStringSubmatch(action); m != nil {
+		if policy, ok := existing.Policies[tableColKey(m[2], m[1])]; ok {
+			return policy.SQL(m[2])
+		}
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP POLICY %s ON %s. Manual intervention required.", m[1], m[2])
+	}
+
+	if m := reAttachPartition.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s;", m[1], m[2])
+	}
+
+	if m := reDetachPartition.FindStringSubmatch(action); m != nil {
+		if bound, ok := existing.PartitionBounds[m[2]]; ok {
+			return fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s %s;", m[1], m[2], bound)
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original bounds of partition %s. Manual intervention required.", m[2])
+	}
+
+	if m := reCreateDomain.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("DROP DOMAIN %s;", m[1])
+	}
+
+	if m := reDropDomain.FindStringSubmatch(action); m != nil {
+		if domain, ok := existing.Domains[m[1]]; ok {
+			return domain.CreateSQL(namespaceOf(m[1]))
+		}
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP DOMAIN %s. Manual intervention required.", m[1])
+	}
+
+	if m := reDomainDefault.FindStringSubmatch(action); m != nil {
+		if domain, ok := existing.Domains[m[1]]; ok {
+			if domain.Default == "" {
+				return fmt.Sprintf("ALTER DOMAIN %s DROP DEFAULT;", m[1])
+			}
+			return fmt.Sprintf("ALTER DOMAIN %s SET DEFAULT %s;", m[1], domain.Default)
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original default of domain %s. Manual intervention required.", m[1])
+	}
+
+	if m := reDomainNotNull.FindStringSubmatch(action); m != nil {
+		if strings.EqualFold(m[2], "SET") {
+			return fmt.Sprintf("ALTER DOMAIN %s DROP NOT NULL;", m[1])
+		}
+		return fmt.Sprintf("ALTER DOMAIN %s SET NOT NULL;", m[1])
+	}
+
+	if m := reDomainAddCheck.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("ALTER DOMAIN %s DROP CONSTRAINT %s;", m[1], m[2])
+	}
+
+	if m := reDomainDropCheck.FindStringSubmatch(action); m != nil {
+		if domain, ok := existing.Domains[m[1]]; ok {
+			if check := domain.GetCheck(objects.UnquoteIdent(m[2])); check != nil {
+				return fmt.Sprintf("ALTER DOMAIN %s ADD %s;", m[1], check.SQL())
+			}
+		}
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP CONSTRAINT %s on domain %s. Manual intervention required.", m[2], m[1])
+	}
+
+	if m := reCreateType.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("DROP TYPE %s;", m[1])
+	}
+
+	if m := reDropType.FindStringSubmatch(action); m != nil {
+		if compositeType, ok := existing.CompositeTypes[m[1]]; ok {
+			return compositeType.CreateSQL(namespaceOf(m[1]))
+		}
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP TYPE %s. Manual intervention required.", m[1])
+	}
+
+	if m := reAddAttribute.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("ALTER TYPE %s DROP ATTRIBUTE %s;", m[1], m[2])
+	}
+
+	if m := reDropAttribute.FindStringSubmatch(action); m != nil {
+		if compositeType, ok := existing.CompositeTypes[m[1]]; ok {
+			if attribute := compositeType.GetAttribute(objects.UnquoteIdent(m[2])); attribute != nil {
+				return fmt.Sprintf("ALTER TYPE %s ADD ATTRIBUTE %s %s;", m[1], m[2], attribute.TypeSQL())
+			}
+		}
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP ATTRIBUTE %s on type %s. Manual intervention required.", m[2], m[1])
+	}
+
+	if m := reAlterAttribute.FindStringSubmatch(action); m != nil {
+		if compositeType, ok := existing.CompositeTypes[m[1]]; ok {
+			if attribute := compositeType.GetAttribute(objects.UnquoteIdent(m[2])); attribute != nil {
+				return fmt.Sprintf("ALTER TYPE %s ALTER ATTRIBUTE %s TYPE %s;", m[1], m[2], attribute.TypeSQL())
+			}
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original type of attribute %s on type %s. Manual intervention required.", m[2], m[1])
+	}
+
+	if m := reRowLevelSecurity.FindStringSubmatch(action); m != nil {
+		opposite := map[string]string{"ENABLE": "DISABLE", "DISABLE": "ENABLE", "FORCE": "NO FORCE", "NO FORCE": "FORCE"}
+		return fmt.Sprintf("ALTER TABLE %s %s ROW LEVEL SECURITY;", m[1], opposite[strings.ToUpper(m[2])])
+	}
+
+	if m := reTablePersistence.FindStringSubmatch(action); m != nil {
+		if strings.EqualFold(m[2], "UNLOGGED") {
+			return fmt.Sprintf("ALTER TABLE %s SET LOGGED;", m[1])
+		}
+		return fmt.Sprintf("ALTER TABLE %s SET UNLOGGED;", m[1])
+	}
+
+	if m := reTablespace.FindStringSubmatch(action); m != nil {
+		if tablespace, ok := existing.Tablespaces[m[1]]; ok {
+			return fmt.Sprintf("ALTER TABLE %s SET TABLESPACE %s;", m[1], objects.QuoteIdent(tablespace))
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original tablespace of table %s. Manual intervention required.", m[1])
+	}
+
+	if m := reStorageSet.FindStringSubmatch(action); m != nil {
+		names := []string{}
+		for _, param := range strings.Split(m[2], ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(param), "=")
+			names = append(names, name)
+		}
+		return reverseStorageParameters(m[1], names, existing)
+	}
+
+	if m := reStorageReset.FindStringSubmatch(action); m != nil {
+		names := []string{}
+		for _, name := range strings.Split(m[2], ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+		return reverseStorageParameters(m[1], names, existing)
+	}
+
 	if m := reCreateTable.FindStringSubmatch(action); m != nil {
 		return fmt.Sprintf("DROP TABLE %s;", m[1])
 	}
@@ -92,6 +474,37 @@ func reverseAction(action string, existing *ExistingState) string {
 		return fmt.Sprintf("-- WARNING: Cannot determine original type for column %s on %s. Manual intervention required.", col, table)
 	}
 
+	if m := reAddIdentity.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP IDENTITY;", m[1], m[2])
+	}
+
+	if m := reDropIdentity.FindStringSubmatch(action); m != nil {
+		table, col := m[1], m[2]
+		if identity, ok := existing.ColumnIdentities[tableColKey(table, col)]; ok && identity != nil {
+			return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ADD %s;", table, col, identity.SQL())
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original identity for column %s on %s. Manual intervention required.", col, table)
+	}
+
+	if m := reAlterIdentity.FindStringSubmatch(action); m != nil {
+		table, col := m[1], m[2]
+		key := tableColKey(table, col)
+		if identity, ok := existing.ColumnIdentities[key]; ok && identity != nil {
+			changes := []string{fmt.Sprintf("SET GENERATED %s", identity.GetGeneration())}
+			effective := identity.Effective(existing.ColumnTypes[key])
+			changes = append(changes, prefixClauses(effective.Clauses())...)
+			if !effective.Cycle {
+				changes = append(changes, "SET NO CYCLE")
+			}
+			return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", table, col, strings.Join(changes, " "))
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original identity for column %s on %s. Manual intervention required.", col, table)
+	}
+
+	if m := reDropExpression.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP EXPRESSION on column %s on %s. Manual intervention required.", m[2], m[1])
+	}
+
 	if m := reAlterColumnSet.FindStringSubmatch(action); m != nil {
 		table, col, change := m[1], m[2], m[3]
 		return reverseColumnChange(table, col, change, existing)
@@ -104,11 +517,41 @@ func reverseAction(action string, existing *ExistingState) string {
 	if m := reDropSequence.FindStringSubmatch(action); m != nil {
 		seqName := m[1]
 		if seqType, ok := existing.SequenceTypes[seqName]; ok {
-			return fmt.Sprintf("CREATE SEQUENCE %s AS %s;", seqName, seqType)
+			down := fmt.Sprintf("CREATE SEQUENCE %s AS %s", seqName, seqType)
+			if clauses := existing.SequenceOptions[seqName].Clauses(); len(clauses) > 0 {
+				down += " " + strings.Join(clauses, " ")
+			}
+			if owner, ok := existing.SequenceOwners[seqName]; ok {
+				down += fmt.Sprintf("; ALTER SEQUENCE %s OWNED BY %s", seqName, owner)
+			}
+			return down + ";"
 		}
 		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP SEQUENCE %s. Manual intervention required.", seqName)
 	}
 
+	if m := reSequenceOptions.FindStringSubmatch(action); m != nil {
+		seqName := m[1]
+		seqType, ok := existing.SequenceTypes[seqName]
+		if !ok {
+			return fmt.Sprintf("-- WARNING: Cannot determine original options for sequence %s. Manual intervention required.", seqName)
+		}
+		options := existing.SequenceOptions[seqName].Effective(seqType)
+		clauses := options.Clauses()
+		if !options.Cycle {
+			clauses = append(clauses, "NO CYCLE")
+		}
+		return fmt.Sprintf("ALTER SEQUENCE %s %s;", seqName, strings.Join(clauses, " "))
+	}
+
+	if m := reSequenceOwnedBy.FindStringSubmatch(action); m != nil {
+		seqName := m[1]
+		owner, ok := existing.SequenceOwners[seqName]
+		if !ok {
+			owner = "NONE"
+		}
+		return fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s;", seqName, owner)
+	}
+
 	if m := reAlterSequence.FindStringSubmatch(action); m != nil {
 		seqName := m[1]
 		if oldType, ok := existing.SequenceTypes[seqName]; ok {
@@ -121,10 +564,28 @@ func reverseAction(action string, existing *ExistingState) string {
 		return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", m[1], m[2])
 	}
 
+	if m := reAlterConstraint.FindStringSubmatch(action); m != nil {
+		if deferral, ok := existing.ConstraintDeferrals[tableColKey(m[1], m[2])]; ok {
+			return fmt.Sprintf("ALTER TABLE %s ALTER CONSTRAINT %s %s;", m[1], m[2], deferral)
+		}
+		return fmt.Sprintf("-- WARNING: Cannot determine original deferral for constraint %s on %s. Manual intervention required.", m[2], m[1])
+	}
+
+	if m := reValidateConstraint.FindStringSubmatch(action); m != nil {
+		return fmt.Sprintf("-- Validating constraint %s on %s needs no reversal.", m[2], m[1])
+	}
+
 	if m := reDropConstraint.FindStringSubmatch(action); m != nil {
 		return fmt.Sprintf("-- WARNING: Cannot automatically reverse DROP CONSTRAINT %s on %s. Manual intervention required.", m[2], m[1])
 	}
 
+	if m := reComment.FindStringSubmatch(action); m != nil {
+		if comment, ok := existing.Comments[m[1]]; ok {
+			return fmt.Sprintf("COMMENT ON %s IS %s;", m[1], objects.QuoteLiteral(comment))
+		}
+		return fmt.Sprintf("COMMENT ON %s IS NULL;", m[1])
+	}
+
 	if m := reCreateIndex.FindStringSubmatch(action); m != nil {
 		return fmt.Sprintf("DROP INDEX %s;", m[1])
 	}
@@ -136,6 +597,14 @@ func reverseAction(action string, existing *ExistingState) string {
 	return fmt.Sprintf("-- WARNING: Cannot reverse unknown action: %s", action)
 }
 
+func prefixClauses(clauses []string) []string {
+	prefixed := make([]string, len(clauses))
+	for i, clause := range clauses {
+		prefixed[i] = "SET " + clause
+	}
+	return prefixed
+}
+
 func reverseColumnChange(table, col, change string, existing *ExistingState) string {
 	changeLower := strings.ToLower(strings.TrimSpace(change))
 	key := tableColKey(table, col)
@@ -165,10 +634,26 @@ func reverseColumnChange(table, col, change string, existing *ExistingState) str
 
 func BuildExistingState(existing []*ExistingTableInfo) *ExistingState {
 	state := &ExistingState{
-		ColumnTypes:    make(map[string]string),
-		ColumnDefaults: make(map[string]string),
-		ColumnNullable: make(map[string]bool),
-		SequenceTypes:  make(map[string]string),
+		ColumnTypes:         make(map[string]string),
+		ColumnDefaults:      make(map[string]string),
+		ColumnNullable:      make(map[string]bool),
+		ColumnIdentities:    make(map[string]*objects.ColumnIdentity),
+		SequenceTypes:       make(map[string]string),
+		SequenceOptions:     make(map[string]objects.SequenceOptions),
+		SequenceOwners:      make(map[string]string),
+		ExtensionSchemas:    make(map[string]string),
+		ExtensionVersions:   make(map[string]string),
+		Comments:            make(map[string]string),
+		ConstraintDeferrals: make(map[string]string),
+		Roles:               make(map[string]*objects.Role),
+		Policies:            make(map[string]*objects.Policy),
+		PartitionBounds:     make(map[string]string),
+		Domains:             make(map[string]*objects.Domain),
+		CompositeTypes:      make(map[string]*objects.CompositeType),
+		Tablespaces:         make(map[string]string),
+		StorageParameters:   make(map[string]map[string]string),
+		Publications:        make(map[string]*objects.Publication),
+		Subscriptions:       make(map[string]*objects.Subscription),
 	}
 
 	for _, t := range existing {
@@ -177,6 +662,7 @@ func BuildExistingState(existing []*ExistingTableInfo) *ExistingState {
 			state.ColumnTypes[key] = col.Type
 			state.ColumnDefaults[key] = col.Default
 			state.ColumnNullable[key] = col.Nullable
+			state.ColumnIdentities[key] = col.Identity
 		}
 	}
 
@@ -193,4 +679,38 @@ type ExistingColumnInfo struct {
 	Type     string
 	Default  string
 	Nullable bool
+	Identity *objects.ColumnIdentity
+}
+
+// reverseStorageParameters restores the given storage parameters of a table to
+// their original values, resetting those that were not set.
+func reverseStorageParameters(table string, names []string, existing *ExistingState) string {
+	params, ok := existing.StorageParameters[table]
+	if !ok {
+		return fmt.Sprintf("-- WARNING: Cannot determine original storage parameters of table %s. Manual intervention required.", table)
+	}
+
+	reset, set := []string{}, []string{}
+	for _, name := range names {
+		if value, ok := params[name]; ok {
+			set = append(set, fmt.Sprintf("%s=%s", name, value))
+		} else {
+			reset = append(reset, name)
+		}
+	}
+
+	down := []string{}
+	if len(reset) > 0 {
+		down = append(down, fmt.Sprintf("ALTER TABLE %s RESET (%s);", table, strings.Join(reset, ", ")))
+	}
+	if len(set) > 0 {
+		down = append(down, fmt.Sprintf("ALTER TABLE %s SET (%s);", table, strings.Join(set, ", ")))
+	}
+	return strings.Join(down, "\n")
+}
+
+// namespaceOf returns the namespace of a qualified object name as written in
+// SQL, unquoted.
+func namespaceOf(qualified string) string {
+	return objects.SplitQualified(qualified)[0]
 }
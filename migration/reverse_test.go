@@ -1,6 +1,7 @@
 package migration
 
 import (
+	"stijntratsaertit/terramigrate/objects"
 	"strings"
 	"testing"
 )
@@ -149,6 +150,47 @@ func TestGenerateDownSQL_DropSchema(t *testing.T) {
 	}
 }
 
+func TestGenerateDownSQL_SequenceOptionsAndOwner(t *testing.T) {
+	up := []string{
+		"CREATE SEQUENCE public.tickets_id_seq AS integer START WITH 1000;",
+		"ALTER SEQUENCE public.counter_seq INCREMENT BY 5;",
+		"ALTER SEQUENCE public.counter_seq OWNED BY public.counters.id;",
+	}
+	existing := &ExistingState{
+		SequenceTypes:   map[string]string{"public.counter_seq": "integer"},
+		SequenceOptions: map[string]objects.SequenceOptions{"public.counter_seq": {Increment: 10}},
+	}
+	down := strings.Split(GenerateDownSQL(up, existing), "\n")
+
+	if down[0] != "ALTER SEQUENCE public.counter_seq OWNED BY NONE;" {
+		t.Errorf("expected owner to be reset, got: %s", down[0])
+	}
+	if down[1] != "ALTER SEQUENCE public.counter_seq START WITH 1 INCREMENT BY 10 MINVALUE 1 MAXVALUE 2147483647 CACHE 1 NO CYCLE;" {
+		t.Errorf("expected original options to be restored, got: %s", down[1])
+	}
+	if down[2] != "DROP SEQUENCE public.tickets_id_seq;" {
+		t.Errorf("expected DROP SEQUENCE, got: %s", down[2])
+	}
+}
+
+func TestGenerateDownSQL_Comment(t *testing.T) {
+	up := []string{
+		"COMMENT ON TABLE public.users IS 'All users; including ''guests''';",
+		"COMMENT ON CONSTRAINT users_pkey ON public.users IS NULL;",
+	}
+	existing := &ExistingState{
+		Comments: map[string]string{"CONSTRAINT users_pkey ON public.users": "Users' key"},
+	}
+	down := strings.Split(GenerateDownSQL(up, existing), "\n")
+
+	if down[0] != "COMMENT ON CONSTRAINT users_pkey ON public.users IS 'Users'' key';" {
+		t.Errorf("expected previous comment to be restored, got: %s", down[0])
+	}
+	if down[1] != "COMMENT ON TABLE public.users IS NULL;" {
+		t.Errorf("expected new comment to be removed, got: %s", down[1])
+	}
+}
+
 func TestGenerateDownSQL_CreateIndex(t *testing.T) {
 	up := []string{"CREATE UNIQUE INDEX idx_email ON public.users USING btree (email);"}
 	down := GenerateDownSQL(up, nil)
@@ -167,6 +209,27 @@ func TestGenerateDownSQL_DropIndex(t *testing.T) {
 	}
 }
 
+func TestGenerateDownSQL_AlterConstraintDeferral(t *testing.T) {
+	up := []string{"ALTER TABLE public.orders ALTER CONSTRAINT orders_user_fk DEFERRABLE INITIALLY DEFERRED;"}
+	existing := &ExistingState{
+		ConstraintDeferrals: map[string]string{"public.orders.orders_user_fk": "NOT DEFERRABLE"},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	if down != "ALTER TABLE public.orders ALTER CONSTRAINT orders_user_fk NOT DEFERRABLE;" {
+		t.Errorf("expected constraint deferral to be restored, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_ValidateConstraint(t *testing.T) {
+	up := []string{"ALTER TABLE public.orders VALIDATE CONSTRAINT orders_user_fk;"}
+	down := GenerateDownSQL(up, nil)
+
+	if strings.Contains(down, "WARNING") || !strings.HasPrefix(down, "--") {
+		t.Errorf("expected a comment for VALIDATE CONSTRAINT, got: %s", down)
+	}
+}
+
 func TestGenerateDownSQL_AddConstraint(t *testing.T) {
 	up := []string{"ALTER TABLE public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);"}
 	down := GenerateDownSQL(up, nil)
@@ -227,3 +290,400 @@ func TestBuildExistingState(t *testing.T) {
 		t.Error("expected name to be nullable")
 	}
 }
+
+func TestGenerateDownSQL_CreateExtension(t *testing.T) {
+	up := []string{`CREATE EXTENSION "uuid-ossp" SCHEMA public;`}
+	down := GenerateDownSQL(up, nil)
+
+	if down != `DROP EXTENSION "uuid-ossp";` {
+		t.Errorf("expected DROP EXTENSION, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_DropExtension_WithState(t *testing.T) {
+	up := []string{"DROP EXTENSION citext;"}
+	existing := &ExistingState{
+		ExtensionSchemas:  map[string]string{"citext": "public"},
+		ExtensionVersions: map[string]string{"citext": "1.6"},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	if down != "CREATE EXTENSION citext SCHEMA public VERSION '1.6';" {
+		t.Errorf("expected CREATE EXTENSION with schema and version, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_UpdateExtension(t *testing.T) {
+	up := []string{"ALTER EXTENSION postgis UPDATE TO '3.4.0';"}
+	existing := &ExistingState{
+		ExtensionVersions: map[string]string{"postgis": "3.3.0"},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	if down != "ALTER EXTENSION postgis UPDATE TO '3.3.0';" {
+		t.Errorf("expected restore to previous version, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_AddIdentity(t *testing.T) {
+	up := []string{"ALTER TABLE public.users ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY;"}
+	down := GenerateDownSQL(up, nil)
+
+	if down != "ALTER TABLE public.users ALTER COLUMN id DROP IDENTITY;" {
+		t.Errorf("expected DROP IDENTITY, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_DropIdentity_WithState(t *testing.T) {
+	up := []string{"ALTER TABLE public.users ALTER COLUMN id DROP IDENTITY;"}
+	existing := &ExistingState{
+		ColumnIdentities: map[string]*objects.ColumnIdentity{
+			"public.users.id": {Generation: objects.IdentityGenerationByDefault, SequenceOptions: objects.SequenceOptions{Increment: 5}},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	if down != "ALTER TABLE public.users ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY (INCREMENT BY 5);" {
+		t.Errorf("expected identity to be restored, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_AlterIdentity_WithState(t *testing.T) {
+	up := []string{"ALTER TABLE public.users ALTER COLUMN id SET GENERATED BY DEFAULT SET INCREMENT BY 10;"}
+	existing := &ExistingState{
+		ColumnTypes: map[string]string{"public.users.id": "INTEGER"},
+		ColumnIdentities: map[string]*objects.ColumnIdentity{
+			"public.users.id": {Generation: objects.IdentityGenerationAlways},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := "ALTER TABLE public.users ALTER COLUMN id SET GENERATED ALWAYS SET START WITH 1 SET INCREMENT BY 1 SET MINVALUE 1 SET MAXVALUE 2147483647 SET CACHE 1 SET NO CYCLE;"
+	if down != expected {
+		t.Errorf("expected identity options to be restored, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_DropExpression(t *testing.T) {
+	up := []string{"ALTER TABLE public.order_items ALTER COLUMN total DROP EXPRESSION;"}
+	down := GenerateDownSQL(up, nil)
+
+	if !strings.Contains(down, "WARNING") {
+		t.Errorf("expected WARNING for irreversible DROP EXPRESSION, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_AlterColumnType_WithModifiers(t *testing.T) {
+	up := []string{"ALTER TABLE public.events ALTER COLUMN at TYPE TIMESTAMP(3) WITH TIME ZONE;"}
+	existing := &ExistingState{
+		ColumnTypes: map[string]string{"public.events.at": "TIMESTAMP WITHOUT TIME ZONE"},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	if down != "ALTER TABLE public.events ALTER COLUMN at TYPE TIMESTAMP WITHOUT TIME ZONE;" {
+		t.Errorf("expected restore to previous type, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_Grants(t *testing.T) {
+	up := []string{
+		"GRANT SELECT, INSERT ON TABLE public.users TO reader;",
+		"REVOKE EXECUTE ON FUNCTION public.add_user(text, integer) FROM PUBLIC;",
+		"ALTER DEFAULT PRIVILEGES FOR ROLE owner IN SCHEMA public GRANT SELECT ON TABLES TO reader;",
+		"GRANT writers TO app;",
+	}
+	down := GenerateDownSQL(up, nil)
+
+	expected := strings.Join([]string{
+		"REVOKE writers FROM app;",
+		"ALTER DEFAULT PRIVILEGES FOR ROLE owner IN SCHEMA public REVOKE SELECT ON TABLES FROM reader;",
+		"GRANT EXECUTE ON FUNCTION public.add_user(text, integer) TO PUBLIC;",
+		"REVOKE SELECT, INSERT ON TABLE public.users FROM reader;",
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected grants to be reversed, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_Roles(t *testing.T) {
+	up := []string{
+		"CREATE ROLE app LOGIN;",
+		"ALTER ROLE reporting NOINHERIT;",
+		"DROP ROLE legacy;",
+	}
+	existing := &ExistingState{
+		Roles: map[string]*objects.Role{
+			"legacy": {Name: "legacy", Login: true, MemberOf: []string{"readers"}},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := strings.Join([]string{
+		"CREATE ROLE legacy LOGIN;",
+		"GRANT readers TO legacy;",
+		"ALTER ROLE reporting INHERIT;",
+		"DROP ROLE app;",
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected roles to be reversed, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_DropRole_NoState(t *testing.T) {
+	down := GenerateDownSQL([]string{"DROP ROLE legacy;"}, nil)
+
+	if !strings.Contains(down, "WARNING") {
+		t.Errorf("expected WARNING for DROP ROLE without state, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_Policies(t *testing.T) {
+	up := []string{
+		"CREATE POLICY tenant_isolation ON public.documents FOR ALL TO app USING (tenant_id = 1);",
+		"ALTER POLICY readers ON public.documents TO app USING (true);",
+		"DROP POLICY legacy ON public.documents;",
+		"ALTER TABLE public.documents ENABLE ROW LEVEL SECURITY;",
+		"ALTER TABLE public.documents NO FORCE ROW LEVEL SECURITY;",
+	}
+	existing := &ExistingState{
+		Policies: map[string]*objects.Policy{
+			"public.documents.readers": {Name: "readers", Command: objects.PolicyCommandSelect, Using: "(owner = CURRENT_USER)"},
+			"public.documents.legacy":  {Name: "legacy", Restrictive: true, Roles: []string{"app"}, Using: "true"},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := strings.Join([]string{
+		"ALTER TABLE public.documents FORCE ROW LEVEL SECURITY;",
+		"ALTER TABLE public.documents DISABLE ROW LEVEL SECURITY;",
+		"CREATE POLICY legacy ON public.documents AS RESTRICTIVE FOR ALL TO app USING (true);",
+		"ALTER POLICY readers ON public.documents TO PUBLIC USING ((owner = CURRENT_USER));",
+		"DROP POLICY tenant_isolation ON public.documents;",
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected policies to be reversed, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_DropPolicy_NoState(t *testing.T) {
+	down := GenerateDownSQL([]string{"DROP POLICY legacy ON public.documents;"}, nil)
+
+	if !strings.Contains(down, "WARNING") {
+		t.Errorf("expected WARNING for DROP POLICY without state, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_Partitions(t *testing.T) {
+	up := []string{
+		"ALTER TABLE public.orders DETACH PARTITION public.orders_us;",
+		"ALTER TABLE public.orders ATTACH PARTITION public.orders_us FOR VALUES IN ('us', 'ca');",
+		"CREATE TABLE public.orders_za PARTITION OF public.orders FOR VALUES IN ('za');",
+	}
+	existing := &ExistingState{
+		PartitionBounds: map[string]string{"public.orders_us": "FOR VALUES IN ('us')"},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := strings.Join([]string{
+		"DROP TABLE public.orders_za;",
+		"ALTER TABLE public.orders DETACH PARTITION public.orders_us;",
+		"ALTER TABLE public.orders ATTACH PARTITION public.orders_us FOR VALUES IN ('us');",
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected partitions to be reversed, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_Domains(t *testing.T) {
+	up := []string{
+		"CREATE DOMAIN app.email AS CITEXT;",
+		"ALTER DOMAIN app.positive SET DEFAULT 1;",
+		"ALTER DOMAIN app.positive SET NOT NULL;",
+		"ALTER DOMAIN app.positive DROP CONSTRAINT small_check;",
+		"ALTER DOMAIN app.positive ADD CONSTRAINT small_check CHECK (VALUE < 1000);",
+		"DROP DOMAIN app.legacy_code;",
+	}
+	existing := &ExistingState{
+		Domains: map[string]*objects.Domain{
+			"app.positive":    {Name: "positive", Type: "INTEGER", Checks: []*objects.DomainCheck{{Name: "small_check", Expression: "(VALUE < 100)"}}},
+			"app.legacy_code": {Name: "legacy_code", Type: "TEXT", Default: "''"},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := strings.Join([]string{
+		"CREATE DOMAIN app.legacy_code AS TEXT DEFAULT '';",
+		"ALTER DOMAIN app.positive DROP CONSTRAINT small_check;",
+		"ALTER DOMAIN app.positive ADD CONSTRAINT small_check CHECK ((VALUE < 100));",
+		"ALTER DOMAIN app.positive DROP NOT NULL;",
+		"ALTER DOMAIN app.positive DROP DEFAULT;",
+		"DROP DOMAIN app.email;",
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected domains to be reversed, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_CompositeTypes(t *testing.T) {
+	up := []string{
+		"CREATE TYPE app.money_amount AS (amount NUMERIC(12,2));",
+		"ALTER TYPE app.address ALTER ATTRIBUTE zip TYPE CHARACTER VARYING(10);",
+		"ALTER TYPE app.address DROP ATTRIBUTE fax;",
+		"ALTER TYPE app.address ADD ATTRIBUTE country TEXT;",
+	}
+	existing := &ExistingState{
+		CompositeTypes: map[string]*objects.CompositeType{
+			"app.address": {Name: "address", Attributes: []*objects.Attribute{{Name: "zip", Type: "INTEGER"}, {Name: "fax", Type: "TEXT"}}},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := strings.Join([]string{
+		"ALTER TYPE app.address DROP ATTRIBUTE country;",
+		"ALTER TYPE app.address ADD ATTRIBUTE fax TEXT;",
+		"ALTER TYPE app.address ALTER ATTRIBUTE zip TYPE INTEGER;",
+		"DROP TYPE app.money_amount;",
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected composite types to be reversed, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_TableStorage(t *testing.T) {
+	up := []string{
+		"CREATE UNLOGGED TABLE app.events () WITH (fillfactor=70);",
+		"ALTER TABLE app.users SET LOGGED;",
+		"ALTER TABLE app.users SET TABLESPACE fast_ssd;",
+		"ALTER TABLE app.users RESET (toast.autovacuum_enabled);",
+		"ALTER TABLE app.users SET (autovacuum_analyze_threshold=500, fillfactor=80);",
+		`ALTER TABLE app.users ALTER COLUMN name TYPE TEXT COLLATE "C";`,
+	}
+	existing := &ExistingState{
+		ColumnTypes: map[string]string{"app.users.name": `TEXT COLLATE "en_US"`},
+		Tablespaces: map[string]string{"app.users": "pg_default"},
+		StorageParameters: map[string]map[string]string{
+			"app.users": {"fillfactor": "70", "toast.autovacuum_enabled": "false"},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := strings.Join([]string{
+		`ALTER TABLE app.users ALTER COLUMN name TYPE TEXT COLLATE "en_US";`,
+		"ALTER TABLE app.users RESET (autovacuum_analyze_threshold);",
+		"ALTER TABLE app.users SET (fillfactor=70);",
+		"ALTER TABLE app.users SET (toast.autovacuum_enabled=false);",
+		"ALTER TABLE app.users SET TABLESPACE pg_default;",
+		"ALTER TABLE app.users SET UNLOGGED;",
+		"DROP TABLE app.events;",
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected table storage to be reversed, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_Publications(t *testing.T) {
+	up := []string{
+		"ALTER PUBLICATION cdc DROP TABLE app.orders;",
+		"DROP PUBLICATION old;",
+		"ALTER PUBLICATION cdc ADD TABLE app.orders WHERE (total > 100);",
+		"ALTER PUBLICATION cdc SET (publish = 'insert');",
+		"CREATE PUBLICATION everything FOR ALL TABLES;",
+	}
+	existing := &ExistingState{
+		Publications: map[string]*objects.Publication{
+			"cdc": {Name: "cdc", Tables: []*objects.PublicationTable{{Table: "app.orders", Where: "(total > 0)"}}},
+			"old": {Name: "old", Tables: []*objects.PublicationTable{{Table: "app.users"}}, Publish: []string{"insert"}},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := strings.Join([]string{
+		"DROP PUBLICATION everything;",
+		"ALTER PUBLICATION cdc SET (publish = 'insert, update, delete, truncate');",
+		"ALTER PUBLICATION cdc DROP TABLE app.orders;",
+		"CREATE PUBLICATION old FOR TABLE app.users WITH (publish = 'insert');",
+		"ALTER PUBLICATION cdc ADD TABLE app.orders WHERE ((total > 0));",
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected publications to be reversed, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_Subscriptions(t *testing.T) {
+	up := []string{
+		"ALTER SUBSCRIPTION legacy_sub DISABLE;",
+		"ALTER SUBSCRIPTION legacy_sub SET (slot_name = NONE);",
+		"DROP SUBSCRIPTION legacy_sub;",
+		"ALTER SUBSCRIPTION orders_sub DISABLE;",
+		"ALTER SUBSCRIPTION orders_sub SET (slot_name = 'orders');",
+		"ALTER SUBSCRIPTION orders_sub CONNECTION 'host=new dbname=app';",
+		"ALTER SUBSCRIPTION orders_sub SET PUBLICATION audit, cdc WITH (refresh = false);",
+		"ALTER SUBSCRIPTION orders_sub ENABLE;",
+		"CREATE SUBSCRIPTION standby CONNECTION 'host=primary dbname=app' PUBLICATION cdc WITH (create_slot = false, enabled = false);",
+		"ALTER SUBSCRIPTION standby ENABLE;",
+	}
+	existing := &ExistingState{
+		Subscriptions: map[string]*objects.Subscription{
+			"legacy_sub": {Name: "legacy_sub", Connection: "host=primary dbname=app", Publications: []string{"legacy"}, SlotName: "legacy"},
+			"orders_sub": {Name: "orders_sub", Connection: "host=old dbname=app", Publications: []string{"cdc"}},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := strings.Join([]string{
+		"ALTER SUBSCRIPTION standby DISABLE;",
+		"ALTER SUBSCRIPTION standby SET (slot_name = NONE);",
+		"DROP SUBSCRIPTION standby;",
+		"ALTER SUBSCRIPTION orders_sub DISABLE;",
+		"ALTER SUBSCRIPTION orders_sub SET PUBLICATION cdc WITH (refresh = false);",
+		"ALTER SUBSCRIPTION orders_sub CONNECTION 'host=old dbname=app';",
+		"ALTER SUBSCRIPTION orders_sub SET (slot_name = 'orders_sub');",
+		"ALTER SUBSCRIPTION orders_sub ENABLE;",
+		"CREATE SUBSCRIPTION legacy_sub CONNECTION 'host=primary dbname=app' PUBLICATION legacy WITH (create_slot = false, enabled = false, slot_name = 'legacy');",
+		"ALTER SUBSCRIPTION legacy_sub SET (slot_name = 'legacy');",
+		"ALTER SUBSCRIPTION legacy_sub ENABLE;",
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected subscriptions to be reversed, got: %s", down)
+	}
+}
+
+func TestGenerateDownSQL_QuotedIdentifiers(t *testing.T) {
+	up := []string{
+		`CREATE TABLE public."user" ();`,
+		`ALTER TABLE public."user" ADD COLUMN "createdAt" TIMESTAMP NOT NULL;`,
+		`ALTER TABLE "My Schema"."Order Lines" ALTER COLUMN "unit.price" SET NOT NULL;`,
+		`ALTER TABLE public."order" ALTER COLUMN total TYPE BIGINT;`,
+		`CREATE INDEX "Order_idx" ON public."order" USING btree ("createdAt");`,
+		`ALTER TYPE app."Address" DROP ATTRIBUTE "zipCode";`,
+		`DROP DOMAIN "App"."Positive";`,
+		`ALTER PUBLICATION cdc DROP TABLE app."order";`,
+	}
+	existing := &ExistingState{
+		ColumnTypes: map[string]string{`public."order".total`: "INTEGER"},
+		CompositeTypes: map[string]*objects.CompositeType{
+			`app."Address"`: {Name: "Address", Attributes: []*objects.Attribute{{Name: "zipCode", Type: "TEXT"}}},
+		},
+		Domains: map[string]*objects.Domain{
+			`"App"."Positive"`: {Name: "Positive", Type: "INTEGER"},
+		},
+		Publications: map[string]*objects.Publication{
+			"cdc": {Name: "cdc", Tables: []*objects.PublicationTable{{Table: "app.order", Columns: []string{"id", "createdAt"}}}},
+		},
+	}
+	down := GenerateDownSQL(up, existing)
+
+	expected := strings.Join([]string{
+		`ALTER PUBLICATION cdc ADD TABLE app."order" (id, "createdAt");`,
+		`CREATE DOMAIN "App"."Positive" AS INTEGER;`,
+		`ALTER TYPE app."Address" ADD ATTRIBUTE "zipCode" TEXT;`,
+		`DROP INDEX "Order_idx";`,
+		`ALTER TABLE public."order" ALTER COLUMN total TYPE INTEGER;`,
+		`ALTER TABLE "My Schema"."Order Lines" ALTER COLUMN "unit.price" DROP NOT NULL;`,
+		`ALTER TABLE public."user" DROP COLUMN "createdAt";`,
+		`DROP TABLE public."user";`,
+	}, "\n")
+	if down != expected {
+		t.Errorf("expected quoted identifiers to be reversed, got: %s", down)
+	}
+}
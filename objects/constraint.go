@@ -0,0 +1,58 @@
+package objects
+
+import "unicode/utf8"
+
+// ConstraintTypes returns the kinds of table constraints.
+func ConstraintTypes() []ConstraintType {
+	return []ConstraintType{ConstraintTypePrimaryKey, ConstraintTypeUnique, ConstraintTypeForeignKey, ConstraintTypeCheck, ConstraintTypeExclusion}
+}
+
+// ConstraintActions returns the referential actions a foreign key can take on
+// delete or update.
+func ConstraintActions() []ConstraintAction {
+	return []ConstraintAction{ConstraintActionNoAction, ConstraintActionRestrict, ConstraintActionCascade, ConstraintActionSetNull, ConstraintActionSetDefault}
+}
+
+// PrimaryKeyName returns the name PostgreSQL gives the primary key of the
+// table when none is chosen, with the table name clipped to fit.
+func PrimaryKeyName(table string) string {
+	name := table
+	for len(name)+len("_pkey") > maxIdentifierLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name + "_pkey"
+}
+
+// NormalizePrimaryKey makes columns marked primary_key and a PRIMARY KEY
+// constraint two ways of declaring the same key. A table with marked columns
+// but no constraint gets one named after the table, and when no column is
+// marked, the targets of the constraint are. Marked columns that differ from
+// the constraint are left for validation to report.
+func (t *Table) NormalizePrimaryKey() {
+	marked := []string{}
+	for _, c := range t.Columns {
+		if c.IsPrimaryKey {
+			marked = append(marked, c.Name)
+		}
+	}
+
+	for _, c := range t.Constraints {
+		if c.Type != ConstraintTypePrimaryKey {
+			continue
+		}
+		if len(marked) == 0 {
+			for _, target := range c.Targets {
+				if col := t.getColumn(target); col != nil {
+					col.IsPrimaryKey = true
+				}
+			}
+		}
+		return
+	}
+
+	if len(marked) > 0 {
+		key := &Constraint{Name: PrimaryKeyName(t.Name), Type: ConstraintTypePrimaryKey, Targets: marked}
+		t.Constraints = append([]*Constraint{key}, t.Constraints...)
+	}
+}
@@ -0,0 +1,94 @@
+package objects
+
+import (
+	"fmt"
+	"strings"
+)
+
+// canonicalTypeSQL renders a user-written type in canonical form, or returns
+// it unchanged when it cannot be parsed.
+func canonicalTypeSQL(typ string) string {
+	parsed, err := ParseType(typ)
+	if err != nil {
+		return typ
+	}
+	return parsed.SQL()
+}
+
+// TypeSQL returns the canonical base type of the domain.
+func (d *Domain) TypeSQL() string {
+	return canonicalTypeSQL(d.Type)
+}
+
+// TypeSQL returns the canonical type of the attribute.
+func (a *Attribute) TypeSQL() string {
+	return canonicalTypeSQL(a.Type)
+}
+
+// SQL renders the check as used by CREATE DOMAIN and ALTER DOMAIN ADD.
+func (c *DomainCheck) SQL() string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", QuoteIdent(c.Name), c.Expression)
+}
+
+// CreateSQL renders the CREATE DOMAIN statement for the domain in the given
+// namespace.
+func (d *Domain) CreateSQL(namespace string) string {
+	statement := fmt.Sprintf("CREATE DOMAIN %s AS %s", QualifiedName(namespace, d.Name), d.TypeSQL())
+	if d.Default != "" {
+		statement += " DEFAULT " + d.Default
+	}
+	if d.NotNull {
+		statement += " NOT NULL"
+	}
+	for _, c := range d.Checks {
+		statement += " " + c.SQL()
+	}
+	return statement + ";"
+}
+
+// CreateSQL renders the CREATE TYPE statement for the composite type in the
+// given namespace.
+func (t *CompositeType) CreateSQL(namespace string) string {
+	attributes := []string{}
+	for _, a := range t.Attributes {
+		attributes = append(attributes, fmt.Sprintf("%s %s", QuoteIdent(a.Name), a.TypeSQL()))
+	}
+	return fmt.Sprintf("CREATE TYPE %s AS (%s);", QualifiedName(namespace, t.Name), strings.Join(attributes, ", "))
+}
+
+// GetCheck returns the check of the domain with the given name, or nil.
+func (d *Domain) GetCheck(name string) *DomainCheck {
+	for _, c := range d.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// GetAttribute returns the attribute of the type with the given name, or nil.
+func (t *CompositeType) GetAttribute(name string) *Attribute {
+	for _, a := range t.Attributes {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// usedBy reports whether a column of the given type uses the domain of the
+// named namespace. The type names the domain bare or qualified with that
+// namespace, and is compared by SQL rules: bare names fold to lower case.
+func (d *Domain) usedBy(namespace string, parsed *ColumnType) bool {
+	if parsed.ArrayDimensions > 0 {
+		return false
+	}
+	parts := SplitQualified(parsed.Name)
+	switch len(parts) {
+	case 1:
+		return parts[0] == d.Name
+	case 2:
+		return namespace != "" && parts[0] == namespace && parts[1] == d.Name
+	}
+	return false
+}
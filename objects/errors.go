@@ -0,0 +1,89 @@
+package objects
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Position is where an object is declared in the desired state files.
+type Position struct {
+	File string
+	Line int
+}
+
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
+}
+
+// Positions maps the objects read from the desired state files, by pointer, to
+// where they are declared.
+type Positions map[interface{}]Position
+
+// ValidationError is a problem with one object of the desired state. Path
+// locates the object, such as public.orders.columns[2], and Position the file
+// and line it is declared on, when known.
+type ValidationError struct {
+	Position
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	msg := e.Err.Error()
+	if e.Path != "" {
+		msg = e.Path + ": " + msg
+	}
+	if e.File != "" {
+		msg = e.Position.String() + ": " + msg
+	}
+	return msg
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors are all the problems found in the desired state, one per
+// line.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := []string{}
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validator collects the problems found while walking the desired state.
+type validator struct {
+	positions Positions
+	errs      ValidationErrors
+}
+
+// check records err, if any, for the object at path. It is located at the
+// first of the given objects with a known position, so that columns copied
+// from a template fall back to their table.
+func (v *validator) check(path string, err error, objects ...interface{}) {
+	if err == nil {
+		return
+	}
+	e := &ValidationError{Path: path, Err: err}
+	for _, o := range objects {
+		if p, ok := v.positions[o]; ok {
+			e.Position = p
+			break
+		}
+	}
+	v.errs = append(v.errs, e)
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
@@ -0,0 +1,169 @@
+package objects
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	expressionCastRegex        = regexp.MustCompile(`::\s*(?:"[^"]*"|[a-z_][a-z0-9_$]*)(?:\.(?:"[^"]*"|[a-z_][a-z0-9_$]*))*(?:\s+(?:varying|precision|with(?:out)?\s+time\s+zone))?(?:\s*\(\s*\d+(?:\s*,\s*\d+)?\s*\))?(?:\s*\[\])*`)
+	expressionTypedLiteral     = regexp.MustCompile(`\b(?:interval|date|time|timestamp|timestamptz|text|varchar|json|jsonb|uuid|numeric|integer|bigint|boolean|bool)\s+(\x00\d+\x00)`)
+	expressionSpaceRegex       = regexp.MustCompile(`\s+`)
+	expressionPunctuationRegex = regexp.MustCompile(`\s*([(),+\-*/%<>=|&])\s*`)
+	expressionNumberRegex      = regexp.MustCompile(`^'(-?\d+(?:\.\d+)?)'$`)
+	expressionPlaceholderRegex = regexp.MustCompile(`\x00(\d+)\x00`)
+	expressionWrappedIdent     = regexp.MustCompile(`(^|[^a-z0-9_$"])\(([a-z_][a-z0-9_$]*|"[^"]*")\)`)
+)
+
+// expressionEquivalents maps expressions PostgreSQL treats as the same thing
+// but reports differently.
+var expressionEquivalents = map[string]string{
+	"current_timestamp":       "now()",
+	"transaction_timestamp()": "now()",
+}
+
+// NormalizeExpression brings a SQL expression, such as a column default, into
+// a canonical form so that the way PostgreSQL reports it and the way it was
+// written compare equal. Casts are dropped, identifiers and keywords are
+// lower-cased, whitespace is collapsed and redundant outer parentheses are
+// removed. String literals and quoted identifiers are kept verbatim.
+func NormalizeExpression(expr string) string {
+	literals := []string{}
+	var masked strings.Builder
+
+	for i := 0; i < len(expr); i++ {
+		quote := expr[i]
+		if quote != '\'' && quote != '"' {
+			masked.WriteByte(expr[i])
+			continue
+		}
+
+		end := i + 1
+		for end < len(expr) {
+			if expr[end] == quote {
+				if end+1 < len(expr) && expr[end+1] == quote {
+					end += 2
+					continue
+				}
+				break
+			}
+			end++
+		}
+		if end >= len(expr) {
+			end = len(expr) - 1
+		}
+
+		literal := expr[i : end+1]
+		if quote == '"' {
+			masked.WriteString(literal)
+		} else {
+			masked.WriteString(fmt.Sprintf("\x00%d\x00", len(literals)))
+			literals = append(literals, literal)
+		}
+		i = end
+	}
+
+	normalized := lowerUnquoted(masked.String())
+	normalized = expressionSpaceRegex.ReplaceAllString(normalized, " ")
+	normalized = expressionCastRegex.ReplaceAllString(normalized, "")
+	normalized = expressionTypedLiteral.ReplaceAllString(normalized, "$1")
+	normalized = expressionPunctuationRegex.ReplaceAllString(normalized, "$1")
+	normalized = strings.TrimSpace(normalized)
+	for wrapped := ""; wrapped != normalized; {
+		wrapped = normalized
+		normalized = expressionWrappedIdent.ReplaceAllString(normalized, "$1$2")
+	}
+	normalized = stripOuterParentheses(normalized)
+
+	for from, to := range expressionEquivalents {
+		if normalized == from {
+			normalized = to
+		}
+	}
+
+	normalized = expressionPlaceholderRegex.ReplaceAllStringFunc(normalized, func(placeholder string) string {
+		index, _ := strconv.Atoi(strings.Trim(placeholder, "\x00"))
+		literal := literals[index]
+		if matches := expressionNumberRegex.FindStringSubmatch(literal); matches != nil && placeholder == normalized {
+			return matches[1]
+		}
+		return literal
+	})
+
+	return unqualifySequences(normalized, "public")
+}
+
+// NormalizeExpressionIn normalizes an expression of an object in the given
+// namespace. Besides those in public, sequences in the namespace are left
+// unqualified.
+func NormalizeExpressionIn(namespace, expr string) string {
+	return unqualifySequences(NormalizeExpression(expr), namespace)
+}
+
+// unqualifySequences drops the namespace from the sequences nextval is called
+// with. PostgreSQL reports sequences in a schema on the search path, such as
+// public or, often, the schema of the column itself, unqualified.
+func unqualifySequences(expr, namespace string) string {
+	prefixes := []string{`"` + strings.ReplaceAll(namespace, `"`, `""`) + `"`}
+	if QuoteIdent(namespace) == namespace {
+		prefixes = append(prefixes, namespace)
+	}
+	for _, prefix := range prefixes {
+		expr = strings.ReplaceAll(expr, "nextval('"+prefix+".", "nextval('")
+	}
+	return expr
+}
+
+// ExpressionsEqual reports whether two SQL expressions are equal once
+// normalized.
+func ExpressionsEqual(a, b string) bool {
+	return NormalizeExpression(a) == NormalizeExpression(b)
+}
+
+// ExpressionsEqualIn reports whether two SQL expressions of an object in the
+// given namespace are equal once normalized.
+func ExpressionsEqualIn(namespace, a, b string) bool {
+	return NormalizeExpressionIn(namespace, a) == NormalizeExpressionIn(namespace, b)
+}
+
+func lowerUnquoted(expr string) string {
+	var result strings.Builder
+	quoted := false
+	for _, r := range expr {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if quoted {
+			result.WriteRune(r)
+		} else {
+			result.WriteString(strings.ToLower(string(r)))
+		}
+	}
+	return result.String()
+}
+
+func stripOuterParentheses(expr string) string {
+	for strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		depth := 0
+		wraps := true
+		for i, r := range expr {
+			switch r {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 && i < len(expr)-1 {
+				wraps = false
+				break
+			}
+		}
+		if !wraps {
+			break
+		}
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	return expr
+}
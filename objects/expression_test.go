@@ -0,0 +1,70 @@
+package objects
+
+import "testing"
+
+func TestExpressionsEqual(t *testing.T) {
+	cases := []struct {
+		reported, written string
+	}{
+		{"nextval('customers_id_seq'::regclass)", "nextval('customers_id_seq')"},
+		{"nextval('customers_id_seq'::regclass)", "nextval('public.customers_id_seq')"},
+		{`nextval('"App".customers_id_seq'::regclass)`, `nextval('"App".customers_id_seq')`},
+		{"'pending'::character varying", "'pending'"},
+		{"now()", "NOW()"},
+		{"CURRENT_TIMESTAMP", "now()"},
+		{"'-1'::integer", "-1"},
+		{"(now() + '1 day'::interval)", "NOW() + interval '1 day'"},
+		{"'{}'::jsonb", "'{}'"},
+		{"(quantity * unit_price_cents)", "quantity*unit_price_cents"},
+		{"'2026-01-01 00:00:00'::timestamp without time zone", "'2026-01-01 00:00:00'"},
+		{"lower((email)::text)", "lower(email)"},
+		{"", ""},
+	}
+
+	for _, tc := range cases {
+		if !ExpressionsEqual(tc.reported, tc.written) {
+			t.Errorf("expected %q and %q to be equal, normalized to %q and %q",
+				tc.reported, tc.written, NormalizeExpression(tc.reported), NormalizeExpression(tc.written))
+		}
+	}
+}
+
+func TestExpressionsEqual_Different(t *testing.T) {
+	cases := []struct {
+		a, b string
+	}{
+		{"'Pending'", "'pending'"},
+		{"nextval('users_id_seq')", "nextval('orders_id_seq')"},
+		{"nextval('users_id_seq')", `nextval('"App".users_id_seq')`},
+		{"0", "1"},
+		{"(a + b) * c", "a + b * c"},
+		{"", "0"},
+	}
+
+	for _, tc := range cases {
+		if ExpressionsEqual(tc.a, tc.b) {
+			t.Errorf("expected %q and %q to differ", tc.a, tc.b)
+		}
+	}
+}
+
+func TestExpressionsEqualIn(t *testing.T) {
+	cases := []struct {
+		namespace, a, b string
+		equal           bool
+	}{
+		{"app", "nextval('users_id_seq'::regclass)", "nextval('app.users_id_seq')", true},
+		{"app", "nextval('users_id_seq'::regclass)", `nextval('"app".users_id_seq')`, true},
+		{"Sales", "nextval('orders_id_seq'::regclass)", `nextval('"Sales".orders_id_seq')`, true},
+		{"app", "nextval('users_id_seq'::regclass)", "nextval('public.users_id_seq')", true},
+		{"Sales", "nextval('orders_id_seq'::regclass)", "nextval('sales.orders_id_seq')", false},
+		{"app", "nextval('users_id_seq'::regclass)", "nextval('other.users_id_seq')", false},
+	}
+
+	for _, tc := range cases {
+		if ExpressionsEqualIn(tc.namespace, tc.a, tc.b) != tc.equal {
+			t.Errorf("expected %q and %q in %s to be equal: %v, normalized to %q and %q",
+				tc.a, tc.b, tc.namespace, tc.equal, NormalizeExpressionIn(tc.namespace, tc.a), NormalizeExpressionIn(tc.namespace, tc.b))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package objects
+
+import "strings"
+
+// keywords lists the PostgreSQL keywords that cannot be used as a bare
+// identifier everywhere: the reserved ones and those only allowed as a column
+// or function name. quote_ident quotes the same set.
+var keywords = map[string]bool{}
+
+func init() {
+	for _, keyword := range strings.Fields(`
+		all analyse analyze and any array as asc asymmetric authorization between
+		bigint binary bit boolean both case cast char character check coalesce
+		collate collation column concurrently constraint create cross
+		current_catalog current_date current_role current_schema current_time
+		current_timestamp current_user dec decimal default deferrable desc distinct
+		do else end except exists extract false fetch float for foreign freeze from
+		full grant greatest group grouping having ilike in initially inner inout int
+		integer intersect interval into is isnull join json json_array
+		json_arrayagg json_exists json_object json_objectagg json_query json_scalar
+		json_serialize json_table json_value lateral leading least left like limit
+		localtime localtimestamp merge_action national natural nchar none normalize
+		not notnull null nullif numeric offset on only or order out outer overlaps
+		overlay placing position precision primary real references returning right
+		row select session_user setof similar smallint some substring symmetric
+		system_user table tablesample then time timestamp to trailing treat trim
+		true union unique user using values varchar variadic verbose when where
+		window with xmlattributes xmlconcat xmlelement xmlexists xmlforest
+		xmlnamespaces xmlparse xmlpi xmlroot xmlserialize xmltable`) {
+		keywords[keyword] = true
+	}
+}
+
+// QuoteIdent returns name quoted as a PostgreSQL identifier when it cannot be
+// written bare, e.g. the "uuid-ossp" extension, a table named "user" or a
+// mixed-case column such as "createdAt".
+func QuoteIdent(name string) string {
+	if name == "" {
+		return name
+	}
+	for i, r := range name {
+		if (r >= 'a' && r <= 'z') || r == '_' || (i > 0 && (r >= '0' && r <= '9' || r == '$')) {
+			continue
+		}
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+	if keywords[name] {
+		return `"` + name + `"`
+	}
+	return name
+}
+
+// QuoteIdents quotes each of the names and joins them with commas, as in a
+// column list.
+func QuoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = QuoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// QualifiedName returns the object name qualified with its namespace, both
+// quoted where needed, e.g. public."order".
+func QualifiedName(namespace, name string) string {
+	return QuoteIdent(namespace) + "." + QuoteIdent(name)
+}
+
+// SplitQualified splits a possibly qualified name as written in SQL into its
+// parts, e.g. public."Order" into public and Order. Quoted parts are unquoted
+// and keep their case, bare parts are folded to lower case like PostgreSQL
+// does.
+func SplitQualified(sql string) []string {
+	parts := []string{}
+	var part strings.Builder
+	quoted := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quoted && c == '"' && i+1 < len(sql) && sql[i+1] == '"':
+			part.WriteByte('"')
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+			part.WriteByte(c)
+		case c == '.':
+			parts = append(parts, part.String())
+			part.Reset()
+		case c >= 'A' && c <= 'Z':
+			part.WriteByte(c + 'a' - 'A')
+		default:
+			part.WriteByte(c)
+		}
+	}
+	return append(parts, part.String())
+}
+
+// UnquoteIdent returns the name of an identifier as written in SQL, the
+// inverse of QuoteIdent.
+func UnquoteIdent(sql string) string {
+	return strings.Join(SplitQualified(sql), ".")
+}
+
+// QuoteLiteral returns value as a PostgreSQL string literal.
+func QuoteLiteral(value string) string {
+	return `'` + strings.ReplaceAll(value, `'`, `''`) + `'`
+}
@@ -0,0 +1,57 @@
+package objects
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	cases := []struct {
+		name, quoted string
+	}{
+		{"users", "users"},
+		{"order_items_2024", "order_items_2024"},
+		{"user", `"user"`},
+		{"order", `"order"`},
+		{"position", `"position"`},
+		{"createdAt", `"createdAt"`},
+		{"uuid-ossp", `"uuid-ossp"`},
+		{"2fa", `"2fa"`},
+		{`say "hi"`, `"say ""hi"""`},
+		{"", ""},
+	}
+
+	for _, tc := range cases {
+		if quoted := QuoteIdent(tc.name); quoted != tc.quoted {
+			t.Errorf("expected %q to be quoted as %s, got %s", tc.name, tc.quoted, quoted)
+		}
+	}
+}
+
+func TestSplitQualified(t *testing.T) {
+	cases := []struct {
+		sql   string
+		parts []string
+	}{
+		{"public.users", []string{"public", "users"}},
+		{`public."user"`, []string{"public", "user"}},
+		{`"My Schema"."Order.Lines"`, []string{"My Schema", "Order.Lines"}},
+		{`Public.Users`, []string{"public", "users"}},
+		{`"say ""hi"""`, []string{`say "hi"`}},
+		{`app."Orders".id`, []string{"app", "Orders", "id"}},
+	}
+
+	for _, tc := range cases {
+		if parts := SplitQualified(tc.sql); strings.Join(parts, "|") != strings.Join(tc.parts, "|") {
+			t.Errorf("expected %s to split into %q, got %q", tc.sql, tc.parts, parts)
+		}
+	}
+}
+
+func TestSplitQualified_RoundTrip(t *testing.T) {
+	for _, name := range []string{"users", "user", "createdAt", "uuid-ossp", "Order.Lines", `say "hi"`} {
+		if parts := SplitQualified(QualifiedName("app", name)); len(parts) != 2 || parts[0] != "app" || parts[1] != name {
+			t.Errorf("expected %q to survive quoting, got %q", name, parts)
+		}
+	}
+}
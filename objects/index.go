@@ -0,0 +1,91 @@
+package objects
+
+import "strings"
+
+// opclassAlgorithms maps well-known operator classes to the index methods
+// they belong to. Operator classes not listed are not checked.
+var opclassAlgorithms = map[string][]IndexAlgorithm{
+	"text_pattern_ops":       {IndexAlgorithmBTree, IndexAlgorithmHash},
+	"varchar_pattern_ops":    {IndexAlgorithmBTree, IndexAlgorithmHash},
+	"bpchar_pattern_ops":     {IndexAlgorithmBTree, IndexAlgorithmHash},
+	"jsonb_path_ops":         {IndexAlgorithmGIN},
+	"gin_trgm_ops":           {IndexAlgorithmGIN},
+	"gist_trgm_ops":          {IndexAlgorithmGiST},
+	"gist_geometry_ops":      {IndexAlgorithmGiST},
+	"quad_point_ops":         {IndexAlgorithmSPGiST},
+	"kd_point_ops":           {IndexAlgorithmSPGiST},
+	"int4_minmax_ops":        {IndexAlgorithmBRIN},
+	"int8_minmax_ops":        {IndexAlgorithmBRIN},
+	"timestamp_minmax_ops":   {IndexAlgorithmBRIN},
+	"timestamptz_minmax_ops": {IndexAlgorithmBRIN},
+	"date_minmax_ops":        {IndexAlgorithmBRIN},
+	"int4_bloom_ops":         {IndexAlgorithmBRIN},
+	"int8_bloom_ops":         {IndexAlgorithmBRIN},
+	"uuid_bloom_ops":         {IndexAlgorithmBRIN},
+	"text_bloom_ops":         {IndexAlgorithmBRIN},
+}
+
+// ginTypes are the column types GIN can index without an explicit operator
+// class. Array columns can be indexed as well.
+var ginTypes = map[string]bool{
+	"JSONB":    true,
+	"TSVECTOR": true,
+	"HSTORE":   true,
+}
+
+// IndexAlgorithms returns every index method PostgreSQL ships with.
+func IndexAlgorithms() []IndexAlgorithm {
+	return []IndexAlgorithm{IndexAlgorithmBTree, IndexAlgorithmHash, IndexAlgorithmGiST, IndexAlgorithmSPGiST, IndexAlgorithmGIN, IndexAlgorithmBRIN}
+}
+
+// GetAlgorithm returns the index method, btree when none is set.
+func (i *Index) GetAlgorithm() IndexAlgorithm {
+	if i.Algorithm == "" {
+		return IndexAlgorithmBTree
+	}
+	return IndexAlgorithm(strings.ToLower(string(i.Algorithm)))
+}
+
+// GetKeys returns the keys of the index, turning plain Columns into keys when
+// no Keys are set.
+func (i *Index) GetKeys() []*IndexKey {
+	if len(i.Keys) > 0 {
+		return i.Keys
+	}
+	keys := make([]*IndexKey, len(i.Columns))
+	for idx, col := range i.Columns {
+		keys[idx] = &IndexKey{Column: col}
+	}
+	return keys
+}
+
+// GetOrder returns the sort order of the key, ASC when none is set.
+func (k *IndexKey) GetOrder() IndexOrder {
+	if k.Order == "" {
+		return IndexOrderAsc
+	}
+	return IndexOrder(strings.ToUpper(string(k.Order)))
+}
+
+// GetNulls returns where nulls sort, defaulting to what PostgreSQL picks for
+// the sort order.
+func (k *IndexKey) GetNulls() IndexNulls {
+	if k.Nulls != "" {
+		return IndexNulls(strings.ToUpper(string(k.Nulls)))
+	}
+	if k.GetOrder() == IndexOrderDesc {
+		return IndexNullsFirst
+	}
+	return IndexNullsLast
+}
+
+// IsPlain reports whether the key is a bare column without opclass or a
+// non-default sort order, so it can be written as an entry of Columns.
+func (k *IndexKey) IsPlain() bool {
+	return k.Column != "" && k.Expression == "" && k.Opclass == "" &&
+		k.GetOrder() == IndexOrderAsc && k.GetNulls() == IndexNullsLast
+}
+
+func (i *Index) storageParameters() []string {
+	return storageParameters(i.With)
+}
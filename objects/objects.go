@@ -1,35 +1,306 @@
 package objects
 
+// Database is the desired state of a whole database. A nil Roles,
+// Publications or Subscriptions list leaves those unmanaged. Positions, when
+// read from files, locate the objects in validation errors.
 type Database struct {
-	Name       string       `yaml:"name"`
-	Namespaces []*Namespace `yaml:"namespaces"`
+	Name          string          `yaml:"name"`
+	Roles         []*Role         `yaml:"roles,omitempty"`
+	Extensions    []*Extension    `yaml:"extensions,omitempty"`
+	Namespaces    []*Namespace    `yaml:"namespaces"`
+	Publications  []*Publication  `yaml:"publications,omitempty"`
+	Subscriptions []*Subscription `yaml:"subscriptions,omitempty"`
+	Positions     Positions       `yaml:"-"`
 }
 
+// Role is a database role. Inherit defaults to true, as in PostgreSQL.
+// Passwords are never part of the desired state; set them out of band.
+type Role struct {
+	Name     string   `yaml:"name" jsonschema:"required"`
+	Login    bool     `yaml:"login,omitempty"`
+	Inherit  *bool    `yaml:"inherit,omitempty"`
+	MemberOf []string `yaml:"member_of,omitempty"`
+}
+
+// Grant gives a role privileges on the object it is listed on. The role
+// PUBLIC stands for every role and ALL for every privilege of the object.
+type Grant struct {
+	Role       string   `yaml:"role" jsonschema:"required"`
+	Privileges []string `yaml:"privileges" jsonschema:"required"`
+}
+
+// Function is an existing function whose grants are managed. Functions are
+// not created or dropped; Arguments is the argument type list identifying the
+// overload, e.g. "integer, text".
+type Function struct {
+	Name      string   `yaml:"name" jsonschema:"required"`
+	Arguments string   `yaml:"arguments"`
+	Grants    []*Grant `yaml:"grants"`
+}
+
+// DefaultPrivilege holds the grants applied to objects of one kind (TABLES,
+// SEQUENCES, FUNCTIONS or TYPES) that Role creates in the namespace later on.
+type DefaultPrivilege struct {
+	Role   string   `yaml:"role" jsonschema:"required"`
+	On     string   `yaml:"on" jsonschema:"required"`
+	Grants []*Grant `yaml:"grants"`
+}
+
+// Extension is a PostgreSQL extension installed in the database. Schema and
+// Version are optional; when omitted the server defaults are used.
+type Extension struct {
+	Name    string  `yaml:"name" jsonschema:"required"`
+	Schema  string  `yaml:"schema,omitempty"`
+	Version string  `yaml:"version,omitempty"`
+	Comment *string `yaml:"comment,omitempty"`
+}
+
+// Publication publishes the changes of its Tables, or of every table when
+// AllTables is set, for logical replication. Publish lists the published
+// operations; all of insert, update, delete and truncate when empty.
+type Publication struct {
+	Name      string              `yaml:"name" jsonschema:"required"`
+	AllTables bool                `yaml:"all_tables,omitempty"`
+	Tables    []*PublicationTable `yaml:"tables,omitempty"`
+	Publish   []string            `yaml:"publish,omitempty"`
+	Comment   *string             `yaml:"comment,omitempty"`
+}
+
+// PublicationTable is a table of a publication, written as "schema.table".
+// Where filters the published rows and Columns limits the published columns;
+// both need PostgreSQL 15 or later.
+type PublicationTable struct {
+	Table   string   `yaml:"table" jsonschema:"required"`
+	Where   string   `yaml:"where,omitempty"`
+	Columns []string `yaml:"columns,omitempty"`
+}
+
+// Subscription subscribes to Publications of the publisher reached through
+// Connection, a libpq connection string that must not hold a password. The
+// replication slot SlotName, the subscription name when empty, is neither
+// created nor dropped. Enabled defaults to true, as in PostgreSQL.
+type Subscription struct {
+	Name         string   `yaml:"name" jsonschema:"required"`
+	Connection   string   `yaml:"connection" jsonschema:"required"`
+	Publications []string `yaml:"publications" jsonschema:"required"`
+	SlotName     string   `yaml:"slot_name,omitempty"`
+	Enabled      *bool    `yaml:"enabled,omitempty"`
+	Comment      *string  `yaml:"comment,omitempty"`
+}
+
+// Namespace is a schema. A nil Grants or DefaultPrivileges list leaves those
+// privileges unmanaged, as does a nil Grants list on its objects.
 type Namespace struct {
-	Name      string      `yaml:"name"`
-	Tables    []*Table    `yaml:"tables"`
-	Sequences []*Sequence `yaml:"sequences"`
+	Name              string              `yaml:"name" jsonschema:"required"`
+	Comment           *string             `yaml:"comment,omitempty"`
+	Grants            []*Grant            `yaml:"grants,omitempty"`
+	Domains           []*Domain           `yaml:"domains,omitempty"`
+	CompositeTypes    []*CompositeType    `yaml:"composite_types,omitempty"`
+	Tables            []*Table            `yaml:"tables"`
+	Sequences         []*Sequence         `yaml:"sequences"`
+	Functions         []*Function         `yaml:"functions,omitempty"`
+	DefaultPrivileges []*DefaultPrivilege `yaml:"default_privileges,omitempty"`
+}
+
+// Domain is a data type based on another one, with an optional default and
+// constraints every value must satisfy. Columns use it by its name, qualified
+// with the namespace unless that is on the search path.
+type Domain struct {
+	Name    string         `yaml:"name" jsonschema:"required"`
+	Type    string         `yaml:"type" jsonschema:"required"`
+	Default string         `yaml:"default,omitempty"`
+	NotNull bool           `yaml:"not_null,omitempty"`
+	Checks  []*DomainCheck `yaml:"checks,omitempty"`
+	Comment *string        `yaml:"comment,omitempty"`
+}
+
+// DomainCheck is a named CHECK constraint of a domain, whose Expression refers
+// to the checked value as VALUE.
+type DomainCheck struct {
+	Name       string `yaml:"name" jsonschema:"required"`
+	Expression string `yaml:"expression" jsonschema:"required"`
 }
 
+// CompositeType is a row type made of named attributes.
+type CompositeType struct {
+	Name       string       `yaml:"name" jsonschema:"required"`
+	Attributes []*Attribute `yaml:"attributes" jsonschema:"required"`
+	Comment    *string      `yaml:"comment,omitempty"`
+}
+
+type Attribute struct {
+	Name string `yaml:"name" jsonschema:"required"`
+	Type string `yaml:"type" jsonschema:"required"`
+}
+
+// Sequence is a standalone sequence. OwnedBy names the "table.column" the
+// sequence belongs to, so that it is dropped together with the column; a table
+// in another namespace is written as "schema.table.column".
 type Sequence struct {
-	Name string `yaml:"name"`
-	Type string `yaml:"type"`
+	Name            string `yaml:"name" jsonschema:"required"`
+	Type            string `yaml:"type"`
+	SequenceOptions `yaml:",inline"`
+	OwnedBy         string   `yaml:"owned_by,omitempty"`
+	Comment         *string  `yaml:"comment,omitempty"`
+	Grants          []*Grant `yaml:"grants,omitempty"`
 }
 
+// Table is a table of a namespace. RowLevelSecurity turns on its Policies;
+// when empty, row level security is disabled. A table with PartitionBy is
+// partitioned into its Partitions, which are not listed as tables themselves.
+// Unlogged, Tablespace and With set how the table is stored; With holds its
+// storage parameters, with those of its TOAST table prefixed by "toast.".
+// Extends lists the templates whose columns the table takes; they are expanded
+// when the desired state is loaded.
 type Table struct {
-	Name        string        `yaml:"name"`
-	Columns     []*Column     `yaml:"columns"`
-	Constraints []*Constraint `yaml:"constraints"`
-	Indices     []*Index      `yaml:"indices"`
+	Name             string            `yaml:"name" jsonschema:"required"`
+	Extends          []string          `yaml:"extends,omitempty"`
+	Comment          *string           `yaml:"comment,omitempty"`
+	Columns          []*Column         `yaml:"columns"`
+	Constraints      []*Constraint     `yaml:"constraints"`
+	Indices          []*Index          `yaml:"indices"`
+	Grants           []*Grant          `yaml:"grants,omitempty"`
+	RowLevelSecurity RowLevelSecurity  `yaml:"row_level_security,omitempty"`
+	Policies         []*Policy         `yaml:"policies,omitempty"`
+	PartitionBy      *PartitionBy      `yaml:"partition_by,omitempty"`
+	Partitions       []*Partition      `yaml:"partitions,omitempty"`
+	Unlogged         bool              `yaml:"unlogged,omitempty"`
+	Tablespace       string            `yaml:"tablespace,omitempty"`
+	With             map[string]string `yaml:"with,omitempty"`
 }
 
+type PartitionStrategy string
+
+var (
+	PartitionStrategyRange PartitionStrategy = "range"
+	PartitionStrategyList  PartitionStrategy = "list"
+	PartitionStrategyHash  PartitionStrategy = "hash"
+)
+
+func GetPartitionStrategyFromCode(code string) PartitionStrategy {
+	switch code {
+	case "l":
+		return PartitionStrategyList
+	case "h":
+		return PartitionStrategyHash
+	default:
+		return PartitionStrategyRange
+	}
+}
+
+// PartitionBy splits the rows of a table over its partitions by the values of
+// the key Columns.
+type PartitionBy struct {
+	Strategy PartitionStrategy `yaml:"strategy" jsonschema:"required"`
+	Columns  []string          `yaml:"columns" jsonschema:"required"`
+}
+
+// Partition is a child table of a partitioned table. Values holds its bounds as
+// written after FOR VALUES, e.g. "FROM ('2024-01-01') TO ('2024-02-01')",
+// "IN ('eu', 'us')" or "WITH (MODULUS 4, REMAINDER 0)". A Default partition
+// holds the rows no other partition accepts.
+type Partition struct {
+	Name    string `yaml:"name" jsonschema:"required"`
+	Values  string `yaml:"values,omitempty"`
+	Default bool   `yaml:"default,omitempty"`
+}
+
+type RowLevelSecurity string
+
+var (
+	RowLevelSecurityDisabled RowLevelSecurity = ""
+	RowLevelSecurityEnabled  RowLevelSecurity = "enabled"
+	RowLevelSecurityForced   RowLevelSecurity = "forced"
+)
+
+type PolicyCommand string
+
+var (
+	PolicyCommandAll    PolicyCommand = "ALL"
+	PolicyCommandSelect PolicyCommand = "SELECT"
+	PolicyCommandInsert PolicyCommand = "INSERT"
+	PolicyCommandUpdate PolicyCommand = "UPDATE"
+	PolicyCommandDelete PolicyCommand = "DELETE"
+)
+
+func GetPolicyCommandFromCode(code string) PolicyCommand {
+	switch code {
+	case "r":
+		return PolicyCommandSelect
+	case "a":
+		return PolicyCommandInsert
+	case "w":
+		return PolicyCommandUpdate
+	case "d":
+		return PolicyCommandDelete
+	default:
+		return PolicyCommandAll
+	}
+}
+
+// Template is a reusable set of columns that tables, and other templates, can
+// extend. It only exists in the desired state file.
+type Template struct {
+	Name    string    `yaml:"name" jsonschema:"required"`
+	Extends []string  `yaml:"extends,omitempty"`
+	Columns []*Column `yaml:"columns"`
+}
+
+// Policy is a row level security policy. An empty Command means ALL and no
+// Roles means PUBLIC. Using filters the rows that are visible, WithCheck the
+// rows that may be written.
+type Policy struct {
+	Name        string        `yaml:"name" jsonschema:"required"`
+	Command     PolicyCommand `yaml:"command,omitempty"`
+	Restrictive bool          `yaml:"restrictive,omitempty"`
+	Roles       []string      `yaml:"roles,omitempty"`
+	Using       string        `yaml:"using,omitempty"`
+	WithCheck   string        `yaml:"with_check,omitempty"`
+}
+
+// Column is a table column. Type holds the bare type name; its modifiers are
+// kept apart: MaxLength for character and bit types, Precision and Scale for
+// numeric and time types, and ArrayDimensions for arrays of the type.
+// Collation is only set when it differs from the default of the type.
 type Column struct {
-	Name         string `yaml:"name"`
-	Type         string `yaml:"type"`
-	MaxLength    int    `yaml:"max_length"`
-	Nullable     bool   `yaml:"nullable"`
-	Default      string `yaml:"default"`
-	IsPrimaryKey bool   `yaml:"primary_key"`
+	Name            string          `yaml:"name" jsonschema:"required"`
+	Type            string          `yaml:"type" jsonschema:"required"`
+	MaxLength       int             `yaml:"max_length"`
+	Precision       *int            `yaml:"precision,omitempty"`
+	Scale           int             `yaml:"scale,omitempty"`
+	ArrayDimensions int             `yaml:"array_dimensions,omitempty"`
+	Nullable        bool            `yaml:"nullable"`
+	Default         string          `yaml:"default"`
+	IsPrimaryKey    bool            `yaml:"primary_key"`
+	Identity        *ColumnIdentity `yaml:"identity,omitempty"`
+	Generated       string          `yaml:"generated,omitempty"`
+	Collation       string          `yaml:"collation,omitempty"`
+	Comment         *string         `yaml:"comment,omitempty"`
+}
+
+// SequenceOptions are the options shared by sequences and identity columns.
+// Unset options fall back to the PostgreSQL defaults for the data type.
+type SequenceOptions struct {
+	Start     *int64 `yaml:"start,omitempty"`
+	Increment int64  `yaml:"increment,omitempty"`
+	MinValue  *int64 `yaml:"min_value,omitempty"`
+	MaxValue  *int64 `yaml:"max_value,omitempty"`
+	Cache     int64  `yaml:"cache,omitempty"`
+	Cycle     bool   `yaml:"cycle,omitempty"`
+}
+
+type IdentityGeneration string
+
+var (
+	IdentityGenerationAlways    IdentityGeneration = "ALWAYS"
+	IdentityGenerationByDefault IdentityGeneration = "BY DEFAULT"
+)
+
+// ColumnIdentity turns a column into a GENERATED ... AS IDENTITY column. An
+// empty Generation means ALWAYS.
+type ColumnIdentity struct {
+	Generation      IdentityGeneration `yaml:"generation,omitempty"`
+	SequenceOptions `yaml:",inline"`
 }
 
 type ConstraintType string
@@ -39,6 +310,7 @@ var (
 	ConstraintTypeUnique     ConstraintType = "UNIQUE"
 	ConstraintTypeForeignKey ConstraintType = "FOREIGN KEY"
 	ConstraintTypeCheck      ConstraintType = "CHECK"
+	ConstraintTypeExclusion  ConstraintType = "EXCLUDE"
 	ConstraintTypeUnknown    ConstraintType = ""
 )
 
@@ -52,14 +324,19 @@ func GetConstraintTypeFromCode(code string) ConstraintType {
 		return ConstraintTypePrimaryKey
 	case "u":
 		return ConstraintTypeUnique
+	case "x":
+		return ConstraintTypeExclusion
 	default:
 		return ConstraintTypeUnknown
 	}
 }
 
+// ConstraintReference is the table a foreign key points to. Schema defaults to
+// the namespace of the constraint's own table.
 type ConstraintReference struct {
-	Table   string
-	Columns []string
+	Schema  string   `yaml:"schema,omitempty"`
+	Table   string   `yaml:"table" jsonschema:"required"`
+	Columns []string `yaml:"columns"`
 }
 
 type ConstraintAction string
@@ -90,24 +367,82 @@ func GetConstraintActionFromCode(code string) ConstraintAction {
 	}
 }
 
+// Constraint is a table constraint. Exclusion constraints list their
+// Exclusions instead of Targets, use the Using index method (gist when empty)
+// and may be partial through Where. NotValid adds a foreign key or check
+// constraint without checking the existing rows.
 type Constraint struct {
-	Name      string               `yaml:"name"`
-	Type      ConstraintType       `yaml:"type"`
-	Targets   []string             `yaml:"targets"`
-	Reference *ConstraintReference `yaml:"reference"`
-	OnDelete  ConstraintAction      `yaml:"on_delete"`
-	OnUpdate  ConstraintAction      `yaml:"on_update"`
+	Name              string               `yaml:"name" jsonschema:"required"`
+	Type              ConstraintType       `yaml:"type" jsonschema:"required"`
+	Targets           []string             `yaml:"targets"`
+	Reference         *ConstraintReference `yaml:"reference,omitempty"`
+	OnDelete          ConstraintAction     `yaml:"on_delete,omitempty"`
+	OnUpdate          ConstraintAction     `yaml:"on_update,omitempty"`
+	MatchFull         bool                 `yaml:"match_full,omitempty"`
+	Using             IndexAlgorithm       `yaml:"using,omitempty"`
+	Exclusions        []*ExclusionElement  `yaml:"exclusions,omitempty"`
+	Where             string               `yaml:"where,omitempty"`
+	Deferrable        bool                 `yaml:"deferrable,omitempty"`
+	InitiallyDeferred bool                 `yaml:"initially_deferred,omitempty"`
+	NotValid          bool                 `yaml:"not_valid,omitempty"`
+	Comment           *string              `yaml:"comment,omitempty"`
+}
+
+// ExclusionElement is an element of an exclusion constraint: an index key and
+// the operator rows must not all satisfy, e.g. "&&" for overlapping ranges.
+type ExclusionElement struct {
+	IndexKey `yaml:",inline"`
+	Operator string `yaml:"operator" jsonschema:"required"`
 }
 
 type IndexAlgorithm string
 
 var (
-	IndexAlgorithmBTree IndexAlgorithm = "btree"
+	IndexAlgorithmBTree  IndexAlgorithm = "btree"
+	IndexAlgorithmHash   IndexAlgorithm = "hash"
+	IndexAlgorithmGiST   IndexAlgorithm = "gist"
+	IndexAlgorithmSPGiST IndexAlgorithm = "spgist"
+	IndexAlgorithmGIN    IndexAlgorithm = "gin"
+	IndexAlgorithmBRIN   IndexAlgorithm = "brin"
 )
 
+// Index is a table index. Plain indexes list their Columns; indexes whose keys
+// need an expression, opclass or sort order list Keys instead. Include holds
+// the non-key columns of a covering index, Where the predicate of a partial
+// index and With its storage parameters.
 type Index struct {
-	Name      string         `yaml:"name"`
-	Unique    bool           `yaml:"unique"`
-	Algorithm IndexAlgorithm `yaml:"algorithm"`
-	Columns   []string       `yaml:"columns"`
+	Name      string            `yaml:"name" jsonschema:"required"`
+	Unique    bool              `yaml:"unique"`
+	Algorithm IndexAlgorithm    `yaml:"algorithm,omitempty"`
+	Columns   []string          `yaml:"columns,omitempty"`
+	Keys      []*IndexKey       `yaml:"keys,omitempty"`
+	Include   []string          `yaml:"include,omitempty"`
+	Where     string            `yaml:"where,omitempty"`
+	With      map[string]string `yaml:"with,omitempty"`
+	Comment   *string           `yaml:"comment,omitempty"`
+}
+
+type IndexOrder string
+
+var (
+	IndexOrderAsc  IndexOrder = "ASC"
+	IndexOrderDesc IndexOrder = "DESC"
+)
+
+type IndexNulls string
+
+var (
+	IndexNullsFirst IndexNulls = "FIRST"
+	IndexNullsLast  IndexNulls = "LAST"
+)
+
+// IndexKey is a single index key: either a column or an expression, with an
+// optional opclass and sort order. An empty Nulls uses the PostgreSQL default
+// for the order, NULLS LAST for ASC and NULLS FIRST for DESC.
+type IndexKey struct {
+	Column     string     `yaml:"column,omitempty"`
+	Expression string     `yaml:"expression,omitempty"`
+	Opclass    string     `yaml:"opclass,omitempty"`
+	Order      IndexOrder `yaml:"order,omitempty"`
+	Nulls      IndexNulls `yaml:"nulls,omitempty"`
 }
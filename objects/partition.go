@@ -0,0 +1,64 @@
+package objects
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PartitionStrategies returns the supported partitioning strategies.
+func PartitionStrategies() []PartitionStrategy {
+	return []PartitionStrategy{PartitionStrategyRange, PartitionStrategyList, PartitionStrategyHash}
+}
+
+// GetStrategy returns the partitioning strategy, lower-cased.
+func (p *PartitionBy) GetStrategy() PartitionStrategy {
+	return PartitionStrategy(strings.ToLower(string(p.Strategy)))
+}
+
+// boundsKeyword returns the keyword the bounds of a partition start with for
+// the strategy.
+func (p *PartitionBy) boundsKeyword() string {
+	switch p.GetStrategy() {
+	case PartitionStrategyList:
+		return "IN"
+	case PartitionStrategyHash:
+		return "WITH"
+	default:
+		return "FROM"
+	}
+}
+
+// SQL returns the PARTITION BY clause of the table.
+func (p *PartitionBy) SQL() string {
+	return fmt.Sprintf("PARTITION BY %s (%s)", strings.ToUpper(string(p.GetStrategy())), QuoteIdents(p.Columns))
+}
+
+func (p *PartitionBy) Equal(other *PartitionBy) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.GetStrategy() == other.GetStrategy() && strings.Join(p.Columns, ",") == strings.Join(other.Columns, ",")
+}
+
+// BoundSQL returns the bounds of the partition as used by CREATE TABLE ...
+// PARTITION OF and ATTACH PARTITION.
+func (p *Partition) BoundSQL() string {
+	if p.Default {
+		return "DEFAULT"
+	}
+	return "FOR VALUES " + p.Values
+}
+
+func (p *Partition) Equal(other *Partition) bool {
+	return p.Default == other.Default && ExpressionsEqual(p.Values, other.Values)
+}
+
+// GetPartition returns the partition of the table with the given name, or nil.
+func (t *Table) GetPartition(name string) *Partition {
+	for _, p := range t.Partitions {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package objects
+
+import (
+	"sort"
+	"strings"
+)
+
+// PolicyCommands returns the commands a policy can apply to.
+func PolicyCommands() []PolicyCommand {
+	return []PolicyCommand{PolicyCommandAll, PolicyCommandSelect, PolicyCommandInsert, PolicyCommandUpdate, PolicyCommandDelete}
+}
+
+// GetRowLevelSecurity returns the row level security mode of the table,
+// lower-cased, with "disabled" read as the empty default.
+func (t *Table) GetRowLevelSecurity() RowLevelSecurity {
+	mode := RowLevelSecurity(strings.ToLower(string(t.RowLevelSecurity)))
+	if mode == "disabled" {
+		return RowLevelSecurityDisabled
+	}
+	return mode
+}
+
+// GetCommand returns the command of the policy, ALL when unset.
+func (p *Policy) GetCommand() PolicyCommand {
+	if p.Command == "" {
+		return PolicyCommandAll
+	}
+	return PolicyCommand(strings.ToUpper(string(p.Command)))
+}
+
+// GetRoles returns the roles the policy applies to, sorted, with PUBLIC when
+// none are listed.
+func (p *Policy) GetRoles() []string {
+	roles := []string{}
+	for _, role := range p.Roles {
+		if strings.EqualFold(role, "PUBLIC") {
+			role = "PUBLIC"
+		}
+		roles = append(roles, role)
+	}
+	if len(roles) == 0 {
+		return []string{"PUBLIC"}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func (p *Policy) rolesSQL() string {
+	roles := []string{}
+	for _, role := range p.GetRoles() {
+		if role != "PUBLIC" {
+			role = QuoteIdent(role)
+		}
+		roles = append(roles, role)
+	}
+	return strings.Join(roles, ", ")
+}
@@ -0,0 +1,110 @@
+package objects
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// privilegesByObject lists the privileges that can be granted on each kind of
+// object, in the order PostgreSQL prints them. The plural kinds are the ones
+// default privileges apply to.
+var privilegesByObject = map[string][]string{
+	"SCHEMA":    {"USAGE", "CREATE"},
+	"TABLE":     {"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"},
+	"SEQUENCE":  {"USAGE", "SELECT", "UPDATE"},
+	"FUNCTION":  {"EXECUTE"},
+	"TABLES":    {"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"},
+	"SEQUENCES": {"USAGE", "SELECT", "UPDATE"},
+	"FUNCTIONS": {"EXECUTE"},
+	"TYPES":     {"USAGE"},
+}
+
+// DefaultPrivilegeObjects returns the kinds of objects default privileges can
+// be set for.
+func DefaultPrivilegeObjects() []string {
+	return []string{"TABLES", "SEQUENCES", "FUNCTIONS", "TYPES"}
+}
+
+// ExpandPrivileges returns the privileges for an object of the given kind
+// upper-cased, deduplicated and in canonical order, with ALL expanded.
+// Privileges the object does not support are dropped.
+func ExpandPrivileges(objectType string, privileges []string) []string {
+	wanted := map[string]bool{}
+	for _, privilege := range privileges {
+		privilege = strings.ToUpper(strings.TrimSpace(privilege))
+		if privilege == "ALL" || privilege == "ALL PRIVILEGES" {
+			for _, p := range privilegesByObject[objectType] {
+				wanted[p] = true
+			}
+			continue
+		}
+		wanted[privilege] = true
+	}
+
+	expanded := []string{}
+	for _, p := range privilegesByObject[objectType] {
+		if wanted[p] {
+			expanded = append(expanded, p)
+		}
+	}
+	return expanded
+}
+
+// GrantedPrivileges merges grants into the expanded privileges of each role.
+// Role names are kept as written, except PUBLIC which is upper-cased.
+func GrantedPrivileges(objectType string, grants []*Grant) map[string][]string {
+	merged := map[string][]string{}
+	for _, g := range grants {
+		role := g.GetRole()
+		merged[role] = ExpandPrivileges(objectType, append(merged[role], g.Privileges...))
+	}
+	for role, privileges := range merged {
+		if len(privileges) == 0 {
+			delete(merged, role)
+		}
+	}
+	return merged
+}
+
+// GrantRoles returns the roles of a GrantedPrivileges result, sorted.
+func GrantRoles(granted ...map[string][]string) []string {
+	seen := map[string]bool{}
+	roles := []string{}
+	for _, g := range granted {
+		for role := range g {
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// GetRole returns the grantee, with PUBLIC upper-cased.
+func (g *Grant) GetRole() string {
+	if strings.EqualFold(g.Role, "PUBLIC") {
+		return "PUBLIC"
+	}
+	return g.Role
+}
+
+// GetInherit reports whether the role inherits the privileges of the roles it
+// is a member of.
+func (r *Role) GetInherit() bool {
+	return r.Inherit == nil || *r.Inherit
+}
+
+// GetOn returns the kind of objects the default privileges apply to,
+// upper-cased.
+func (d *DefaultPrivilege) GetOn() string {
+	return strings.ToUpper(d.On)
+}
+
+// Signature returns the function as it is referred to in GRANT statements,
+// qualified with the namespace.
+func (f *Function) Signature(namespace string) string {
+	return fmt.Sprintf("%s(%s)", QualifiedName(namespace, f.Name), f.Arguments)
+}
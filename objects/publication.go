@@ -0,0 +1,104 @@
+package objects
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PublicationOperations returns the operations a publication can publish, in
+// the order PostgreSQL lists them.
+func PublicationOperations() []string {
+	return []string{"insert", "update", "delete", "truncate"}
+}
+
+// GetPublish returns the published operations lower-cased and in canonical
+// order, every operation when none are listed.
+func (p *Publication) GetPublish() []string {
+	if len(p.Publish) == 0 {
+		return PublicationOperations()
+	}
+	publish := []string{}
+	for _, op := range PublicationOperations() {
+		for _, wanted := range p.Publish {
+			if strings.EqualFold(strings.TrimSpace(wanted), op) {
+				publish = append(publish, op)
+				break
+			}
+		}
+	}
+	return publish
+}
+
+// PublishSQL renders the published operations as the value of the publish
+// parameter, e.g. 'insert, update'.
+func (p *Publication) PublishSQL() string {
+	return QuoteLiteral(strings.Join(p.GetPublish(), ", "))
+}
+
+// SQL renders the CREATE PUBLICATION statement. The publish parameter is
+// only written when it differs from the default.
+func (p *Publication) SQL() string {
+	statement := "CREATE PUBLICATION " + QuoteIdent(p.Name)
+	if p.AllTables {
+		statement += " FOR ALL TABLES"
+	} else if len(p.Tables) > 0 {
+		tables := []string{}
+		for _, t := range p.Tables {
+			tables = append(tables, t.SQL())
+		}
+		statement += " FOR TABLE " + strings.Join(tables, ", ")
+	}
+	if len(p.GetPublish()) != len(PublicationOperations()) {
+		statement += fmt.Sprintf(" WITH (publish = %s)", p.PublishSQL())
+	}
+	return statement + ";"
+}
+
+// GetTable returns the table of the publication with the given qualified
+// name, or nil.
+func (p *Publication) GetTable(name string) *PublicationTable {
+	for _, t := range p.Tables {
+		if t.Table == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// TableSQL renders the qualified name of the table, as listed after DROP
+// TABLE.
+func (t *PublicationTable) TableSQL() string {
+	return QualifiedName(t.splitTable())
+}
+
+// SQL renders the table as listed after FOR TABLE or ADD TABLE, with its
+// column list and row filter.
+func (t *PublicationTable) SQL() string {
+	table := t.TableSQL()
+	if len(t.Columns) > 0 {
+		table += fmt.Sprintf(" (%s)", QuoteIdents(t.Columns))
+	}
+	if t.Where != "" {
+		table += fmt.Sprintf(" WHERE (%s)", t.Where)
+	}
+	return table
+}
+
+// Equal reports whether both tables publish the same rows and columns. The
+// order of the column list does not matter.
+func (t *PublicationTable) Equal(other *PublicationTable) bool {
+	if t.Table != other.Table || !ExpressionsEqual(t.Where, other.Where) {
+		return false
+	}
+	columns, otherColumns := append([]string{}, t.Columns...), append([]string{}, other.Columns...)
+	sort.Strings(columns)
+	sort.Strings(otherColumns)
+	return strings.Join(columns, ",") == strings.Join(otherColumns, ",")
+}
+
+// splitTable returns the namespace and name of the table.
+func (t *PublicationTable) splitTable() (string, string) {
+	namespace, name, _ := strings.Cut(t.Table, ".")
+	return namespace, name
+}
@@ -5,41 +5,142 @@ import (
 	"strings"
 )
 
+func (e *Extension) String() string {
+	if e.Version != "" {
+		return fmt.Sprintf("%s (%s)", e.Name, e.Version)
+	}
+	return e.Name
+}
+
+func (e *Extension) SQL() string {
+	base := fmt.Sprintf("CREATE EXTENSION %s", QuoteIdent(e.Name))
+	if e.Schema != "" {
+		base += fmt.Sprintf(" SCHEMA %s", QuoteIdent(e.Schema))
+	}
+	if e.Version != "" {
+		base += fmt.Sprintf(" VERSION '%s'", e.Version)
+	}
+	return base + ";"
+}
+
+func (r *Role) String() string {
+	return r.Name
+}
+
+// SQL returns the CREATE ROLE statement for the role, without its
+// memberships.
+func (r *Role) SQL() string {
+	base := fmt.Sprintf("CREATE ROLE %s", QuoteIdent(r.Name))
+	if r.Login {
+		base += " LOGIN"
+	}
+	if !r.GetInherit() {
+		base += " NOINHERIT"
+	}
+	return base + ";"
+}
+
 func (t *Table) String() string {
 	return t.Name
 }
 
 func (s *Sequence) String() string {
-	return fmt.Sprintf("%s (%s)", s.Name, s.Type)
+	base := fmt.Sprintf("%s (%s)", s.Name, s.Type)
+	if clauses := s.Clauses(); len(clauses) > 0 {
+		base += fmt.Sprintf(" %s", strings.Join(clauses, " "))
+	}
+	if s.OwnedBy != "" {
+		base += fmt.Sprintf(" OWNED BY %s", s.OwnedBy)
+	}
+	return base
 }
 
 func (c *Column) String() string {
-	nullable := "NULL"
-	defaulted := ""
+	parts := []string{QuoteIdent(c.Name), c.TypeSQL() + c.CollationSQL()}
 
-	if !c.Nullable {
-		nullable = "NOT " + nullable
+	if c.Nullable {
+		parts = append(parts, "NULL")
+	} else {
+		parts = append(parts, "NOT NULL")
 	}
 	if c.Default != "" {
-		defaulted = fmt.Sprintf("DEFAULT %s", c.Default)
+		parts = append(parts, fmt.Sprintf("DEFAULT %s", c.Default))
+	}
+	if c.Identity != nil {
+		parts = append(parts, c.Identity.SQL())
+	}
+	if c.Generated != "" {
+		parts = append(parts, fmt.Sprintf("GENERATED ALWAYS AS (%s) STORED", c.Generated))
+	}
+	return strings.Join(parts, " ")
+}
+
+// TypeSQL renders the canonical column type including its modifiers and
+// array dimensions, e.g. NUMERIC(12,2) or TIMESTAMP(3) WITH TIME ZONE[].
+func (c *Column) TypeSQL() string {
+	parsed, err := c.ColumnType()
+	if err != nil {
+		parsed = &ColumnType{Name: c.Type, MaxLength: c.MaxLength, Precision: c.Precision, Scale: c.Scale, ArrayDimensions: c.ArrayDimensions}
+	}
+	return parsed.SQL()
+}
+
+func (t *ColumnType) SQL() string {
+	modifier := ""
+	if t.Modifier != "" {
+		modifier = "(" + t.Modifier + ")"
+	} else if t.MaxLength > 0 {
+		modifier = fmt.Sprintf("(%d)", t.MaxLength)
+	} else if t.Precision != nil && t.Scale != 0 {
+		modifier = fmt.Sprintf("(%d,%d)", *t.Precision, t.Scale)
+	} else if t.Precision != nil {
+		modifier = fmt.Sprintf("(%d)", *t.Precision)
+	}
+
+	typ := t.Name + modifier
+	for _, suffix := range []string{" WITH TIME ZONE", " WITHOUT TIME ZONE"} {
+		if modifier != "" && strings.HasSuffix(strings.ToUpper(t.Name), suffix) {
+			base := t.Name[:len(t.Name)-len(suffix)]
+			typ = base + modifier + t.Name[len(base):]
+		}
+	}
+
+	return typ + strings.Repeat("[]", t.ArrayDimensions)
+}
+
+// GetGeneration returns the identity generation, defaulting to ALWAYS.
+func (i *ColumnIdentity) GetGeneration() IdentityGeneration {
+	if i.Generation == "" {
+		return IdentityGenerationAlways
 	}
-	if c.MaxLength > 0 {
-		return fmt.Sprintf("%s %s(%d) %s %s", c.Name, c.Type, c.MaxLength, nullable, defaulted)
+	return i.Generation
+}
+
+func (i *ColumnIdentity) SQL() string {
+	base := fmt.Sprintf("GENERATED %s AS IDENTITY", i.GetGeneration())
+	if clauses := i.Clauses(); len(clauses) > 0 {
+		base += fmt.Sprintf(" (%s)", strings.Join(clauses, " "))
 	}
-	return fmt.Sprintf("%s %s %s %s", c.Name, c.Type, nullable, defaulted)
+	return base
 }
 
 func (c *Constraint) String() string {
 	if c.Type == ConstraintTypeForeignKey {
-		return fmt.Sprintf("%s %s (%s) REFERENCES %s (%s) ON DELETE %s ON UPDATE %s", c.Name, c.Type, strings.Join(c.Targets, ", "), c.Reference.Table, strings.Join(c.Reference.Columns, ", "), c.OnDelete, c.OnUpdate)
+		return fmt.Sprintf("%s %s (%s) REFERENCES %s (%s) ON DELETE %s ON UPDATE %s", c.Name, c.Type, strings.Join(c.Targets, ", "), c.Reference.QualifiedTable(), strings.Join(c.Reference.Columns, ", "), c.OnDelete, c.OnUpdate)
+	}
+	if c.Type == ConstraintTypeExclusion {
+		return fmt.Sprintf("%s %s", c.Name, c.definitionSQL())
 	}
 	return fmt.Sprintf("%s %s (%s)", c.Name, c.Type, strings.Join(c.Targets, ", "))
 }
 
 func (c *Constraint) SQL() string {
-	base := fmt.Sprintf("CONSTRAINT %s %s (%s)", c.Name, c.Type, strings.Join(c.Targets, ", "))
+	base := fmt.Sprintf("CONSTRAINT %s %s", QuoteIdent(c.Name), c.definitionSQL())
 	if c.Type == ConstraintTypeForeignKey && c.Reference != nil {
-		base += fmt.Sprintf(" REFERENCES %s (%s)", c.Reference.Table, strings.Join(c.Reference.Columns, ", "))
+		base += fmt.Sprintf(" REFERENCES %s (%s)", c.Reference.QualifiedTable(), QuoteIdents(c.Reference.Columns))
+		if c.MatchFull {
+			base += " MATCH FULL"
+		}
 		if c.OnDelete != "" && c.OnDelete != ConstraintActionUnknown {
 			base += fmt.Sprintf(" ON DELETE %s", c.OnDelete)
 		}
@@ -47,13 +148,71 @@ func (c *Constraint) SQL() string {
 			base += fmt.Sprintf(" ON UPDATE %s", c.OnUpdate)
 		}
 	}
+	if c.Deferrable {
+		base += " " + c.DeferralSQL()
+	}
+	if c.NotValid {
+		base += " NOT VALID"
+	}
 	return base
 }
 
+func (c *Constraint) definitionSQL() string {
+	if c.Type == ConstraintTypeCheck {
+		return fmt.Sprintf("%s (%s)", c.Type, strings.Join(c.Targets, ", "))
+	}
+	if c.Type != ConstraintTypeExclusion {
+		return fmt.Sprintf("%s (%s)", c.Type, QuoteIdents(c.Targets))
+	}
+
+	elements := []string{}
+	for _, e := range c.Exclusions {
+		elements = append(elements, fmt.Sprintf("%s WITH %s", e.SQL(), e.Operator))
+	}
+	definition := fmt.Sprintf("%s USING %s (%s)", c.Type, c.GetUsing(), strings.Join(elements, ", "))
+	if c.Where != "" {
+		definition += fmt.Sprintf(" WHERE (%s)", c.Where)
+	}
+	return definition
+}
+
+// QualifiedTable returns the referenced table as written in SQL, prefixed
+// with its schema when one is set.
+func (r *ConstraintReference) QualifiedTable() string {
+	if r.Schema == "" {
+		return QuoteIdent(r.Table)
+	}
+	return QualifiedName(r.Schema, r.Table)
+}
+
+// DeferralSQL renders when the constraint is checked, e.g. "DEFERRABLE
+// INITIALLY DEFERRED", as accepted by ALTER CONSTRAINT.
+func (c *Constraint) DeferralSQL() string {
+	if !c.Deferrable {
+		return "NOT DEFERRABLE"
+	}
+	if c.InitiallyDeferred {
+		return "DEFERRABLE INITIALLY DEFERRED"
+	}
+	return "DEFERRABLE INITIALLY IMMEDIATE"
+}
+
+// GetUsing returns the index method of an exclusion constraint, gist when none
+// is set.
+func (c *Constraint) GetUsing() IndexAlgorithm {
+	if c.Using == "" {
+		return IndexAlgorithmGiST
+	}
+	return IndexAlgorithm(strings.ToLower(string(c.Using)))
+}
+
 func (c *Constraint) Equal(other *Constraint) bool {
 	if c.Type != other.Type {
 		return false
 	}
+	if c.Deferrable != other.Deferrable || c.InitiallyDeferred != other.InitiallyDeferred {
+		return false
+	}
 	if len(c.Targets) != len(other.Targets) {
 		return false
 	}
@@ -69,6 +228,9 @@ func (c *Constraint) Equal(other *Constraint) bool {
 		if c.Reference.Table != other.Reference.Table {
 			return false
 		}
+		if c.Reference.Schema != "" && other.Reference.Schema != "" && c.Reference.Schema != other.Reference.Schema {
+			return false
+		}
 		if len(c.Reference.Columns) != len(other.Reference.Columns) {
 			return false
 		}
@@ -80,32 +242,176 @@ func (c *Constraint) Equal(other *Constraint) bool {
 		if c.OnDelete != other.OnDelete || c.OnUpdate != other.OnUpdate {
 			return false
 		}
+		if c.MatchFull != other.MatchFull {
+			return false
+		}
+	}
+	if c.Type == ConstraintTypeExclusion {
+		if c.GetUsing() != other.GetUsing() || !ExpressionsEqual(c.Where, other.Where) {
+			return false
+		}
+		if len(c.Exclusions) != len(other.Exclusions) {
+			return false
+		}
+		for i, e := range c.Exclusions {
+			if !e.IndexKey.Equal(&other.Exclusions[i].IndexKey) || e.Operator != other.Exclusions[i].Operator {
+				return false
+			}
+		}
 	}
 	return true
 }
 
 func (i *Index) String() string {
-	onCols := strings.Join(i.Columns, ", ")
+	keys := []string{}
+	for _, key := range i.GetKeys() {
+		keys = append(keys, key.SQL())
+	}
+	onCols := strings.Join(keys, ", ")
+	if len(i.Include) > 0 {
+		onCols += fmt.Sprintf(" INCLUDE (%s)", strings.Join(i.Include, ", "))
+	}
+	if i.Where != "" {
+		onCols += fmt.Sprintf(" WHERE %s", i.Where)
+	}
 	if i.Unique {
 		return fmt.Sprintf("%s (UNIQUE) ON %s", i.Name, onCols)
 	}
 	return fmt.Sprintf("%s ON %s", i.Name, onCols)
 }
 
+// DefinitionSQL renders everything of CREATE INDEX that follows the table
+// name: the method, keys, INCLUDE columns, storage parameters and predicate.
+func (i *Index) DefinitionSQL() string {
+	keys := []string{}
+	for _, key := range i.GetKeys() {
+		keys = append(keys, key.SQL())
+	}
+
+	definition := fmt.Sprintf("USING %s (%s)", i.GetAlgorithm(), strings.Join(keys, ", "))
+	if len(i.Include) > 0 {
+		definition += fmt.Sprintf(" INCLUDE (%s)", QuoteIdents(i.Include))
+	}
+	if len(i.With) > 0 {
+		definition += fmt.Sprintf(" WITH (%s)", strings.Join(i.storageParameters(), ", "))
+	}
+	if i.Where != "" {
+		definition += fmt.Sprintf(" WHERE %s", i.Where)
+	}
+	return definition
+}
+
 func (i *Index) Equal(other *Index) bool {
 	if i.Unique != other.Unique {
 		return false
 	}
-	if i.Algorithm != other.Algorithm {
+	if i.GetAlgorithm() != other.GetAlgorithm() {
+		return false
+	}
+	keys, otherKeys := i.GetKeys(), other.GetKeys()
+	if len(keys) != len(otherKeys) {
 		return false
 	}
-	if len(i.Columns) != len(other.Columns) {
+	for idx, key := range keys {
+		if !key.Equal(otherKeys[idx]) {
+			return false
+		}
+	}
+	if len(i.Include) != len(other.Include) {
 		return false
 	}
-	for idx, col := range i.Columns {
-		if col != other.Columns[idx] {
+	for idx, col := range i.Include {
+		if col != other.Include[idx] {
+			return false
+		}
+	}
+	if !ExpressionsEqual(i.Where, other.Where) {
+		return false
+	}
+	params, otherParams := i.storageParameters(), other.storageParameters()
+	if len(params) != len(otherParams) {
+		return false
+	}
+	for idx, param := range params {
+		if param != otherParams[idx] {
 			return false
 		}
 	}
 	return true
 }
+
+// SQL renders the key as it appears in the column list of CREATE INDEX.
+// Expressions are always parenthesized, which PostgreSQL accepts for any
+// expression.
+func (k *IndexKey) SQL() string {
+	parts := []string{QuoteIdent(k.Column)}
+	if k.Expression != "" {
+		parts[0] = fmt.Sprintf("(%s)", k.Expression)
+	}
+	if k.Opclass != "" {
+		parts = append(parts, k.Opclass)
+	}
+	order := k.GetOrder()
+	if order != IndexOrderAsc {
+		parts = append(parts, string(order))
+	}
+	if k.Nulls != "" && k.GetNulls() != (&IndexKey{Order: order}).GetNulls() {
+		parts = append(parts, "NULLS "+string(k.GetNulls()))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (k *IndexKey) Equal(other *IndexKey) bool {
+	if k.Column != other.Column {
+		return false
+	}
+	if !ExpressionsEqual(k.Expression, other.Expression) {
+		return false
+	}
+	if !strings.EqualFold(k.Opclass, other.Opclass) {
+		return false
+	}
+	return k.GetOrder() == other.GetOrder() && k.GetNulls() == other.GetNulls()
+}
+
+func (p *Policy) String() string {
+	return p.Name
+}
+
+// SQL returns the CREATE POLICY statement for the policy on table, given by
+// its qualified name.
+func (p *Policy) SQL(table string) string {
+	base := fmt.Sprintf("CREATE POLICY %s ON %s", QuoteIdent(p.Name), table)
+	if p.Restrictive {
+		base += " AS RESTRICTIVE"
+	}
+	base += fmt.Sprintf(" FOR %s TO %s", p.GetCommand(), p.rolesSQL())
+	return base + p.expressionsSQL() + ";"
+}
+
+// AlterSQL returns the ALTER POLICY statement setting the roles and
+// expressions of the policy on table, given by its qualified name.
+func (p *Policy) AlterSQL(table string) string {
+	return fmt.Sprintf("ALTER POLICY %s ON %s TO %s%s;", QuoteIdent(p.Name), table, p.rolesSQL(), p.expressionsSQL())
+}
+
+func (p *Policy) expressionsSQL() string {
+	base := ""
+	if p.Using != "" {
+		base += fmt.Sprintf(" USING (%s)", p.Using)
+	}
+	if p.WithCheck != "" {
+		base += fmt.Sprintf(" WITH CHECK (%s)", p.WithCheck)
+	}
+	return base
+}
+
+func (p *Policy) Equal(other *Policy) bool {
+	if p.GetCommand() != other.GetCommand() || p.Restrictive != other.Restrictive {
+		return false
+	}
+	if strings.Join(p.GetRoles(), ",") != strings.Join(other.GetRoles(), ",") {
+		return false
+	}
+	return ExpressionsEqual(p.Using, other.Using) && ExpressionsEqual(p.WithCheck, other.WithCheck)
+}
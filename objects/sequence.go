@@ -0,0 +1,189 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+func sequenceTypeBounds(dataType string) (int64, int64) {
+	switch strings.ToLower(dataType) {
+	case "smallint", "int2":
+		return math.MinInt16, math.MaxInt16
+	case "integer", "int", "int4":
+		return math.MinInt32, math.MaxInt32
+	default:
+		return math.MinInt64, math.MaxInt64
+	}
+}
+
+// Effective returns the options with every unset value replaced by the
+// default PostgreSQL would pick for a sequence of the given data type.
+func (o SequenceOptions) Effective(dataType string) SequenceOptions {
+	typeMin, typeMax := sequenceTypeBounds(dataType)
+
+	effective := SequenceOptions{Increment: o.Increment, Cache: o.Cache, Cycle: o.Cycle}
+	if effective.Increment == 0 {
+		effective.Increment = 1
+	}
+	if effective.Cache == 0 {
+		effective.Cache = 1
+	}
+
+	minValue, maxValue := int64(1), typeMax
+	if effective.Increment < 0 {
+		minValue, maxValue = typeMin, -1
+	}
+	if o.MinValue != nil {
+		minValue = *o.MinValue
+	}
+	if o.MaxValue != nil {
+		maxValue = *o.MaxValue
+	}
+
+	start := minValue
+	if effective.Increment < 0 {
+		start = maxValue
+	}
+	if o.Start != nil {
+		start = *o.Start
+	}
+
+	effective.Start, effective.MinValue, effective.MaxValue = &start, &minValue, &maxValue
+	return effective
+}
+
+// Simplify returns the options with every value that matches the PostgreSQL
+// default for the data type left unset, so introspected options stay terse.
+func (o SequenceOptions) Simplify(dataType string) SequenceOptions {
+	effective := o.Effective(dataType)
+	defaults := SequenceOptions{Increment: effective.Increment}.Effective(dataType)
+
+	simple := SequenceOptions{Cycle: effective.Cycle}
+	if effective.Increment != 1 {
+		simple.Increment = effective.Increment
+	}
+	if effective.Cache != 1 {
+		simple.Cache = effective.Cache
+	}
+	if *effective.MinValue != *defaults.MinValue {
+		simple.MinValue = effective.MinValue
+	}
+	if *effective.MaxValue != *defaults.MaxValue {
+		simple.MaxValue = effective.MaxValue
+	}
+
+	defaultStart := *effective.MinValue
+	if effective.Increment < 0 {
+		defaultStart = *effective.MaxValue
+	}
+	if *effective.Start != defaultStart {
+		simple.Start = effective.Start
+	}
+	return simple
+}
+
+// IsZero reports whether no option is set.
+func (o SequenceOptions) IsZero() bool {
+	return o.Start == nil && o.Increment == 0 && o.MinValue == nil && o.MaxValue == nil && o.Cache == 0 && !o.Cycle
+}
+
+// Clauses renders the set options as sequence option clauses, e.g.
+// "INCREMENT BY 10".
+func (o SequenceOptions) Clauses() []string {
+	clauses := []string{}
+	if o.Start != nil {
+		clauses = append(clauses, fmt.Sprintf("START WITH %d", *o.Start))
+	}
+	if o.Increment != 0 {
+		clauses = append(clauses, fmt.Sprintf("INCREMENT BY %d", o.Increment))
+	}
+	if o.MinValue != nil {
+		clauses = append(clauses, fmt.Sprintf("MINVALUE %d", *o.MinValue))
+	}
+	if o.MaxValue != nil {
+		clauses = append(clauses, fmt.Sprintf("MAXVALUE %d", *o.MaxValue))
+	}
+	if o.Cache != 0 {
+		clauses = append(clauses, fmt.Sprintf("CACHE %d", o.Cache))
+	}
+	if o.Cycle {
+		clauses = append(clauses, "CYCLE")
+	}
+	return clauses
+}
+
+// ChangedClauses returns the clauses needed to turn the existing options into
+// these ones for a sequence of the given data type.
+func (o SequenceOptions) ChangedClauses(existing SequenceOptions, dataType string) []string {
+	want, have := o.Effective(dataType), existing.Effective(dataType)
+
+	clauses := []string{}
+	if *want.Start != *have.Start {
+		clauses = append(clauses, fmt.Sprintf("START WITH %d", *want.Start))
+	}
+	if want.Increment != have.Increment {
+		clauses = append(clauses, fmt.Sprintf("INCREMENT BY %d", want.Increment))
+	}
+	if *want.MinValue != *have.MinValue {
+		clauses = append(clauses, fmt.Sprintf("MINVALUE %d", *want.MinValue))
+	}
+	if *want.MaxValue != *have.MaxValue {
+		clauses = append(clauses, fmt.Sprintf("MAXVALUE %d", *want.MaxValue))
+	}
+	if want.Cache != have.Cache {
+		clauses = append(clauses, fmt.Sprintf("CACHE %d", want.Cache))
+	}
+	if want.Cycle != have.Cycle {
+		if want.Cycle {
+			clauses = append(clauses, "CYCLE")
+		} else {
+			clauses = append(clauses, "NO CYCLE")
+		}
+	}
+	return clauses
+}
+
+// OwnedBySQL renders the owner of the sequence for OWNED BY, qualifying the
+// table with the given namespace unless it already names one.
+func (s *Sequence) OwnedBySQL(namespace string) string {
+	if s.OwnedBy == "" {
+		return "NONE"
+	}
+	parts := strings.Split(s.OwnedBy, ".")
+	if len(parts) == 2 {
+		parts = append([]string{namespace}, parts...)
+	}
+	for i, part := range parts {
+		parts[i] = QuoteIdent(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Owner returns the namespace, table and column owning the sequence, the
+// table qualified with the given namespace unless it already names one. They
+// are empty when the sequence is not owned by a column.
+func (s *Sequence) Owner(namespace string) (string, string, string) {
+	parts := strings.Split(s.OwnedBy, ".")
+	switch len(parts) {
+	case 2:
+		return namespace, parts[0], parts[1]
+	case 3:
+		return parts[0], parts[1], parts[2]
+	}
+	return "", "", ""
+}
+
+// CreateSQL renders the CREATE SEQUENCE statement for the sequence in the
+// given namespace. Ownership is left out, as the owning column may not exist
+// yet when the sequence is created.
+func (s *Sequence) CreateSQL(namespace string) string {
+	statement := "CREATE SEQUENCE " + QualifiedName(namespace, s.Name)
+	if s.Type != "" {
+		statement += " AS " + s.Type
+	}
+	if clauses := s.Clauses(); len(clauses) > 0 {
+		statement += " " + strings.Join(clauses, " ")
+	}
+	return statement + ";"
+}
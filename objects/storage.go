@@ -0,0 +1,72 @@
+package objects
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// storageParameters renders storage parameters as sorted name=value pairs,
+// with the names lower-cased.
+func storageParameters(with map[string]string) []string {
+	params := make([]string, 0, len(with))
+	for name, value := range with {
+		params = append(params, fmt.Sprintf("%s=%s", strings.ToLower(name), value))
+	}
+	sort.Strings(params)
+	return params
+}
+
+// GetWith returns the storage parameters of the table with the names
+// lower-cased.
+func (t *Table) GetWith() map[string]string {
+	with := map[string]string{}
+	for name, value := range t.With {
+		with[strings.ToLower(name)] = value
+	}
+	return with
+}
+
+// StorageSQL renders the WITH and TABLESPACE clauses of CREATE TABLE, with a
+// leading space, or nothing when the table uses the defaults.
+func (t *Table) StorageSQL() string {
+	clauses := ""
+	if len(t.With) > 0 {
+		clauses += fmt.Sprintf(" WITH (%s)", strings.Join(storageParameters(t.With), ", "))
+	}
+	if t.Tablespace != "" {
+		clauses += " TABLESPACE " + QuoteIdent(t.Tablespace)
+	}
+	return clauses
+}
+
+// GetTablespace returns the tablespace of the table, pg_default when unset.
+func (t *Table) GetTablespace() string {
+	if t.Tablespace == "" {
+		return "pg_default"
+	}
+	return t.Tablespace
+}
+
+// GetCollation returns the collation of the column, empty when it uses the
+// default of its type.
+func (c *Column) GetCollation() string {
+	if c.Collation == "default" {
+		return ""
+	}
+	return c.Collation
+}
+
+// CollationSQL renders the COLLATE clause of the column with a leading space,
+// or nothing when it uses the default collation. Collations outside the
+// search path are written as "schema.collation".
+func (c *Column) CollationSQL() string {
+	if c.GetCollation() == "" {
+		return ""
+	}
+	parts := strings.Split(c.GetCollation(), ".")
+	for i, part := range parts {
+		parts[i] = QuoteIdent(part)
+	}
+	return " COLLATE " + strings.Join(parts, ".")
+}
@@ -0,0 +1,65 @@
+package objects
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	connectionPasswordRegex    = regexp.MustCompile(`(?i)(^|\s)password\s*=\s*('(?:[^'\\]|\\.)*'|\S+)`)
+	connectionURIPasswordRegex = regexp.MustCompile(`(?i)^(postgres(?:ql)?://[^:/@?]*):[^@/?]*@`)
+)
+
+// GetEnabled reports whether the subscription replicates changes.
+func (s *Subscription) GetEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// GetSlotName returns the name of the replication slot on the publisher,
+// the subscription name unless set.
+func (s *Subscription) GetSlotName() string {
+	if s.SlotName == "" {
+		return s.Name
+	}
+	return s.SlotName
+}
+
+// GetPublications returns the subscribed publications sorted by name.
+func (s *Subscription) GetPublications() []string {
+	publications := append([]string{}, s.Publications...)
+	sort.Strings(publications)
+	return publications
+}
+
+// PublicationsSQL renders the subscribed publications as listed after
+// PUBLICATION.
+func (s *Subscription) PublicationsSQL() string {
+	return QuoteIdents(s.GetPublications())
+}
+
+// SQL renders the CREATE SUBSCRIPTION statement. The subscription is created
+// disabled and without a replication slot so that the statement can run in
+// the migration transaction; ENABLE follows separately.
+func (s *Subscription) SQL() string {
+	options := "create_slot = false, enabled = false"
+	if s.GetSlotName() != s.Name {
+		options += fmt.Sprintf(", slot_name = %s", QuoteLiteral(s.GetSlotName()))
+	}
+	return fmt.Sprintf("CREATE SUBSCRIPTION %s CONNECTION %s PUBLICATION %s WITH (%s);", QuoteIdent(s.Name), QuoteLiteral(s.Connection), s.PublicationsSQL(), options)
+}
+
+// ConnectionHasPassword reports whether the libpq connection string holds a
+// password, either as a password keyword or in the userinfo of a URI.
+func ConnectionHasPassword(connection string) bool {
+	return connectionPasswordRegex.MatchString(connection) || connectionURIPasswordRegex.MatchString(connection)
+}
+
+// ConnectionWithoutPassword returns the libpq connection string with its
+// password removed.
+func ConnectionWithoutPassword(connection string) string {
+	connection = connectionURIPasswordRegex.ReplaceAllString(connection, "$1@")
+	connection = connectionPasswordRegex.ReplaceAllString(connection, "")
+	return strings.TrimSpace(connection)
+}
@@ -0,0 +1,206 @@
+package objects
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExpandTemplates replaces the templates tables extend by their columns. The
+// columns of the templates come first, in the order they are extended, and a
+// column declared again, by a later template or by the table itself,
+// overrides the earlier one in place. Every table gets its own copy of the
+// columns, so that serial columns get a sequence per table. All problems are
+// returned as ValidationErrors, located by positions.
+func ExpandTemplates(templates []*Template, namespaces []*Namespace, positions Positions) error {
+	v := &validator{positions: positions}
+	byName := map[string]*Template{}
+	for i, t := range templates {
+		path := fmt.Sprintf("templates[%d]", i)
+		if t.Name == "" {
+			v.check(path, fmt.Errorf("template has no name"), t)
+			continue
+		}
+		if byName[t.Name] != nil {
+			v.check(path, fmt.Errorf("template %s is declared twice", t.Name), t)
+			continue
+		}
+		byName[t.Name] = t
+	}
+
+	resolved := map[string][]*Column{}
+	for _, n := range namespaces {
+		for _, t := range n.Tables {
+			if len(t.Extends) == 0 {
+				continue
+			}
+			columns, err := extendedColumns(t.Extends, byName, resolved, nil)
+			if err != nil {
+				v.check(n.Name+"."+t.Name, err, t, n)
+				continue
+			}
+			t.Columns = mergeColumns(columns, t.Columns)
+			t.Extends = nil
+		}
+	}
+	return v.err()
+}
+
+// extendedColumns returns the merged columns of the named templates. Visiting
+// holds the templates being resolved, to catch templates extending themselves.
+func extendedColumns(names []string, templates map[string]*Template, resolved map[string][]*Column, visiting []string) ([]*Column, error) {
+	columns := []*Column{}
+	for _, name := range names {
+		if _, ok := resolved[name]; !ok {
+			template := templates[name]
+			if template == nil {
+				return nil, fmt.Errorf("extends unknown template %s", name)
+			}
+			for _, v := range visiting {
+				if v == name {
+					return nil, fmt.Errorf("extends template %s, which extends itself through %s", name, strings.Join(append(visiting, name), " -> "))
+				}
+			}
+			base, err := extendedColumns(template.Extends, templates, resolved, append(visiting, name))
+			if err != nil {
+				return nil, err
+			}
+			resolved[name] = mergeColumns(base, template.Columns)
+		}
+		columns = mergeColumns(columns, resolved[name])
+	}
+	return columns, nil
+}
+
+// mergeColumns returns copies of the base columns with the other columns
+// merged in: a column named like a base column replaces it, the others are
+// appended.
+func mergeColumns(base, other []*Column) []*Column {
+	merged := []*Column{}
+	for _, c := range base {
+		merged = append(merged, c.copy())
+	}
+	for _, c := range other {
+		replaced := false
+		for i, m := range merged {
+			if m.Name == c.Name {
+				merged[i] = c.copy()
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, c.copy())
+		}
+	}
+	return merged
+}
+
+func (c *Column) copy() *Column {
+	copied := *c
+	if c.Identity != nil {
+		identity := *c.Identity
+		copied.Identity = &identity
+	}
+	return &copied
+}
+
+// FactorTemplates is the reverse of ExpandTemplates, used when exporting:
+// tables that start with the same column are grouped, and the leading columns
+// every table of a group shares with identical definitions become a template
+// they all extend. Only leading columns are factored, so that expanding the
+// templates again keeps the column order. The namespaces are returned as
+// copies, leaving the given ones untouched.
+func FactorTemplates(namespaces []*Namespace) ([]*Template, []*Namespace) {
+	type tableRef struct {
+		table      *Table
+		signatures []string
+	}
+	refs := []*tableRef{}
+	for _, n := range namespaces {
+		for _, t := range n.Tables {
+			ref := &tableRef{table: t}
+			for _, c := range t.Columns {
+				ref.signatures = append(ref.signatures, c.signature())
+			}
+			refs = append(refs, ref)
+		}
+	}
+
+	groups := map[string][]*tableRef{}
+	for _, ref := range refs {
+		if len(ref.signatures) > 0 {
+			groups[ref.signatures[0]] = append(groups[ref.signatures[0]], ref)
+		}
+	}
+
+	prefixes := map[*Table]int{}
+	keys := map[*Table]string{}
+	for key, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		shared := len(group[0].signatures)
+		for _, ref := range group[1:] {
+			length := 0
+			for length < shared && length < len(ref.signatures) && ref.signatures[length] == group[0].signatures[length] {
+				length++
+			}
+			shared = length
+		}
+		for _, ref := range group {
+			prefixes[ref.table], keys[ref.table] = shared, key
+		}
+	}
+
+	templates := []*Template{}
+	names := map[string]string{}
+	taken := map[string]bool{}
+	factored := []*Namespace{}
+	for _, n := range namespaces {
+		copied := *n
+		copied.Tables = []*Table{}
+		for _, t := range n.Tables {
+			table := *t
+			length, key := prefixes[t], keys[t]
+			if length > 0 {
+				name, ok := names[key]
+				if !ok {
+					name = templateName(t.Columns[:length], taken)
+					names[key] = name
+					templates = append(templates, &Template{Name: name, Columns: t.Columns[:length]})
+				}
+				table.Extends = []string{name}
+				table.Columns = t.Columns[length:]
+			}
+			copied.Tables = append(copied.Tables, &table)
+		}
+		factored = append(factored, &copied)
+	}
+	return templates, factored
+}
+
+// templateName names a factored template after its columns, e.g.
+// "id_created_at", numbering it when the name is taken.
+func templateName(columns []*Column, taken map[string]bool) string {
+	names := []string{}
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	base := strings.Join(names, "_")
+	name := base
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	taken[name] = true
+	return name
+}
+
+// signature identifies the full definition of a column.
+func (c *Column) signature() string {
+	comment := ""
+	if c.Comment != nil {
+		comment = strconv.Quote(*c.Comment)
+	}
+	return fmt.Sprintf("%s|%t|%s", c.String(), c.IsPrimaryKey, comment)
+}
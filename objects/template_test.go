@@ -0,0 +1,145 @@
+package objects
+
+import (
+	"strings"
+	"testing"
+)
+
+func columnNames(columns []*Column) string {
+	names := []string{}
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func TestExpandTemplates(t *testing.T) {
+	templates := []*Template{
+		{Name: "id", Columns: []*Column{{Name: "id", Type: "serial", IsPrimaryKey: true}}},
+		{Name: "timestamps", Columns: []*Column{
+			{Name: "created_at", Type: "timestamptz", Default: "now()"},
+			{Name: "updated_at", Type: "timestamptz", Default: "now()"},
+		}},
+		{Name: "entity", Extends: []string{"id", "timestamps"}},
+	}
+	namespaces := []*Namespace{
+		{Name: "app", Tables: []*Table{
+			{Name: "users", Extends: []string{"entity"}, Columns: []*Column{
+				{Name: "email", Type: "text", Nullable: true},
+				{Name: "updated_at", Type: "timestamptz", Nullable: true},
+			}},
+			{Name: "orders", Extends: []string{"id"}},
+		}},
+	}
+
+	if err := ExpandTemplates(templates, namespaces, nil); err != nil {
+		t.Fatalf("could not expand templates: %v", err)
+	}
+
+	users, orders := namespaces[0].Tables[0], namespaces[0].Tables[1]
+	if names := columnNames(users.Columns); names != "id, created_at, updated_at, email" {
+		t.Errorf("expected template columns first and overrides in place, got: %s", names)
+	}
+	if !users.Columns[2].Nullable || users.Columns[2].Default != "" {
+		t.Errorf("expected updated_at to be overridden by the table, got: %+v", users.Columns[2])
+	}
+	if users.Extends != nil {
+		t.Errorf("expected extends to be cleared once expanded, got: %v", users.Extends)
+	}
+	if names := columnNames(orders.Columns); names != "id" {
+		t.Errorf("expected orders to take the id column, got: %s", names)
+	}
+	if users.Columns[0] == orders.Columns[0] || users.Columns[0] == templates[0].Columns[0] {
+		t.Errorf("expected every table to get its own copy of the template columns")
+	}
+
+	if err := namespaces[0].Normalize(); err != nil {
+		t.Fatalf("could not normalize: %v", err)
+	}
+	if len(namespaces[0].Sequences) != 2 {
+		t.Errorf("expected a sequence per table for the templated serial column, got: %d", len(namespaces[0].Sequences))
+	}
+}
+
+func TestExpandTemplates_Errors(t *testing.T) {
+	cases := []struct {
+		templates []*Template
+		extends   []string
+		err       string
+	}{
+		{[]*Template{{Name: "a"}}, []string{"b"}, "extends unknown template b"},
+		{[]*Template{{Name: "a"}, {Name: "a"}}, []string{"a"}, "template a is declared twice"},
+		{[]*Template{{Name: ""}}, []string{"a"}, "template has no name"},
+		{[]*Template{{Name: "a", Extends: []string{"b"}}, {Name: "b", Extends: []string{"a"}}}, []string{"a"}, "extends itself through a -> b -> a"},
+	}
+
+	for _, tc := range cases {
+		namespaces := []*Namespace{{Name: "app", Tables: []*Table{{Name: "users", Extends: tc.extends}}}}
+		err := ExpandTemplates(tc.templates, namespaces, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected error containing %q, got: %v", tc.err, err)
+		}
+	}
+}
+
+func TestFactorTemplates(t *testing.T) {
+	id := func() *Column {
+		return &Column{Name: "id", Type: "BIGINT", Identity: &ColumnIdentity{}, IsPrimaryKey: true}
+	}
+	created := func() *Column { return &Column{Name: "created_at", Type: "TIMESTAMP WITH TIME ZONE", Default: "now()"} }
+	namespaces := []*Namespace{
+		{Name: "app", Tables: []*Table{
+			{Name: "users", Columns: []*Column{id(), created(), {Name: "email", Type: "TEXT", Nullable: true}}},
+			{Name: "orders", Columns: []*Column{id(), created(), {Name: "total", Type: "NUMERIC", Nullable: true}}},
+			{Name: "tags", Columns: []*Column{{Name: "name", Type: "TEXT"}, id()}},
+		}},
+	}
+
+	templates, factored := FactorTemplates(namespaces)
+
+	if len(templates) != 1 || templates[0].Name != "id_created_at" || columnNames(templates[0].Columns) != "id, created_at" {
+		t.Fatalf("expected an id_created_at template, got: %+v", templates)
+	}
+	users, tags := factored[0].Tables[0], factored[0].Tables[2]
+	if strings.Join(users.Extends, ",") != "id_created_at" || columnNames(users.Columns) != "email" {
+		t.Errorf("expected users to extend the template, got: %v with %s", users.Extends, columnNames(users.Columns))
+	}
+	if tags.Extends != nil || columnNames(tags.Columns) != "name, id" {
+		t.Errorf("expected tags to be left as is, got: %v with %s", tags.Extends, columnNames(tags.Columns))
+	}
+	if len(namespaces[0].Tables[0].Columns) != 3 || namespaces[0].Tables[0].Extends != nil {
+		t.Errorf("expected the given namespaces to be left untouched")
+	}
+
+	if err := ExpandTemplates(templates, factored, nil); err != nil {
+		t.Fatalf("could not expand factored templates: %v", err)
+	}
+	if names := columnNames(factored[0].Tables[1].Columns); names != "id, created_at, total" {
+		t.Errorf("expected expanding to restore the column order, got: %s", names)
+	}
+}
+
+func TestFactorTemplates_SharedPrefixOfGroup(t *testing.T) {
+	id := func() *Column { return &Column{Name: "id", Type: "BIGINT", IsPrimaryKey: true} }
+	created := func() *Column { return &Column{Name: "created_at", Type: "TIMESTAMP WITH TIME ZONE", Nullable: true} }
+	updated := func() *Column { return &Column{Name: "updated_at", Type: "TIMESTAMP WITH TIME ZONE", Nullable: true} }
+	namespaces := []*Namespace{
+		{Name: "app", Tables: []*Table{
+			{Name: "users", Columns: []*Column{id(), created(), updated(), {Name: "email", Type: "TEXT", Nullable: true}}},
+			{Name: "orders", Columns: []*Column{id(), created(), updated(), {Name: "total", Type: "NUMERIC", Nullable: true}}},
+			{Name: "events", Columns: []*Column{id(), created(), {Name: "kind", Type: "TEXT", Nullable: true}}},
+		}},
+	}
+
+	templates, factored := FactorTemplates(namespaces)
+
+	if len(templates) != 1 || columnNames(templates[0].Columns) != "id, created_at" {
+		t.Fatalf("expected a single template of the prefix all tables share, got: %+v", templates)
+	}
+	for i, rest := range []string{"updated_at, email", "updated_at, total", "kind"} {
+		table := factored[0].Tables[i]
+		if strings.Join(table.Extends, ",") != templates[0].Name || columnNames(table.Columns) != rest {
+			t.Errorf("expected %s to extend the template and keep %s, got: %v with %s", table.Name, rest, table.Extends, columnNames(table.Columns))
+		}
+	}
+}
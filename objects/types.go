@@ -0,0 +1,360 @@
+package objects
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	typeArraySuffixRegex = regexp.MustCompile(`(?i)(\[\d*\]|\s+array(\[\d*\])?)$`)
+	typeModifierRegex    = regexp.MustCompile(`^([^(]*?)\s*\(([^)]*)\)\s*(.*)$`)
+	typeSpaceRegex       = regexp.MustCompile(`\s+`)
+)
+
+// typeAliases maps the spellings PostgreSQL accepts for built-in types to the
+// canonical name reported by format_type, upper-cased.
+var typeAliases = map[string]string{
+	"int":                         "INTEGER",
+	"int4":                        "INTEGER",
+	"integer":                     "INTEGER",
+	"int2":                        "SMALLINT",
+	"smallint":                    "SMALLINT",
+	"int8":                        "BIGINT",
+	"bigint":                      "BIGINT",
+	"varchar":                     "CHARACTER VARYING",
+	"character varying":           "CHARACTER VARYING",
+	"char":                        "CHARACTER",
+	"character":                   "CHARACTER",
+	"bpchar":                      "CHARACTER",
+	"bool":                        "BOOLEAN",
+	"boolean":                     "BOOLEAN",
+	"float4":                      "REAL",
+	"real":                        "REAL",
+	"float8":                      "DOUBLE PRECISION",
+	"double precision":            "DOUBLE PRECISION",
+	"decimal":                     "NUMERIC",
+	"numeric":                     "NUMERIC",
+	"timestamp":                   "TIMESTAMP WITHOUT TIME ZONE",
+	"timestamp without time zone": "TIMESTAMP WITHOUT TIME ZONE",
+	"timestamptz":                 "TIMESTAMP WITH TIME ZONE",
+	"timestamp with time zone":    "TIMESTAMP WITH TIME ZONE",
+	"time":                        "TIME WITHOUT TIME ZONE",
+	"time without time zone":      "TIME WITHOUT TIME ZONE",
+	"timetz":                      "TIME WITH TIME ZONE",
+	"time with time zone":         "TIME WITH TIME ZONE",
+	"bit":                         "BIT",
+	"varbit":                      "BIT VARYING",
+	"bit varying":                 "BIT VARYING",
+}
+
+// builtinTypes are the other built-in types, whose modifiers, if they take
+// any, are numbers like those of the types above.
+var builtinTypes = map[string]bool{
+	"TEXT": true, "BYTEA": true, "UUID": true, "JSON": true, "JSONB": true, "DATE": true, "INTERVAL": true,
+}
+
+// serialTypes maps the serial pseudo-types to the integer type backing them.
+var serialTypes = map[string]string{
+	"smallserial": "SMALLINT",
+	"serial2":     "SMALLINT",
+	"serial":      "INTEGER",
+	"serial4":     "INTEGER",
+	"bigserial":   "BIGINT",
+	"serial8":     "BIGINT",
+}
+
+// ColumnType is a column type in canonical form: the upper-cased name
+// format_type would report, with its modifiers split out.
+type ColumnType struct {
+	Name            string
+	MaxLength       int
+	Precision       *int
+	Scale           int
+	ArrayDimensions int
+	// Modifier holds the modifiers of types that are not built in, such as
+	// Point,4326 for geometry(Point,4326), as written: only the type knows
+	// what they mean.
+	Modifier string
+	// Serial is set for serial, bigserial and smallserial, which are not real
+	// types but an integer column backed by an owned sequence.
+	Serial bool
+}
+
+// ParseType parses a user-written type such as "varchar(255)", "int4",
+// "timestamptz(3)" or "text[]" into its canonical form. Unknown types, such as
+// those provided by extensions, are upper-cased unless quoted, and their
+// modifiers are kept as written.
+func ParseType(typ string) (*ColumnType, error) {
+	parsed := &ColumnType{}
+
+	name := strings.TrimSpace(typ)
+	for {
+		match := typeArraySuffixRegex.FindString(name)
+		if match == "" {
+			break
+		}
+		name = strings.TrimSpace(strings.TrimSuffix(name, match))
+		parsed.ArrayDimensions++
+	}
+
+	var modifiers []string
+	if matches := typeModifierRegex.FindStringSubmatch(name); matches != nil {
+		name = strings.TrimSpace(matches[1] + " " + matches[3])
+		for _, modifier := range strings.Split(matches[2], ",") {
+			modifiers = append(modifiers, strings.TrimSpace(modifier))
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("type %q has no name", typ)
+	}
+
+	if strings.Contains(name, `"`) {
+		parsed.Name = name
+	} else {
+		lower := typeSpaceRegex.ReplaceAllString(strings.ToLower(name), " ")
+		if serial, ok := serialTypes[lower]; ok {
+			parsed.Name, parsed.Serial = serial, true
+		} else if canonical, ok := typeAliases[lower]; ok {
+			parsed.Name = canonical
+		} else if lower == "float" {
+			parsed.Name = "DOUBLE PRECISION"
+			if len(modifiers) == 1 {
+				if p, err := strconv.Atoi(modifiers[0]); err == nil && p <= 24 {
+					parsed.Name = "REAL"
+				}
+				modifiers = nil
+			}
+		} else {
+			parsed.Name = strings.ToUpper(lower)
+		}
+	}
+
+	if len(modifiers) > 0 && !parsed.isBuiltin() {
+		parsed.Modifier = strings.Join(modifiers, ",")
+		modifiers = nil
+	}
+	if len(modifiers) > 2 {
+		return nil, fmt.Errorf("type %q has too many modifiers", typ)
+	}
+	values := make([]int, len(modifiers))
+	for i, modifier := range modifiers {
+		value, err := strconv.Atoi(modifier)
+		if err != nil {
+			return nil, fmt.Errorf("type %q has an invalid modifier %q", typ, modifier)
+		}
+		values[i] = value
+	}
+
+	if len(values) > 0 {
+		if parsed.HasLength() {
+			if len(values) > 1 {
+				return nil, fmt.Errorf("type %q only takes a length", typ)
+			}
+			parsed.MaxLength = values[0]
+		} else {
+			parsed.Precision = &values[0]
+			if len(values) > 1 {
+				parsed.Scale = values[1]
+			}
+		}
+	}
+
+	return parsed, nil
+}
+
+// isBuiltin reports whether the type is a built-in one, whose modifiers are a
+// length or a precision and scale.
+func (t *ColumnType) isBuiltin() bool {
+	if builtinTypes[t.Name] || strings.HasPrefix(t.Name, "INTERVAL") {
+		return true
+	}
+	for _, name := range typeAliases {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NameSQL returns the name of the type together with the modifiers kept as
+// written, the form a column type is stored in once its length, precision
+// and scale are split out.
+func (t *ColumnType) NameSQL() string {
+	if t.Modifier == "" {
+		return t.Name
+	}
+	return t.Name + "(" + t.Modifier + ")"
+}
+
+// HasLength reports whether the type's modifier is a length rather than a
+// precision.
+func (t *ColumnType) HasLength() bool {
+	switch t.Name {
+	case "CHARACTER VARYING", "CHARACTER", "BIT", "BIT VARYING":
+		return true
+	}
+	return false
+}
+
+// IsInteger reports whether the type is one of the integer types.
+func (t *ColumnType) IsInteger() bool {
+	switch t.Name {
+	case "SMALLINT", "INTEGER", "BIGINT":
+		return true
+	}
+	return false
+}
+
+// family returns the group of types whose values a foreign key can compare
+// directly: the integer types, and the character types. Other types only
+// belong with themselves.
+func (t *ColumnType) family() string {
+	switch {
+	case t.IsInteger():
+		return "integer"
+	case t.Name == "TEXT" || t.Name == "CHARACTER VARYING" || t.Name == "CHARACTER":
+		return "character"
+	}
+	return t.Name
+}
+
+// IsCollatable reports whether the type can take a collation. Only the
+// built-in types known not to be collatable are rejected, as extension types
+// and domains may well be.
+func (t *ColumnType) IsCollatable() bool {
+	if t.Serial || t.Name == "UUID" || t.Name == "JSONB" || t.Name == "JSON" || t.Name == "DATE" {
+		return false
+	}
+	for _, name := range typeAliases {
+		if t.Name == name {
+			return t.HasLength() && !strings.HasPrefix(t.Name, "BIT")
+		}
+	}
+	return true
+}
+
+// ColumnType returns the canonical type of the column, combining the type
+// string with the modifier fields. Modifiers written in both places must agree.
+func (c *Column) ColumnType() (*ColumnType, error) {
+	parsed, err := ParseType(c.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.MaxLength != 0 {
+		if parsed.MaxLength != 0 && parsed.MaxLength != c.MaxLength {
+			return nil, fmt.Errorf("column %s has length %d in its type but max_length %d", c.Name, parsed.MaxLength, c.MaxLength)
+		}
+		parsed.MaxLength = c.MaxLength
+	}
+	if c.Precision != nil {
+		if parsed.Precision != nil && *parsed.Precision != *c.Precision {
+			return nil, fmt.Errorf("column %s has precision %d in its type but precision %d", c.Name, *parsed.Precision, *c.Precision)
+		}
+		parsed.Precision = c.Precision
+	}
+	if c.Scale != 0 {
+		if parsed.Scale != 0 && parsed.Scale != c.Scale {
+			return nil, fmt.Errorf("column %s has scale %d in its type but scale %d", c.Name, parsed.Scale, c.Scale)
+		}
+		parsed.Scale = c.Scale
+	}
+	if c.ArrayDimensions != 0 {
+		if parsed.ArrayDimensions != 0 && parsed.ArrayDimensions != c.ArrayDimensions {
+			return nil, fmt.Errorf("column %s has %d array dimensions in its type but array_dimensions %d", c.Name, parsed.ArrayDimensions, c.ArrayDimensions)
+		}
+		parsed.ArrayDimensions = c.ArrayDimensions
+	}
+
+	return parsed, nil
+}
+
+// Normalize rewrites the column type into its canonical form. Serial types
+// are left to Namespace.Normalize, which also creates their sequence.
+func (c *Column) Normalize() error {
+	parsed, err := c.ColumnType()
+	if err != nil {
+		return err
+	}
+
+	if !parsed.Serial {
+		c.Type = parsed.NameSQL()
+	}
+	c.MaxLength = parsed.MaxLength
+	c.Precision = parsed.Precision
+	c.Scale = parsed.Scale
+	c.ArrayDimensions = parsed.ArrayDimensions
+	return nil
+}
+
+// Normalize brings the namespace into canonical form: column and sequence
+// types are rewritten to their canonical names, serial columns are expanded
+// into an integer column with an owned sequence and a nextval default
+// qualified with the namespace, as the search path may not include it,
+// foreign keys without a schema are pointed at this namespace and primary
+// keys are declared both on the columns and as a constraint.
+func (n *Namespace) Normalize() error {
+	v := &validator{}
+	n.normalize(v)
+	return v.err()
+}
+
+// NormalizeNamespaces normalizes every namespace. All problems are returned as
+// ValidationErrors, located by positions.
+func NormalizeNamespaces(namespaces []*Namespace, positions Positions) error {
+	v := &validator{positions: positions}
+	for _, n := range namespaces {
+		n.normalize(v)
+	}
+	return v.err()
+}
+
+func (n *Namespace) normalize(v *validator) {
+	for _, s := range n.Sequences {
+		if parsed, err := ParseType(s.Type); err == nil && parsed.IsInteger() {
+			s.Type = strings.ToLower(parsed.Name)
+		}
+	}
+
+	for _, t := range n.Tables {
+		t.NormalizePrimaryKey()
+		for _, c := range t.Constraints {
+			if c.Type == ConstraintTypeForeignKey && c.Reference != nil && c.Reference.Schema == "" {
+				c.Reference.Schema = n.Name
+			}
+		}
+
+		for i, c := range t.Columns {
+			if err := c.Normalize(); err != nil {
+				v.check(fmt.Sprintf("%s.%s.columns[%d]", n.Name, t.Name, i), err, c, t, n)
+				continue
+			}
+
+			parsed, err := ParseType(c.Type)
+			if err != nil || !parsed.Serial {
+				continue
+			}
+
+			sequence := fmt.Sprintf("%s_%s_seq", t.Name, c.Name)
+			c.Type = parsed.Name
+			c.Nullable = false
+			if c.Default == "" {
+				c.Default = fmt.Sprintf("nextval(%s)", QuoteLiteral(QualifiedName(n.Name, sequence)))
+			}
+			if n.getSequence(sequence) == nil {
+				n.Sequences = append(n.Sequences, &Sequence{Name: sequence, Type: strings.ToLower(parsed.Name), OwnedBy: fmt.Sprintf("%s.%s", t.Name, c.Name)})
+			}
+		}
+	}
+}
+
+func (n *Namespace) getSequence(name string) *Sequence {
+	for _, s := range n.Sequences {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
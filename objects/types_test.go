@@ -0,0 +1,194 @@
+package objects
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseType_FormattedTypes(t *testing.T) {
+	precision := func(p int) *int { return &p }
+	cases := map[string]ColumnType{
+		"numeric(12,2)":               {Name: "NUMERIC", Precision: precision(12), Scale: 2},
+		"timestamp(3) with time zone": {Name: "TIMESTAMP WITH TIME ZONE", Precision: precision(3)},
+		"character(2)":                {Name: "CHARACTER", MaxLength: 2},
+		"bit varying(8)":              {Name: "BIT VARYING", MaxLength: 8},
+		"text[]":                      {Name: "TEXT", ArrayDimensions: 1},
+		"character varying(100)[][]":  {Name: "CHARACTER VARYING", MaxLength: 100, ArrayDimensions: 2},
+		`"Money"`:                     {Name: `"Money"`},
+		"time without time zone":      {Name: "TIME WITHOUT TIME ZONE"},
+		"geometry(Point,4326)":        {Name: "GEOMETRY", Modifier: "Point,4326"},
+		"vector(1536)":                {Name: "VECTOR", Modifier: "1536"},
+	}
+
+	for input, expected := range cases {
+		parsed, err := ParseType(input)
+		if err != nil {
+			t.Errorf("could not parse %q: %v", input, err)
+			continue
+		}
+		if parsed.Name != expected.Name || parsed.MaxLength != expected.MaxLength || parsed.Scale != expected.Scale || parsed.Modifier != expected.Modifier ||
+			parsed.ArrayDimensions != expected.ArrayDimensions || (parsed.Precision == nil) != (expected.Precision == nil) ||
+			(parsed.Precision != nil && *parsed.Precision != *expected.Precision) {
+			t.Errorf("expected %q to parse into %+v, got %+v", input, expected, parsed)
+		}
+	}
+}
+
+func TestParseType_Aliases(t *testing.T) {
+	cases := map[string]string{
+		"int":                         "INTEGER",
+		"int4":                        "INTEGER",
+		"INTEGER":                     "INTEGER",
+		"int8":                        "BIGINT",
+		"varchar(255)":                "CHARACTER VARYING(255)",
+		"character  varying(20)":      "CHARACTER VARYING(20)",
+		"bool":                        "BOOLEAN",
+		"timestamptz":                 "TIMESTAMP WITH TIME ZONE",
+		"timestamp":                   "TIMESTAMP WITHOUT TIME ZONE",
+		"timestamp(3) with time zone": "TIMESTAMP(3) WITH TIME ZONE",
+		"timestamptz(3)":              "TIMESTAMP(3) WITH TIME ZONE",
+		"decimal(12, 2)":              "NUMERIC(12,2)",
+		"float":                       "DOUBLE PRECISION",
+		"float(10)":                   "REAL",
+		"text[]":                      "TEXT[]",
+		"int array":                   "INTEGER[]",
+		"citext":                      "CITEXT",
+		"geometry(Point, 4326)":       "GEOMETRY(Point,4326)",
+		"vector(1536)[]":              "VECTOR(1536)[]",
+	}
+
+	for input, expected := range cases {
+		parsed, err := ParseType(input)
+		if err != nil {
+			t.Errorf("could not parse %q: %v", input, err)
+			continue
+		}
+		if got := parsed.SQL(); got != expected {
+			t.Errorf("expected %q to normalize to %s, got %s", input, expected, got)
+		}
+	}
+}
+
+func TestParseType_InvalidModifier(t *testing.T) {
+	if _, err := ParseType("varchar(abc)"); err == nil {
+		t.Error("expected error for a non-numeric modifier")
+	}
+}
+
+func TestColumn_Normalize_KeepsExtensionModifiers(t *testing.T) {
+	for typ, expected := range map[string]string{"geometry(Point,4326)": "GEOMETRY(Point,4326)", "vector(1536)": "VECTOR(1536)"} {
+		c := &Column{Name: "value", Type: typ, Nullable: true}
+		if err := c.Normalize(); err != nil {
+			t.Fatalf("could not normalize %s: %v", typ, err)
+		}
+		if c.Type != expected || c.Precision != nil || c.TypeSQL() != expected {
+			t.Errorf("expected %s to normalize to %s, got type %s and precision %v", typ, expected, c.Type, c.Precision)
+		}
+		if err := c.Valid(); err != nil {
+			t.Errorf("expected a %s column to be valid, got: %v", typ, err)
+		}
+	}
+}
+
+func TestColumn_ColumnType_ConflictingModifiers(t *testing.T) {
+	c := &Column{Name: "email", Type: "varchar(255)", MaxLength: 100}
+	if _, err := c.ColumnType(); err == nil {
+		t.Error("expected error when the type and max_length disagree")
+	}
+}
+
+func TestColumn_Valid_AliasedVarchar(t *testing.T) {
+	c := &Column{Name: "email", Type: "varchar(255)", Nullable: true}
+	if err := c.Valid(); err != nil {
+		t.Errorf("expected varchar(255) to be valid, got: %v", err)
+	}
+}
+
+func TestColumn_Valid_Serial(t *testing.T) {
+	c := &Column{Name: "id", Type: "serial"}
+	if err := c.Valid(); err != nil {
+		t.Errorf("expected serial column to be valid, got: %v", err)
+	}
+}
+
+func TestNamespace_Normalize_ExpandsSerial(t *testing.T) {
+	ns := &Namespace{Name: "public", Tables: []*Table{
+		{Name: "users", Columns: []*Column{
+			{Name: "id", Type: "bigserial"},
+			{Name: "email", Type: "varchar(255)", Nullable: true},
+		}},
+	}}
+
+	if err := ns.Normalize(); err != nil {
+		t.Fatalf("could not normalize: %v", err)
+	}
+
+	id := ns.Tables[0].Columns[0]
+	if id.Type != "BIGINT" || id.Default != "nextval('public.users_id_seq')" || id.Nullable {
+		t.Errorf("expected serial to expand into a not nullable BIGINT with a nextval default, got: %s", id.String())
+	}
+	if len(ns.Sequences) != 1 || ns.Sequences[0].Name != "users_id_seq" || ns.Sequences[0].Type != "bigint" {
+		t.Errorf("expected users_id_seq to be declared, got: %v", ns.Sequences)
+	}
+
+	email := ns.Tables[0].Columns[1]
+	if email.Type != "CHARACTER VARYING" || email.MaxLength != 255 {
+		t.Errorf("expected email to normalize to CHARACTER VARYING(255), got: %s", email.String())
+	}
+}
+
+func TestNamespace_Normalize_KeepsDeclaredSequence(t *testing.T) {
+	ns := &Namespace{Name: "public",
+		Tables: []*Table{
+			{Name: "users", Columns: []*Column{{Name: "id", Type: "serial"}}},
+		},
+		Sequences: []*Sequence{{Name: "users_id_seq", Type: "bigint"}},
+	}
+
+	if err := ns.Normalize(); err != nil {
+		t.Fatalf("could not normalize: %v", err)
+	}
+	if len(ns.Sequences) != 1 {
+		t.Errorf("expected the declared sequence to be reused, got: %v", ns.Sequences)
+	}
+}
+
+func TestNamespace_Normalize_PrimaryKey(t *testing.T) {
+	flagged := &Table{Name: "users", Columns: []*Column{{Name: "id", Type: "INTEGER", IsPrimaryKey: true}, {Name: "email", Type: "TEXT"}}}
+	declared := &Table{
+		Name:        "orders",
+		Columns:     []*Column{{Name: "id", Type: "INTEGER"}, {Name: "line", Type: "INTEGER"}},
+		Constraints: []*Constraint{{Name: "orders_key", Type: ConstraintTypePrimaryKey, Targets: []string{"id", "line"}}},
+	}
+	n := &Namespace{Name: "public", Tables: []*Table{flagged, declared}}
+	if err := n.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(flagged.Constraints) != 1 || flagged.Constraints[0].String() != "users_pkey PRIMARY KEY (id)" {
+		t.Errorf("expected a users_pkey constraint to be synthesized, got %v", flagged.Constraints)
+	}
+	if !declared.Columns[0].IsPrimaryKey || !declared.Columns[1].IsPrimaryKey || len(declared.Constraints) != 1 {
+		t.Errorf("expected the columns of orders_key to be marked, got %v and %v", declared.Columns[0].IsPrimaryKey, declared.Columns[1].IsPrimaryKey)
+	}
+
+	if name := PrimaryKeyName(strings.Repeat("é", 31)); len(name) > 63 || name != strings.Repeat("é", 29)+"_pkey" {
+		t.Errorf("expected the table name to be clipped to fit 63 bytes, got %s", name)
+	}
+}
+
+func TestNamespace_Normalize_QuotesSerialSequence(t *testing.T) {
+	ns := &Namespace{Name: "app", Tables: []*Table{
+		{Name: "Orders", Columns: []*Column{{Name: "id", Type: "SERIAL"}}},
+	}}
+	if err := ns.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if def := ns.Tables[0].Columns[0].Default; def != `nextval('app."Orders_id_seq"')` {
+		t.Errorf("expected the default to quote the sequence, got %s", def)
+	}
+	if err := (&Database{Namespaces: []*Namespace{ns}}).Valid(); err != nil {
+		t.Errorf("expected the quoted default to resolve to the sequence, got %v", err)
+	}
+}
@@ -2,70 +2,1150 @@ package objects
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
+// maxIdentifierLength is the length in bytes PostgreSQL truncates identifiers
+// to, so a name of multibyte characters reaches it in fewer characters.
+const maxIdentifierLength = 63
+
+var (
+	nextvalRegex          = regexp.MustCompile(`(?i)^nextval\('([^']+)'(::regclass)?\)$`)
+	sequenceOwnerRegex    = regexp.MustCompile(`^[^.]+\.[^.]+(\.[^.]+)?$`)
+	storageParameterRegex = regexp.MustCompile(`^(toast\.)?[a-z_]+$`)
+	publicationTableRegex = regexp.MustCompile(`^[^.]+\.[^.]+$`)
+)
+
+// Valid checks the whole database. Every problem found is returned, as
+// ValidationErrors located with the positions of the database.
+func (d *Database) Valid() error {
+	v := &validator{positions: d.Positions}
+
+	roles := map[string]bool{}
+	for i, r := range d.Roles {
+		path := fmt.Sprintf("roles[%d]", i)
+		v.check(path, r.Valid(), r)
+		if roles[r.Name] {
+			v.check(path, fmt.Errorf("role %s is declared twice", r.Name), r)
+		}
+		roles[r.Name] = true
+	}
+
+	for i, e := range d.Extensions {
+		v.check(fmt.Sprintf("extensions[%d]", i), e.Valid(), e)
+	}
+
+	for _, n := range d.Namespaces {
+		n.validate(v)
+	}
+
+	publications := map[string]bool{}
+	for i, p := range d.Publications {
+		path := fmt.Sprintf("publications[%d]", i)
+		if err := p.Valid(); err != nil {
+			v.check(path, err, p)
+		} else {
+			v.check(path, d.validPublicationTables(p), p)
+		}
+		if publications[p.Name] {
+			v.check(path, fmt.Errorf("publication %s is declared twice", p.Name), p)
+		}
+		publications[p.Name] = true
+	}
+
+	subscriptions := map[string]bool{}
+	for i, s := range d.Subscriptions {
+		path := fmt.Sprintf("subscriptions[%d]", i)
+		v.check(path, s.Valid(), s)
+		if subscriptions[s.Name] {
+			v.check(path, fmt.Errorf("subscription %s is declared twice", s.Name), s)
+		}
+		subscriptions[s.Name] = true
+	}
+
+	d.validReferences(v)
+	d.validSequenceDefaults(v)
+	return v.err()
+}
+
+func tooLong(name string) bool {
+	return len(name) > maxIdentifierLength
+}
+
+// validPublicationTables checks that the tables and columns a publication
+// lists are declared in the database.
+func (d *Database) validPublicationTables(p *Publication) error {
+	for _, t := range p.Tables {
+		schema, name := t.splitTable()
+		table := d.getTable(schema, name)
+		if table == nil {
+			return fmt.Errorf("publication %s lists unknown table %s", p.Name, t.Table)
+		}
+		for _, col := range t.Columns {
+			if table.getColumn(col) == nil {
+				return fmt.Errorf("publication %s lists unknown column %s of table %s", p.Name, col, t.Table)
+			}
+		}
+	}
+	return nil
+}
+
+// validReferences checks that every foreign key points to a table and columns
+// declared in the database. References without a schema resolve to the
+// namespace of the constraint's table.
+func (d *Database) validReferences(v *validator) {
+	for _, n := range d.Namespaces {
+		for _, t := range n.Tables {
+			for i, c := range t.Constraints {
+				if c.Type != ConstraintTypeForeignKey {
+					continue
+				}
+				path := fmt.Sprintf("%s.%s.constraints[%d]", n.Name, t.Name, i)
+				v.check(path, d.validReference(n, t, c), c, t, n)
+			}
+		}
+	}
+}
+
+func (d *Database) validReference(n *Namespace, t *Table, c *Constraint) error {
+	if c.Reference == nil || c.Reference.Table == "" {
+		return fmt.Errorf("foreign key %s on table %s has no reference", c.Name, t.Name)
+	}
+
+	schema := c.Reference.Schema
+	if schema == "" {
+		schema = n.Name
+	}
+	table := d.getTable(schema, c.Reference.Table)
+	if table == nil {
+		return fmt.Errorf("foreign key %s on table %s references unknown table %s.%s", c.Name, t.Name, schema, c.Reference.Table)
+	}
+	if len(c.Reference.Columns) != len(c.Targets) {
+		return fmt.Errorf("foreign key %s on table %s has %d columns but references %d", c.Name, t.Name, len(c.Targets), len(c.Reference.Columns))
+	}
+	for _, col := range c.Reference.Columns {
+		if table.getColumn(col) == nil {
+			return fmt.Errorf("foreign key %s on table %s references unknown column %s.%s.%s", c.Name, t.Name, schema, table.Name, col)
+		}
+	}
+	if !table.isUnique(c.Reference.Columns) {
+		return fmt.Errorf("foreign key %s on table %s references %s.%s (%s), which is not a primary key or unique", c.Name, t.Name, schema, table.Name, strings.Join(c.Reference.Columns, ", "))
+	}
+
+	for i, target := range c.Targets {
+		column, referenced := t.getColumn(target), table.getColumn(c.Reference.Columns[i])
+		if column == nil {
+			continue
+		}
+		columnType, err := column.ColumnType()
+		if err != nil {
+			continue
+		}
+		referencedType, err := referenced.ColumnType()
+		if err != nil {
+			continue
+		}
+		columnType, referencedType = d.baseType(n.Name, columnType), d.baseType(schema, referencedType)
+		if columnType.family() != referencedType.family() || columnType.ArrayDimensions != referencedType.ArrayDimensions {
+			return fmt.Errorf("foreign key %s on table %s has column %s of type %s, which does not match %s.%s.%s of type %s", c.Name, t.Name, target, column.TypeSQL(), schema, table.Name, referenced.Name, referenced.TypeSQL())
+		}
+	}
+	return nil
+}
+
+// isUnique reports whether the columns, in any order, are those of the
+// primary key, a unique constraint or a unique index that is not partial, as
+// a foreign key requires of the columns it references. Columns marked
+// primary_key count as the primary key.
+func (t *Table) isUnique(columns []string) bool {
+	same := func(other []string) bool {
+		if len(other) != len(columns) {
+			return false
+		}
+		for _, col := range columns {
+			found := false
+			for _, o := range other {
+				found = found || o == col
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+
+	marked := []string{}
+	for _, c := range t.Columns {
+		if c.IsPrimaryKey {
+			marked = append(marked, c.Name)
+		}
+	}
+	if len(marked) > 0 && same(marked) {
+		return true
+	}
+	for _, c := range t.Constraints {
+		if (c.Type == ConstraintTypePrimaryKey || c.Type == ConstraintTypeUnique) && same(c.Targets) {
+			return true
+		}
+	}
+	for _, i := range t.Indices {
+		if !i.Unique || i.Where != "" {
+			continue
+		}
+		keys := []string{}
+		for _, k := range i.GetKeys() {
+			keys = append(keys, k.Column)
+		}
+		if same(keys) {
+			return true
+		}
+	}
+	return false
+}
+
+// validSequenceDefaults checks that the sequences column defaults take their
+// next value from are declared. Unqualified sequences resolve to the
+// namespace of the table.
+func (d *Database) validSequenceDefaults(v *validator) {
+	for _, n := range d.Namespaces {
+		for _, t := range n.Tables {
+			for i, c := range t.Columns {
+				match := nextvalRegex.FindStringSubmatch(strings.TrimSpace(c.Default))
+				if match == nil {
+					continue
+				}
+				schema, parts := n.Name, SplitQualified(match[1])
+				name := parts[len(parts)-1]
+				if len(parts) > 1 {
+					schema = parts[len(parts)-2]
+				}
+				if ns := d.getNamespace(schema); ns == nil || ns.getSequence(name) == nil {
+					path := fmt.Sprintf("%s.%s.columns[%d]", n.Name, t.Name, i)
+					v.check(path, fmt.Errorf("column %s takes its default from undeclared sequence %s.%s", c.Name, schema, name), c, t, n)
+				}
+			}
+		}
+	}
+}
+
+// Valid checks the namespace and returns every problem found as
+// ValidationErrors.
 func (n *Namespace) Valid() error {
+	v := &validator{}
+	n.validate(v)
+	return v.err()
+}
+
+func (n *Namespace) validate(v *validator) {
+	if n.Name == "" {
+		v.check(n.Name, fmt.Errorf("schema has no name"), n)
+	} else if tooLong(n.Name) {
+		v.check(n.Name, fmt.Errorf("schema name %s is too long", n.Name), n)
+	}
+
+	types := map[string]bool{}
+	for i, d := range n.Domains {
+		path := fmt.Sprintf("%s.domains[%d]", n.Name, i)
+		v.check(path, d.Valid(), d, n)
+		if types[d.Name] {
+			v.check(path, fmt.Errorf("type %s is declared twice in schema %s", d.Name, n.Name), d, n)
+		}
+		types[d.Name] = true
+	}
+
+	for i, c := range n.CompositeTypes {
+		path := fmt.Sprintf("%s.composite_types[%d]", n.Name, i)
+		v.check(path, c.Valid(), c, n)
+		if types[c.Name] {
+			v.check(path, fmt.Errorf("type %s is declared twice in schema %s", c.Name, n.Name), c, n)
+		}
+		types[c.Name] = true
+	}
+
 	for _, t := range n.Tables {
-		err := t.Valid()
-		if err != nil {
-			return err
+		t.validate(v, n.Name+"."+t.Name, n.Name, n.Domains, n)
+	}
+
+	for i, s := range n.Sequences {
+		v.check(fmt.Sprintf("%s.sequences[%d]", n.Name, i), s.Valid(), s, n)
+	}
+
+	n.validRelations(v)
+
+	for i, g := range n.Grants {
+		v.check(fmt.Sprintf("%s.grants[%d]", n.Name, i), validGrant("SCHEMA", g), g, n)
+	}
+
+	for i, f := range n.Functions {
+		v.check(fmt.Sprintf("%s.functions[%d]", n.Name, i), f.Valid(), f, n)
+	}
+
+	kinds := map[string]bool{}
+	for i, d := range n.DefaultPrivileges {
+		path := fmt.Sprintf("%s.default_privileges[%d]", n.Name, i)
+		v.check(path, d.Valid(), d, n)
+		key := d.Role + "." + d.GetOn()
+		if kinds[key] {
+			v.check(path, fmt.Errorf("default privileges on %s for role %s are declared twice", d.GetOn(), d.Role), d, n)
 		}
+		kinds[key] = true
 	}
+}
 
-	for _, s := range n.Sequences {
-		err := s.Valid()
-		if err != nil {
+func (d *Domain) Valid() error {
+	if d.Name == "" {
+		return fmt.Errorf("domain has no name")
+	} else if tooLong(d.Name) {
+		return fmt.Errorf("domain name %s is too long", d.Name)
+	} else if d.Type == "" {
+		return fmt.Errorf("domain %s has no type", d.Name)
+	}
+	if _, err := ParseType(d.Type); err != nil {
+		return fmt.Errorf("domain %s: %v", d.Name, err)
+	}
+
+	checks := map[string]bool{}
+	for _, c := range d.Checks {
+		if c.Name == "" {
+			return fmt.Errorf("domain %s has a check without a name", d.Name)
+		} else if c.Expression == "" {
+			return fmt.Errorf("check %s of domain %s has no expression", c.Name, d.Name)
+		} else if checks[c.Name] {
+			return fmt.Errorf("domain %s has check %s declared twice", d.Name, c.Name)
+		}
+		checks[c.Name] = true
+	}
+	return nil
+}
+
+func (t *CompositeType) Valid() error {
+	if t.Name == "" {
+		return fmt.Errorf("composite type has no name")
+	} else if tooLong(t.Name) {
+		return fmt.Errorf("composite type name %s is too long", t.Name)
+	} else if len(t.Attributes) == 0 {
+		return fmt.Errorf("composite type %s has no attributes", t.Name)
+	}
+
+	attributes := map[string]bool{}
+	for _, a := range t.Attributes {
+		if a.Name == "" {
+			return fmt.Errorf("composite type %s has an attribute without a name", t.Name)
+		} else if a.Type == "" {
+			return fmt.Errorf("attribute %s of composite type %s has no type", a.Name, t.Name)
+		} else if attributes[a.Name] {
+			return fmt.Errorf("composite type %s has attribute %s declared twice", t.Name, a.Name)
+		}
+		if _, err := ParseType(a.Type); err != nil {
+			return fmt.Errorf("composite type %s: %v", t.Name, err)
+		}
+		attributes[a.Name] = true
+	}
+	return nil
+}
+
+func (r *Role) Valid() error {
+	if r.Name == "" {
+		return fmt.Errorf("role has no name")
+	} else if tooLong(r.Name) {
+		return fmt.Errorf("role name %s is too long", r.Name)
+	} else if strings.EqualFold(r.Name, "PUBLIC") || strings.HasPrefix(r.Name, "pg_") {
+		return fmt.Errorf("role name %s is reserved", r.Name)
+	}
+	for _, m := range r.MemberOf {
+		if m == "" {
+			return fmt.Errorf("role %s is a member of a role without a name", r.Name)
+		} else if m == r.Name {
+			return fmt.Errorf("role %s cannot be a member of itself", r.Name)
+		}
+	}
+	return nil
+}
+
+func (f *Function) Valid() error {
+	if f.Name == "" {
+		return fmt.Errorf("function has no name")
+	} else if tooLong(f.Name) {
+		return fmt.Errorf("function name %s is too long", f.Name)
+	}
+	if err := validGrants("FUNCTION", f.Grants); err != nil {
+		return fmt.Errorf("function %s: %v", f.Name, err)
+	}
+	return nil
+}
+
+func (d *DefaultPrivilege) Valid() error {
+	if d.Role == "" {
+		return fmt.Errorf("default privileges have no role creating the objects")
+	}
+	if _, ok := privilegesByObject[d.GetOn()]; !ok || !strings.HasSuffix(d.GetOn(), "S") {
+		return fmt.Errorf("default privileges cannot be set on %s, expected one of %s", d.On, strings.Join(DefaultPrivilegeObjects(), ", "))
+	}
+	if err := validGrants(d.GetOn(), d.Grants); err != nil {
+		return fmt.Errorf("default privileges on %s for role %s: %v", d.GetOn(), d.Role, err)
+	}
+	return nil
+}
+
+// validRelations checks that the tables, partitions, sequences and indexes of
+// the namespace, including those backing constraints, have distinct names, as
+// PostgreSQL keeps them all in one namespace.
+func (n *Namespace) validRelations(v *validator) {
+	relations := map[string]string{}
+	claim := func(path, kind, name string, at ...interface{}) {
+		if other, ok := relations[name]; ok {
+			if other == kind {
+				v.check(path, fmt.Errorf("%s %s is declared twice in schema %s", kind, name, n.Name), at...)
+			} else {
+				v.check(path, fmt.Errorf("%s %s clashes with %s %s in schema %s", kind, name, other, name, n.Name), at...)
+			}
+			return
+		}
+		relations[name] = kind
+	}
+
+	for _, t := range n.Tables {
+		claim(n.Name+"."+t.Name, "table", t.Name, t, n)
+	}
+	for _, t := range n.Tables {
+		for i, p := range t.Partitions {
+			claim(fmt.Sprintf("%s.%s.partitions[%d]", n.Name, t.Name, i), "partition", p.Name, p, t, n)
+		}
+	}
+	for i, s := range n.Sequences {
+		claim(fmt.Sprintf("%s.sequences[%d]", n.Name, i), "sequence", s.Name, s, n)
+	}
+	for _, t := range n.Tables {
+		for i, idx := range t.Indices {
+			claim(fmt.Sprintf("%s.%s.indices[%d]", n.Name, t.Name, i), "index", idx.Name, idx, t, n)
+		}
+		for i, c := range t.Constraints {
+			if c.Type == ConstraintTypePrimaryKey || c.Type == ConstraintTypeUnique || c.Type == ConstraintTypeExclusion {
+				claim(fmt.Sprintf("%s.%s.constraints[%d]", n.Name, t.Name, i), "index", c.Name, c, t, n)
+			}
+		}
+	}
+}
+
+// validGrants checks that every grant names a role and only privileges that
+// exist for the kind of object it is listed on.
+func validGrants(objectType string, grants []*Grant) error {
+	for _, g := range grants {
+		if err := validGrant(objectType, g); err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+func validGrant(objectType string, g *Grant) error {
+	if g.Role == "" {
+		return fmt.Errorf("grant has no role")
+	} else if len(g.Privileges) == 0 {
+		return fmt.Errorf("grant to %s has no privileges", g.Role)
+	}
+	for _, p := range g.Privileges {
+		if len(ExpandPrivileges(objectType, []string{p})) == 0 {
+			return fmt.Errorf("privilege %s cannot be granted to %s on a %s", p, g.Role, strings.ToLower(strings.TrimSuffix(objectType, "S")))
+		}
+	}
+	return nil
+}
+
+func (e *Extension) Valid() error {
+	if e.Name == "" {
+		return fmt.Errorf("extension has no name")
+	} else if tooLong(e.Name) {
+		return fmt.Errorf("extension name %s is too long", e.Name)
+	} else if strings.Contains(e.Version, "'") {
+		return fmt.Errorf("extension %s has an invalid version %s", e.Name, e.Version)
+	}
+	return nil
+}
+
+func (p *Publication) Valid() error {
+	if p.Name == "" {
+		return fmt.Errorf("publication has no name")
+	} else if tooLong(p.Name) {
+		return fmt.Errorf("publication name %s is too long", p.Name)
+	}
+	if p.AllTables && len(p.Tables) > 0 {
+		return fmt.Errorf("publication %s publishes all tables and cannot list tables", p.Name)
+	}
+	for _, op := range p.Publish {
+		valid := false
+		for _, known := range PublicationOperations() {
+			valid = valid || strings.EqualFold(strings.TrimSpace(op), known)
+		}
+		if !valid {
+			return fmt.Errorf("publication %s publishes an invalid operation %s, expected one of %s", p.Name, op, strings.Join(PublicationOperations(), ", "))
+		}
+	}
+	tables := map[string]bool{}
+	for _, t := range p.Tables {
+		if !publicationTableRegex.MatchString(t.Table) {
+			return fmt.Errorf("publication %s lists an invalid table %s, expected schema.table", p.Name, t.Table)
+		}
+		if tables[t.Table] {
+			return fmt.Errorf("publication %s lists table %s twice", p.Name, t.Table)
+		}
+		tables[t.Table] = true
+	}
+	return nil
+}
 
+func (s *Subscription) Valid() error {
+	if s.Name == "" {
+		return fmt.Errorf("subscription has no name")
+	} else if tooLong(s.Name) {
+		return fmt.Errorf("subscription name %s is too long", s.Name)
+	} else if tooLong(s.SlotName) {
+		return fmt.Errorf("subscription %s has a slot name %s that is too long", s.Name, s.SlotName)
+	}
+	if strings.TrimSpace(s.Connection) == "" {
+		return fmt.Errorf("subscription %s has no connection", s.Name)
+	} else if ConnectionHasPassword(s.Connection) {
+		return fmt.Errorf("subscription %s has a password in its connection, use a password file on the subscriber instead", s.Name)
+	}
+	if len(s.Publications) == 0 {
+		return fmt.Errorf("subscription %s subscribes to no publication", s.Name)
+	}
+	publications := map[string]bool{}
+	for _, p := range s.Publications {
+		if p == "" {
+			return fmt.Errorf("subscription %s lists a publication without a name", s.Name)
+		} else if publications[p] {
+			return fmt.Errorf("subscription %s lists publication %s twice", s.Name, p)
+		}
+		publications[p] = true
+	}
 	return nil
 }
 
 func (s *Sequence) Valid() error {
 	if s.Name == "" {
 		return fmt.Errorf("sequence has no name")
-	} else if len(s.Name) > 63 {
+	} else if tooLong(s.Name) {
 		return fmt.Errorf("sequence name %s is too long", s.Name)
-	} else if s.Type != "bigint" && s.Type != "integer" {
+	} else if s.Type != "bigint" && s.Type != "integer" && s.Type != "smallint" {
 		return fmt.Errorf("sequence type %s is not supported", s.Type)
+	} else if s.OwnedBy != "" && !sequenceOwnerRegex.MatchString(s.OwnedBy) {
+		return fmt.Errorf("sequence %s has an invalid owner %s, expected table.column", s.Name, s.OwnedBy)
+	}
+	if err := s.SequenceOptions.valid(s.Type); err != nil {
+		return fmt.Errorf("sequence %s: %v", s.Name, err)
+	}
+	if err := validGrants("SEQUENCE", s.Grants); err != nil {
+		return fmt.Errorf("sequence %s: %v", s.Name, err)
 	}
 	return nil
 }
 
-func (t *Table) Valid() error {
+// Valid checks the table, with its columns checked against the given domains
+// of its namespace, and returns every problem found as ValidationErrors.
+func (t *Table) Valid(domains ...*Domain) error {
+	v := &validator{}
+	t.validate(v, t.Name, "", domains)
+	return v.err()
+}
+
+// validate checks the table at path, its columns against the domains of the
+// namespace it is in. The objects it is declared in locate the problems of
+// columns that were copied from a template.
+func (t *Table) validate(v *validator, path, namespace string, domains []*Domain, in ...interface{}) {
+	at := append([]interface{}{t}, in...)
 	if t.Name == "" {
-		return fmt.Errorf("table has no name")
-	} else if len(t.Name) > 63 {
-		return fmt.Errorf("table name %s is too long", t.Name)
+		v.check(path, fmt.Errorf("table has no name"), at...)
+	} else if tooLong(t.Name) {
+		v.check(path, fmt.Errorf("table name %s is too long", t.Name), at...)
 	}
 
-	for _, c := range t.Columns {
+	columns := map[string]bool{}
+	for i, c := range t.Columns {
+		columnPath := fmt.Sprintf("%s.columns[%d]", path, i)
+		v.check(columnPath, c.valid(namespace, domains), append([]interface{}{c}, at...)...)
+		if columns[c.Name] {
+			v.check(columnPath, fmt.Errorf("table %s has column %s declared twice", t.Name, c.Name), append([]interface{}{c}, at...)...)
+		}
+		columns[c.Name] = true
+	}
+
+	constraints := map[string]bool{}
+	for i, c := range t.Constraints {
+		constraintPath := fmt.Sprintf("%s.constraints[%d]", path, i)
 		err := c.Valid()
-		if err != nil {
-			return err
+		if err == nil {
+			err = t.validTargets(c)
+		}
+		v.check(constraintPath, err, append([]interface{}{c}, at...)...)
+		if constraints[c.Name] {
+			v.check(constraintPath, fmt.Errorf("table %s has constraint %s declared twice", t.Name, c.Name), append([]interface{}{c}, at...)...)
+		}
+		constraints[c.Name] = true
+	}
+
+	for i, idx := range t.Indices {
+		err := idx.Valid()
+		if err == nil {
+			err = t.validIndexColumns(idx)
+		}
+		v.check(fmt.Sprintf("%s.indices[%d]", path, i), err, append([]interface{}{idx}, at...)...)
+	}
+
+	v.check(path, t.validPrimaryKey(), at...)
+
+	for i, g := range t.Grants {
+		v.check(fmt.Sprintf("%s.grants[%d]", path, i), validGrant("TABLE", g), append([]interface{}{g}, at...)...)
+	}
+
+	switch t.GetRowLevelSecurity() {
+	case RowLevelSecurityDisabled, RowLevelSecurityEnabled, RowLevelSecurityForced:
+	default:
+		v.check(path, fmt.Errorf("table %s has an invalid row level security mode %s, expected enabled, forced or disabled", t.Name, t.RowLevelSecurity), at...)
+	}
+
+	policies := map[string]bool{}
+	for i, p := range t.Policies {
+		policyPath := fmt.Sprintf("%s.policies[%d]", path, i)
+		v.check(policyPath, p.Valid(), append([]interface{}{p}, at...)...)
+		if policies[p.Name] {
+			v.check(policyPath, fmt.Errorf("table %s has policy %s declared twice", t.Name, p.Name), append([]interface{}{p}, at...)...)
+		}
+		policies[p.Name] = true
+	}
+
+	v.check(path, t.validStorage(), at...)
+	v.check(path, t.validPartitions(), at...)
+}
+
+// validStorage checks the storage parameters of the table. Partitioned tables
+// hold no rows themselves, so they cannot be unlogged or take parameters.
+func (t *Table) validStorage() error {
+	for name, value := range t.With {
+		if !storageParameterRegex.MatchString(strings.ToLower(name)) {
+			return fmt.Errorf("table %s has an invalid storage parameter %s", t.Name, name)
+		}
+		if value == "" {
+			return fmt.Errorf("table %s has storage parameter %s without a value", t.Name, name)
+		}
+	}
+	if t.PartitionBy != nil && len(t.With) > 0 {
+		return fmt.Errorf("partitioned table %s cannot have storage parameters, and storage parameters of partitions are not supported", t.Name)
+	}
+	if t.PartitionBy != nil && t.Unlogged {
+		return fmt.Errorf("partitioned table %s cannot be unlogged", t.Name)
+	}
+	return nil
+}
+
+// validPartitions checks the partition key and the bounds of every partition
+// against the partitioning strategy. Primary keys and unique constraints of a
+// partitioned table must include the key columns.
+func (t *Table) validPartitions() error {
+	if t.PartitionBy == nil {
+		if len(t.Partitions) > 0 {
+			return fmt.Errorf("table %s has partitions but no partition_by", t.Name)
+		}
+		return nil
+	}
+
+	known := false
+	for _, strategy := range PartitionStrategies() {
+		if t.PartitionBy.GetStrategy() == strategy {
+			known = true
+		}
+	}
+	if !known {
+		return fmt.Errorf("table %s has an invalid partition strategy %s", t.Name, t.PartitionBy.Strategy)
+	} else if len(t.PartitionBy.Columns) == 0 {
+		return fmt.Errorf("table %s is partitioned without key columns", t.Name)
+	} else if t.PartitionBy.GetStrategy() == PartitionStrategyList && len(t.PartitionBy.Columns) > 1 {
+		return fmt.Errorf("table %s is list partitioned by more than one column", t.Name)
+	}
+	for _, col := range t.PartitionBy.Columns {
+		if t.getColumn(col) == nil {
+			return fmt.Errorf("table %s is partitioned by unknown column %s", t.Name, col)
+		}
+		for _, c := range t.Constraints {
+			if c.Type != ConstraintTypePrimaryKey && c.Type != ConstraintTypeUnique {
+				continue
+			}
+			found := false
+			for _, target := range c.Targets {
+				if target == col {
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("constraint %s on partitioned table %s must include partition column %s", c.Name, t.Name, col)
+			}
+		}
+	}
+
+	names := map[string]bool{}
+	defaults := 0
+	keyword := t.PartitionBy.boundsKeyword()
+	for _, p := range t.Partitions {
+		if p.Name == "" {
+			return fmt.Errorf("partition of table %s has no name", t.Name)
+		} else if tooLong(p.Name) {
+			return fmt.Errorf("partition name %s is too long", p.Name)
+		} else if names[p.Name] {
+			return fmt.Errorf("table %s has partition %s declared twice", t.Name, p.Name)
+		}
+		names[p.Name] = true
+
+		if p.Default {
+			defaults++
+			if p.Values != "" {
+				return fmt.Errorf("default partition %s cannot have values", p.Name)
+			} else if t.PartitionBy.GetStrategy() == PartitionStrategyHash {
+				return fmt.Errorf("hash partitioned table %s cannot have default partition %s", t.Name, p.Name)
+			}
+			continue
+		}
+
+		values := strings.ToUpper(strings.TrimSpace(p.Values))
+		if values == "" {
+			return fmt.Errorf("partition %s has no values", p.Name)
+		}
+		rest := strings.TrimPrefix(values, keyword)
+		if rest == values || !strings.HasPrefix(strings.TrimSpace(rest), "(") {
+			return fmt.Errorf("partition %s of %s partitioned table %s must have values starting with %s", p.Name, t.PartitionBy.GetStrategy(), t.Name, keyword)
+		}
+	}
+	if defaults > 1 {
+		return fmt.Errorf("table %s has more than one default partition", t.Name)
+	}
+	return nil
+}
+
+func (p *Policy) Valid() error {
+	if p.Name == "" {
+		return fmt.Errorf("policy has no name")
+	} else if tooLong(p.Name) {
+		return fmt.Errorf("policy name %s is too long", p.Name)
+	}
+
+	known := false
+	for _, command := range PolicyCommands() {
+		if p.GetCommand() == command {
+			known = true
+		}
+	}
+	if !known {
+		return fmt.Errorf("policy %s has an invalid command %s", p.Name, p.Command)
+	}
+
+	if p.GetCommand() == PolicyCommandInsert && p.Using != "" {
+		return fmt.Errorf("policy %s for INSERT can only have a with_check expression", p.Name)
+	}
+	if (p.GetCommand() == PolicyCommandSelect || p.GetCommand() == PolicyCommandDelete) && p.WithCheck != "" {
+		return fmt.Errorf("policy %s for %s can only have a using expression", p.Name, p.GetCommand())
+	}
+	for _, role := range p.Roles {
+		if role == "" {
+			return fmt.Errorf("policy %s applies to a role without a name", p.Name)
+		}
+	}
+	return nil
+}
+
+// validTargets checks that the constraint only targets columns of the table.
+// Check constraints hold an expression instead and are not checked.
+func (t *Table) validTargets(c *Constraint) error {
+	columns := []string{}
+	if c.Type != ConstraintTypeCheck {
+		columns = append(columns, c.Targets...)
+	}
+	for _, e := range c.Exclusions {
+		if e.Column != "" {
+			columns = append(columns, e.Column)
+		}
+	}
+	for _, col := range columns {
+		if t.getColumn(col) == nil {
+			return fmt.Errorf("constraint %s on table %s targets unknown column %s", c.Name, t.Name, col)
+		}
+	}
+	return nil
+}
+
+// validPrimaryKey checks that the columns marked primary_key, if any, are
+// those of the PRIMARY KEY constraint of the table, when it has one.
+func (t *Table) validPrimaryKey() error {
+	var key *Constraint
+	for _, c := range t.Constraints {
+		if c.Type != ConstraintTypePrimaryKey {
+			continue
+		}
+		if key != nil {
+			return fmt.Errorf("table %s has more than one primary key", t.Name)
+		}
+		key = c
+	}
+
+	marked := []string{}
+	for _, c := range t.Columns {
+		if c.IsPrimaryKey {
+			marked = append(marked, c.Name)
+		}
+	}
+	if len(marked) == 0 || key == nil {
+		return nil
+	}
+	for _, col := range marked {
+		found := false
+		for _, target := range key.Targets {
+			found = found || target == col
+		}
+		if !found || len(marked) != len(key.Targets) {
+			return fmt.Errorf("table %s has columns %s marked primary_key, but its primary key %s is on %s", t.Name, strings.Join(marked, ", "), key.Name, strings.Join(key.Targets, ", "))
+		}
+	}
+	return nil
+}
+
+// validIndexColumns checks the index keys against the columns of the table.
+// GIN indexes need an operator class unless the column type has a default
+// one.
+func (t *Table) validIndexColumns(i *Index) error {
+	columns := append([]string{}, i.Include...)
+	for _, k := range i.GetKeys() {
+		if k.Column != "" {
+			columns = append(columns, k.Column)
+		}
+	}
+	for _, col := range columns {
+		if t.getColumn(col) == nil {
+			return fmt.Errorf("index %s on table %s uses unknown column %s", i.Name, t.Name, col)
+		}
+	}
+
+	if i.GetAlgorithm() != IndexAlgorithmGIN {
+		return nil
+	}
+	for _, k := range i.GetKeys() {
+		if k.Column == "" || k.Opclass != "" {
+			continue
+		}
+		for _, c := range t.Columns {
+			if c.Name != k.Column {
+				continue
+			}
+			parsed, err := c.ColumnType()
+			if err != nil {
+				return err
+			}
+			if parsed.ArrayDimensions == 0 && !ginTypes[parsed.Name] {
+				return fmt.Errorf("gin index %s needs an opclass such as gin_trgm_ops for column %s of type %s", i.Name, c.Name, c.Type)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Constraint) Valid() error {
+	if c.Name == "" {
+		return fmt.Errorf("constraint has no name")
+	} else if tooLong(c.Name) {
+		return fmt.Errorf("constraint name %s is too long", c.Name)
+	} else if c.InitiallyDeferred && !c.Deferrable {
+		return fmt.Errorf("constraint %s is initially deferred but not deferrable", c.Name)
+	} else if c.Deferrable && c.Type == ConstraintTypeCheck {
+		return fmt.Errorf("check constraint %s cannot be deferrable", c.Name)
+	} else if c.NotValid && c.Type != ConstraintTypeForeignKey && c.Type != ConstraintTypeCheck {
+		return fmt.Errorf("constraint %s cannot be not valid, only foreign key and check constraints can", c.Name)
+	} else if c.MatchFull && c.Type != ConstraintTypeForeignKey {
+		return fmt.Errorf("constraint %s can only match full as a foreign key", c.Name)
+	}
+
+	if c.Type != ConstraintTypeExclusion {
+		if len(c.Exclusions) > 0 || c.Using != "" || c.Where != "" {
+			return fmt.Errorf("constraint %s has exclusion options but is not an exclusion constraint", c.Name)
+		}
+		return nil
+	}
+
+	switch c.GetUsing() {
+	case IndexAlgorithmGiST, IndexAlgorithmSPGiST, IndexAlgorithmBTree, IndexAlgorithmHash:
+	default:
+		return fmt.Errorf("exclusion constraint %s cannot use the %s algorithm", c.Name, c.Using)
+	}
+	if len(c.Exclusions) == 0 {
+		return fmt.Errorf("exclusion constraint %s has no elements", c.Name)
+	}
+	for _, e := range c.Exclusions {
+		if (e.Column == "") == (e.Expression == "") {
+			return fmt.Errorf("exclusion constraint %s has an element that is not exactly one of a column or an expression", c.Name)
+		} else if e.Operator == "" {
+			return fmt.Errorf("exclusion constraint %s has an element without operator", c.Name)
+		}
+	}
+	return nil
+}
+
+func (i *Index) Valid() error {
+	if i.Name == "" {
+		return fmt.Errorf("index has no name")
+	} else if tooLong(i.Name) {
+		return fmt.Errorf("index name %s is too long", i.Name)
+	} else if len(i.Columns) > 0 && len(i.Keys) > 0 {
+		return fmt.Errorf("index %s has both columns and keys", i.Name)
+	} else if len(i.GetKeys()) == 0 {
+		return fmt.Errorf("index %s has no columns", i.Name)
+	}
+
+	algorithm := i.GetAlgorithm()
+	known := false
+	for _, a := range IndexAlgorithms() {
+		if a == algorithm {
+			known = true
+		}
+	}
+	if !known {
+		return fmt.Errorf("index %s has an unsupported algorithm %s", i.Name, i.Algorithm)
+	} else if i.Unique && algorithm != IndexAlgorithmBTree {
+		return fmt.Errorf("index %s can only be unique with the btree algorithm", i.Name)
+	} else if algorithm == IndexAlgorithmHash && len(i.GetKeys()) > 1 {
+		return fmt.Errorf("hash index %s can only have a single key", i.Name)
+	} else if len(i.Include) > 0 && algorithm != IndexAlgorithmBTree && algorithm != IndexAlgorithmGiST && algorithm != IndexAlgorithmSPGiST {
+		return fmt.Errorf("%s index %s does not support include columns", algorithm, i.Name)
+	}
+
+	for _, k := range i.Keys {
+		if (k.Column == "") == (k.Expression == "") {
+			return fmt.Errorf("index %s has a key that is not exactly one of a column or an expression", i.Name)
+		}
+		if order := k.GetOrder(); order != IndexOrderAsc && order != IndexOrderDesc {
+			return fmt.Errorf("index %s has an invalid sort order %s", i.Name, k.Order)
+		}
+		if nulls := k.GetNulls(); nulls != IndexNullsFirst && nulls != IndexNullsLast {
+			return fmt.Errorf("index %s has an invalid nulls order %s", i.Name, k.Nulls)
+		}
+		if (k.Order != "" || k.Nulls != "") && algorithm != IndexAlgorithmBTree {
+			return fmt.Errorf("%s index %s does not support sort orders", algorithm, i.Name)
+		}
+		if algorithms, ok := opclassAlgorithms[strings.ToLower(k.Opclass)]; ok {
+			supported := false
+			for _, a := range algorithms {
+				if a == algorithm {
+					supported = true
+				}
+			}
+			if !supported {
+				return fmt.Errorf("opclass %s of index %s cannot be used with the %s algorithm", k.Opclass, i.Name, algorithm)
+			}
 		}
 	}
 	return nil
 }
 
 // Returns an error if the column is not valid
-func (c *Column) Valid() error {
+// Valid checks the column. When the column uses one of the given domains of
+// its namespace, the default and NOT NULL of the domain apply to it as well.
+func (c *Column) Valid(domains ...*Domain) error {
+	return c.valid("", domains)
+}
+
+// valid checks the column, resolving its type against the domains of the
+// named namespace.
+func (c *Column) valid(namespace string, domains []*Domain) error {
 	if c.Name == "" {
 		return fmt.Errorf("column has no name")
+	} else if tooLong(c.Name) {
+		return fmt.Errorf("column name %s is too long", c.Name)
 	}
 	if c.Type == "" {
 		return fmt.Errorf("column %s has no type", c.Name)
 	}
-	if strings.ToUpper(c.Type) == "CHARACTER VARYING" && c.MaxLength == 0 {
-		return fmt.Errorf("column %s is of type %s but has no max length", c.Name, c.Type)
+	parsed, err := c.ColumnType()
+	if err != nil {
+		return err
 	}
-	if strings.ToUpper(c.Type) != "CHARACTER VARYING" && c.MaxLength > 0 {
-		return fmt.Errorf("column %s is of type %s but has a max length", c.Name, c.Type)
+	if err := c.validModifiers(parsed); err != nil {
+		return err
 	}
-	if !c.Nullable && c.Default == "" && !c.IsPrimaryKey {
+	if c.Identity != nil {
+		if err := c.validIdentity(parsed); err != nil {
+			return err
+		}
+	}
+	if c.Generated != "" && c.Default != "" {
+		return fmt.Errorf("column %s is generated and cannot have a default value", c.Name)
+	}
+	if parsed.Serial && (c.Identity != nil || c.Generated != "") {
+		return fmt.Errorf("column %s is of type %s and cannot be an identity or generated column", c.Name, c.Type)
+	}
+	if c.GetCollation() != "" && !parsed.IsCollatable() {
+		return fmt.Errorf("column %s is of type %s, which takes no collation", c.Name, c.Type)
+	}
+	for _, d := range domains {
+		if !d.usedBy(namespace, parsed) {
+			continue
+		}
+		if d.NotNull && c.Default == "" && d.Default == "" && c.Identity == nil && c.Generated == "" && !c.IsPrimaryKey {
+			return fmt.Errorf("column %s uses not null domain %s and has no default value", c.Name, d.Name)
+		}
+		if d.Default != "" {
+			return nil
+		}
+	}
+	if !c.Nullable && c.Default == "" && c.Identity == nil && c.Generated == "" && !parsed.Serial && !c.IsPrimaryKey {
 		return fmt.Errorf("column %s is not nullable and has no default value", c.Name)
 	}
 	return nil
 }
+
+func (c *Column) validModifiers(parsed *ColumnType) error {
+	if !parsed.HasLength() && parsed.MaxLength > 0 {
+		return fmt.Errorf("column %s is of type %s but has a max length", c.Name, c.Type)
+	}
+	if parsed.Name == "CHARACTER VARYING" && parsed.MaxLength == 0 {
+		return fmt.Errorf("column %s is of type %s but has no max length", c.Name, c.Type)
+	}
+	if parsed.MaxLength < 0 {
+		return fmt.Errorf("column %s has a negative max length", c.Name)
+	}
+
+	switch {
+	case parsed.Name == "NUMERIC":
+		if parsed.Scale != 0 && parsed.Precision == nil {
+			return fmt.Errorf("column %s has a scale but no precision", c.Name)
+		}
+		if parsed.Precision != nil && (*parsed.Precision < 1 || *parsed.Precision > 1000) {
+			return fmt.Errorf("column %s has precision %d, which is not between 1 and 1000", c.Name, *parsed.Precision)
+		}
+		if parsed.Precision != nil && (parsed.Scale < 0 || parsed.Scale > *parsed.Precision) {
+			return fmt.Errorf("column %s has scale %d, which is not between 0 and its precision %d", c.Name, parsed.Scale, *parsed.Precision)
+		}
+	case strings.HasPrefix(parsed.Name, "TIME") || strings.HasPrefix(parsed.Name, "INTERVAL"):
+		if parsed.Precision != nil && (*parsed.Precision < 0 || *parsed.Precision > 6) {
+			return fmt.Errorf("column %s has precision %d, which is not between 0 and 6", c.Name, *parsed.Precision)
+		}
+		if parsed.Scale != 0 {
+			return fmt.Errorf("column %s is of type %s but has a scale", c.Name, c.Type)
+		}
+	default:
+		if parsed.Precision != nil || parsed.Scale != 0 {
+			return fmt.Errorf("column %s is of type %s but has a precision or scale", c.Name, c.Type)
+		}
+	}
+
+	if parsed.ArrayDimensions < 0 {
+		return fmt.Errorf("column %s has negative array dimensions", c.Name)
+	}
+	return nil
+}
+
+func (c *Column) validIdentity(parsed *ColumnType) error {
+	if !parsed.IsInteger() {
+		return fmt.Errorf("column %s is of type %s and cannot be an identity column", c.Name, c.Type)
+	} else if parsed.ArrayDimensions > 0 {
+		return fmt.Errorf("column %s is an array and cannot be an identity column", c.Name)
+	}
+
+	switch c.Identity.GetGeneration() {
+	case IdentityGenerationAlways, IdentityGenerationByDefault:
+	default:
+		return fmt.Errorf("column %s has unsupported identity generation %s", c.Name, c.Identity.Generation)
+	}
+
+	if c.Default != "" {
+		return fmt.Errorf("column %s is an identity column and cannot have a default value", c.Name)
+	} else if c.Generated != "" {
+		return fmt.Errorf("column %s cannot be both an identity and a generated column", c.Name)
+	} else if c.Nullable {
+		return fmt.Errorf("column %s is an identity column and cannot be nullable", c.Name)
+	}
+
+	if err := c.Identity.SequenceOptions.valid(parsed.Name); err != nil {
+		return fmt.Errorf("column %s has invalid identity options: %v", c.Name, err)
+	}
+	return nil
+}
+
+func (o SequenceOptions) valid(dataType string) error {
+	effective := o.Effective(dataType)
+	typeMin, typeMax := sequenceTypeBounds(dataType)
+
+	if o.Cache < 0 {
+		return fmt.Errorf("cache %d must be positive", o.Cache)
+	} else if *effective.MinValue >= *effective.MaxValue {
+		return fmt.Errorf("min value %d must be less than max value %d", *effective.MinValue, *effective.MaxValue)
+	} else if *effective.MinValue < typeMin || *effective.MaxValue > typeMax {
+		return fmt.Errorf("range %d..%d is out of bounds for type %s", *effective.MinValue, *effective.MaxValue, dataType)
+	} else if *effective.Start < *effective.MinValue || *effective.Start > *effective.MaxValue {
+		return fmt.Errorf("start value %d is outside of range %d..%d", *effective.Start, *effective.MinValue, *effective.MaxValue)
+	}
+	return nil
+}
+
+// baseType resolves a type naming a domain, bare in the given namespace or
+// qualified, to the base type of the domain, following domains over domains.
+// Other types are returned as is.
+func (d *Database) baseType(namespace string, parsed *ColumnType) *ColumnType {
+	seen := map[*Domain]bool{}
+	for {
+		domain, domainNamespace := d.getDomain(namespace, parsed)
+		if domain == nil || seen[domain] {
+			return parsed
+		}
+		seen[domain] = true
+		base, err := ParseType(domain.Type)
+		if err != nil {
+			return parsed
+		}
+		parsed, namespace = base, domainNamespace
+	}
+}
+
+// getDomain returns the domain a type names, and the namespace it is declared
+// in. A bare name only resolves within the given namespace.
+func (d *Database) getDomain(namespace string, parsed *ColumnType) (*Domain, string) {
+	qualified := len(SplitQualified(parsed.Name)) == 2
+	for _, n := range d.Namespaces {
+		if !qualified && n.Name != namespace {
+			continue
+		}
+		for _, domain := range n.Domains {
+			if domain.usedBy(n.Name, parsed) {
+				return domain, n.Name
+			}
+		}
+	}
+	return nil, ""
+}
+
+func (d *Database) getTable(namespace, name string) *Table {
+	for _, n := range d.Namespaces {
+		if n.Name != namespace {
+			continue
+		}
+		for _, t := range n.Tables {
+			if t.Name == name {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Database) getNamespace(name string) *Namespace {
+	for _, n := range d.Namespaces {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+func (t *Table) getColumn(name string) *Column {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package state
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"stijntratsaertit/terramigrate/objects"
 )
@@ -9,11 +11,20 @@ import (
 type Migrator struct {
 	existing *objects.Namespace
 	desired  *objects.Namespace
+	database string
 	actions  []string
+	errs     []error
 	locked   bool
 }
 
 func (m *Migrator) String() string {
+	if m.existing == nil && m.desired == nil && m.database != "" {
+		if len(m.actions) == 0 {
+			return fmt.Sprintf("No actions required for database %s", m.database)
+		}
+		return fmt.Sprintf("Migrating database %s (%d actions)", m.database, len(m.actions))
+	}
+
 	if len(m.actions) == 0 {
 		name := "unknown"
 		if m.existing != nil {
@@ -46,6 +57,12 @@ func (m *Migrator) GetDesired() *objects.Namespace {
 	return m.desired
 }
 
+// Err returns the reasons the desired state cannot be reached by altering the
+// existing objects in place, or nil when every change could be planned.
+func (m *Migrator) Err() error {
+	return errors.Join(m.errs...)
+}
+
 func (m *Migrator) IsLocked() bool {
 	return m.locked
 }
@@ -57,12 +74,26 @@ func (m *Migrator) namespaceName() string {
 	return m.existing.Name
 }
 
-func (m *Migrator) compareSequences() (diff []string) {
+// qualify returns the name of an object in the namespace as written in SQL.
+func (m *Migrator) qualify(name string) string {
+	return objects.QualifiedName(m.namespaceName(), name)
+}
+
+// compareSequences diffs the sequences of the namespace. Sequences are created
+// and altered before any table change, as column defaults may rely on them.
+// Ownership is assigned and unwanted sequences dropped afterwards, once the
+// owning columns exist and no default uses the sequence anymore.
+func (m *Migrator) compareSequences() (diff, after []string) {
 	nsName := m.namespaceName()
 
 	if m.existing == nil || len(m.existing.Sequences) == 0 {
 		for _, sequence := range m.desired.Sequences {
-			diff = append(diff, fmt.Sprintf("CREATE SEQUENCE %s.%s;", nsName, sequence.Name))
+			diff = append(diff, sequence.CreateSQL(nsName))
+			diff = append(diff, commentAction("SEQUENCE "+m.qualify(sequence.Name), nil, sequence.Comment)...)
+			diff = append(diff, grantActions("", "SEQUENCE", "SEQUENCE "+m.qualify(sequence.Name), nil, sequence.Grants)...)
+			if sequence.OwnedBy != "" {
+				after = append(after, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s;", m.qualify(sequence.Name), sequence.OwnedBySQL(nsName)))
+			}
 		}
 		return
 	}
@@ -73,13 +104,26 @@ func (m *Migrator) compareSequences() (diff []string) {
 			if desiredSeq.Name == existingSeq.Name {
 				found = true
 				if desiredSeq.Type != existingSeq.Type {
-					diff = append(diff, fmt.Sprintf("ALTER SEQUENCE %s.%s AS %s;", nsName, existingSeq.Name, desiredSeq.Type))
+					diff = append(diff, fmt.Sprintf("ALTER SEQUENCE %s AS %s;", m.qualify(existingSeq.Name), desiredSeq.Type))
+				}
+				if clauses := desiredSeq.ChangedClauses(existingSeq.SequenceOptions, desiredSeq.Type); len(clauses) > 0 {
+					diff = append(diff, fmt.Sprintf("ALTER SEQUENCE %s %s;", m.qualify(existingSeq.Name), strings.Join(clauses, " ")))
+				}
+				diff = append(diff, commentAction("SEQUENCE "+m.qualify(existingSeq.Name), existingSeq.Comment, desiredSeq.Comment)...)
+				diff = append(diff, grantActions("", "SEQUENCE", "SEQUENCE "+m.qualify(existingSeq.Name), existingSeq.Grants, desiredSeq.Grants)...)
+				if desiredSeq.OwnedBySQL(nsName) != existingSeq.OwnedBySQL(nsName) {
+					if existingSeq.OwnedBy != "" {
+						diff = append(diff, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY NONE;", m.qualify(existingSeq.Name)))
+					}
+					if desiredSeq.OwnedBy != "" {
+						after = append(after, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s;", m.qualify(existingSeq.Name), desiredSeq.OwnedBySQL(nsName)))
+					}
 				}
 				break
 			}
 		}
-		if !found {
-			diff = append(diff, fmt.Sprintf("DROP SEQUENCE %s.%s;", nsName, existingSeq.Name))
+		if !found && !m.droppedWithOwner(existingSeq) {
+			after = append(after, fmt.Sprintf("DROP SEQUENCE %s;", m.qualify(existingSeq.Name)))
 		}
 	}
 
@@ -92,23 +136,177 @@ func (m *Migrator) compareSequences() (diff []string) {
 			}
 		}
 		if !found {
-			diff = append(diff, fmt.Sprintf("CREATE SEQUENCE %s.%s;", nsName, desiredSeq.Name))
+			diff = append(diff, desiredSeq.CreateSQL(nsName))
+			diff = append(diff, commentAction("SEQUENCE "+m.qualify(desiredSeq.Name), nil, desiredSeq.Comment)...)
+			diff = append(diff, grantActions("", "SEQUENCE", "SEQUENCE "+m.qualify(desiredSeq.Name), nil, desiredSeq.Grants)...)
+			if desiredSeq.OwnedBy != "" {
+				after = append(after, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s;", m.qualify(desiredSeq.Name), desiredSeq.OwnedBySQL(nsName)))
+			}
 		}
 	}
 
 	return
 }
 
+// compareTypes diffs the domains and composite types of the namespace. They
+// are created and altered before any table change, as columns may use them,
+// and dropped afterwards, composite types before the domains they may use.
+func (m *Migrator) compareTypes() (diff, drop []string) {
+	nsName := m.namespaceName()
+
+	existingDomains := []*objects.Domain{}
+	existingTypes := []*objects.CompositeType{}
+	if m.existing != nil {
+		existingDomains = m.existing.Domains
+		existingTypes = m.existing.CompositeTypes
+	}
+
+	for _, desiredDom := range m.desired.Domains {
+		var existingDom *objects.Domain
+		for _, d := range existingDomains {
+			if d.Name == desiredDom.Name {
+				existingDom = d
+				break
+			}
+		}
+		target := "DOMAIN " + m.qualify(desiredDom.Name)
+		if existingDom == nil {
+			diff = append(diff, desiredDom.CreateSQL(nsName))
+			diff = append(diff, commentAction(target, nil, desiredDom.Comment)...)
+			continue
+		}
+		diff = append(diff, m.compareDomain(existingDom, desiredDom)...)
+		diff = append(diff, commentAction(target, existingDom.Comment, desiredDom.Comment)...)
+	}
+
+	for _, desiredType := range m.desired.CompositeTypes {
+		var existingType *objects.CompositeType
+		for _, t := range existingTypes {
+			if t.Name == desiredType.Name {
+				existingType = t
+				break
+			}
+		}
+		target := "TYPE " + m.qualify(desiredType.Name)
+		if existingType == nil {
+			diff = append(diff, desiredType.CreateSQL(nsName))
+			diff = append(diff, commentAction(target, nil, desiredType.Comment)...)
+			continue
+		}
+		diff = append(diff, compareCompositeType(nsName, existingType, desiredType)...)
+		diff = append(diff, commentAction(target, existingType.Comment, desiredType.Comment)...)
+	}
+
+	for _, existingType := range existingTypes {
+		found := false
+		for _, t := range m.desired.CompositeTypes {
+			if t.Name == existingType.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			drop = append(drop, fmt.Sprintf("DROP TYPE %s;", m.qualify(existingType.Name)))
+		}
+	}
+
+	for _, existingDom := range existingDomains {
+		found := false
+		for _, d := range m.desired.Domains {
+			if d.Name == existingDom.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			drop = append(drop, fmt.Sprintf("DROP DOMAIN %s;", m.qualify(existingDom.Name)))
+		}
+	}
+
+	return
+}
+
+// compareDomain alters the default, NOT NULL and checks of a domain. Checks
+// cannot be altered, so changed ones are dropped and added again.
+func (m *Migrator) compareDomain(existing, desired *objects.Domain) []string {
+	diff := []string{}
+	name := m.qualify(desired.Name)
+
+	if existing.TypeSQL() != desired.TypeSQL() {
+		m.errs = append(m.errs, fmt.Errorf("the type of domain %s cannot be changed in place, create a new domain instead", name))
+		return diff
+	}
+
+	if !objects.ExpressionsEqualIn(m.namespaceName(), existing.Default, desired.Default) {
+		if desired.Default == "" {
+			diff = append(diff, fmt.Sprintf("ALTER DOMAIN %s DROP DEFAULT;", name))
+		} else {
+			diff = append(diff, fmt.Sprintf("ALTER DOMAIN %s SET DEFAULT %s;", name, desired.Default))
+		}
+	}
+	if existing.NotNull != desired.NotNull {
+		if desired.NotNull {
+			diff = append(diff, fmt.Sprintf("ALTER DOMAIN %s SET NOT NULL;", name))
+		} else {
+			diff = append(diff, fmt.Sprintf("ALTER DOMAIN %s DROP NOT NULL;", name))
+		}
+	}
+
+	for _, existingCheck := range existing.Checks {
+		desiredCheck := desired.GetCheck(existingCheck.Name)
+		if desiredCheck == nil || !objects.ExpressionsEqual(existingCheck.Expression, desiredCheck.Expression) {
+			diff = append(diff, fmt.Sprintf("ALTER DOMAIN %s DROP CONSTRAINT %s;", name, objects.QuoteIdent(existingCheck.Name)))
+		}
+	}
+	for _, desiredCheck := range desired.Checks {
+		existingCheck := existing.GetCheck(desiredCheck.Name)
+		if existingCheck == nil || !objects.ExpressionsEqual(existingCheck.Expression, desiredCheck.Expression) {
+			diff = append(diff, fmt.Sprintf("ALTER DOMAIN %s ADD %s;", name, desiredCheck.SQL()))
+		}
+	}
+
+	return diff
+}
+
+// compareCompositeType adds, drops and retypes the attributes of a composite
+// type.
+func compareCompositeType(namespace string, existing, desired *objects.CompositeType) []string {
+	diff := []string{}
+	name := objects.QualifiedName(namespace, desired.Name)
+
+	for _, existingAttr := range existing.Attributes {
+		desiredAttr := desired.GetAttribute(existingAttr.Name)
+		if desiredAttr == nil {
+			diff = append(diff, fmt.Sprintf("ALTER TYPE %s DROP ATTRIBUTE %s;", name, objects.QuoteIdent(existingAttr.Name)))
+		} else if desiredAttr.TypeSQL() != existingAttr.TypeSQL() {
+			diff = append(diff, fmt.Sprintf("ALTER TYPE %s ALTER ATTRIBUTE %s TYPE %s;", name, objects.QuoteIdent(existingAttr.Name), desiredAttr.TypeSQL()))
+		}
+	}
+	for _, desiredAttr := range desired.Attributes {
+		if existing.GetAttribute(desiredAttr.Name) == nil {
+			diff = append(diff, fmt.Sprintf("ALTER TYPE %s ADD ATTRIBUTE %s %s;", name, objects.QuoteIdent(desiredAttr.Name), desiredAttr.TypeSQL()))
+		}
+	}
+
+	return diff
+}
+
+// droppedWithOwner reports whether an existing sequence goes away on its own
+// because the table or column owning it is dropped.
+func (m *Migrator) droppedWithOwner(sequence *objects.Sequence) bool {
+	namespace, table, column := sequence.Owner(m.namespaceName())
+	if table == "" || namespace != m.namespaceName() {
+		return false
+	}
+	return getColumn(getTable(m.desired, table), column) == nil
+}
+
 func (m *Migrator) compareTables() []string {
 	diff := []string{}
-	nsName := m.namespaceName()
 
 	if m.existing == nil || len(m.existing.Tables) == 0 {
 		for _, table := range m.desired.Tables {
-			diff = append(diff, fmt.Sprintf("CREATE TABLE %s.%s ();", nsName, table.Name))
-			diff = append(diff, m.compareColumns(nil, table)...)
-			diff = append(diff, m.compareConstraints(nil, table)...)
-			diff = append(diff, m.compareIndices(nil, table)...)
+			diff = append(diff, m.createTable(table)...)
 		}
 		return diff
 	}
@@ -117,15 +315,20 @@ func (m *Migrator) compareTables() []string {
 		found := false
 		for _, otherTable := range m.desired.Tables {
 			if otherTable.Name == table.Name {
+				diff = append(diff, commentAction("TABLE "+m.qualify(table.Name), table.Comment, otherTable.Comment)...)
+				diff = append(diff, m.compareStorage(table, otherTable)...)
 				diff = append(diff, m.compareColumns(table, otherTable)...)
 				diff = append(diff, m.compareConstraints(table, otherTable)...)
 				diff = append(diff, m.compareIndices(table, otherTable)...)
+				diff = append(diff, m.comparePolicies(table, otherTable)...)
+				diff = append(diff, grantActions("", "TABLE", "TABLE "+m.qualify(table.Name), table.Grants, otherTable.Grants)...)
+				diff = append(diff, m.comparePartitions(table, otherTable)...)
 				found = true
 				break
 			}
 		}
-		if !found {
-			diff = append(diff, fmt.Sprintf("DROP TABLE %s.%s;", nsName, table.Name))
+		if !found && partitionOf(m.desired, table.Name) == nil {
+			diff = append(diff, fmt.Sprintf("DROP TABLE %s;", m.qualify(table.Name)))
 		}
 	}
 
@@ -137,24 +340,155 @@ func (m *Migrator) compareTables() []string {
 				break
 			}
 		}
-		if !found {
-			diff = append(diff, fmt.Sprintf("CREATE TABLE %s.%s ();", nsName, table.Name))
-			diff = append(diff, m.compareColumns(nil, table)...)
-			diff = append(diff, m.compareConstraints(nil, table)...)
-			diff = append(diff, m.compareIndices(nil, table)...)
+		if !found && !m.detachedPartition(table.Name) {
+			diff = append(diff, m.createTable(table)...)
+		}
+	}
+
+	return diff
+}
+
+// detachedPartition reports whether a desired table already exists as a
+// partition that is detached from its table, which is kept. The next plan
+// then reconciles its definition like that of any other table.
+func (m *Migrator) detachedPartition(name string) bool {
+	parent := partitionOf(m.existing, name)
+	return parent != nil && getTable(m.desired, parent.Name) != nil
+}
+
+// createTable returns the actions creating a desired table. Partitioned tables
+// are created with their columns and partition key in one statement, as the
+// key cannot be added afterwards.
+func (m *Migrator) createTable(table *objects.Table) []string {
+	diff := []string{}
+
+	if table.PartitionBy == nil {
+		create := "CREATE TABLE"
+		if table.Unlogged {
+			create = "CREATE UNLOGGED TABLE"
+		}
+		diff = append(diff, fmt.Sprintf("%s %s ()%s;", create, m.qualify(table.Name), table.StorageSQL()))
+		diff = append(diff, commentAction("TABLE "+m.qualify(table.Name), nil, table.Comment)...)
+		diff = append(diff, m.compareColumns(nil, table)...)
+	} else {
+		columns := []string{}
+		for _, col := range table.Columns {
+			columns = append(columns, col.String())
+		}
+		diff = append(diff, fmt.Sprintf("CREATE TABLE %s (%s) %s%s;", m.qualify(table.Name), strings.Join(columns, ", "), table.PartitionBy.SQL(), table.StorageSQL()))
+		diff = append(diff, commentAction("TABLE "+m.qualify(table.Name), nil, table.Comment)...)
+		for _, col := range table.Columns {
+			diff = append(diff, commentAction(fmt.Sprintf("COLUMN %s.%s", m.qualify(table.Name), objects.QuoteIdent(col.Name)), nil, col.Comment)...)
+		}
+	}
+
+	diff = append(diff, m.compareConstraints(nil, table)...)
+	diff = append(diff, m.compareIndices(nil, table)...)
+	diff = append(diff, m.comparePolicies(nil, table)...)
+	diff = append(diff, grantActions("", "TABLE", "TABLE "+m.qualify(table.Name), nil, table.Grants)...)
+	diff = append(diff, m.comparePartitions(nil, table)...)
+	return diff
+}
+
+// compareStorage diffs how a table is stored: whether it is logged, its
+// tablespace and its storage parameters. Parameters that are left out are
+// reset to their defaults before the others are set.
+func (m *Migrator) compareStorage(existing, desired *objects.Table) []string {
+	diff := []string{}
+	table := m.qualify(desired.Name)
+
+	if existing.Unlogged != desired.Unlogged {
+		persistence := "LOGGED"
+		if desired.Unlogged {
+			persistence = "UNLOGGED"
 		}
+		diff = append(diff, fmt.Sprintf("ALTER TABLE %s SET %s;", table, persistence))
 	}
 
+	if existing.GetTablespace() != desired.GetTablespace() {
+		diff = append(diff, fmt.Sprintf("ALTER TABLE %s SET TABLESPACE %s;", table, objects.QuoteIdent(desired.GetTablespace())))
+	}
+
+	existingWith, desiredWith := existing.GetWith(), desired.GetWith()
+	reset, set := []string{}, []string{}
+	for name := range existingWith {
+		if _, ok := desiredWith[name]; !ok {
+			reset = append(reset, name)
+		}
+	}
+	for name, value := range desiredWith {
+		if existingValue, ok := existingWith[name]; !ok || !strings.EqualFold(existingValue, value) {
+			set = append(set, fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+	sort.Strings(reset)
+	sort.Strings(set)
+
+	if len(reset) > 0 {
+		diff = append(diff, fmt.Sprintf("ALTER TABLE %s RESET (%s);", table, strings.Join(reset, ", ")))
+	}
+	if len(set) > 0 {
+		diff = append(diff, fmt.Sprintf("ALTER TABLE %s SET (%s);", table, strings.Join(set, ", ")))
+	}
 	return diff
 }
 
+// comparePartitions diffs the partitions of a partitioned table. Partitions
+// that are gone are detached, and dropped unless they are kept as a table of
+// their own; existing tables listed as partitions are attached. Partitions
+// whose bounds change are detached and attached again, all detaches first so
+// that the new bounds cannot overlap the old ones.
+func (m *Migrator) comparePartitions(existing, desired *objects.Table) []string {
+	parent := m.qualify(desired.Name)
+
+	if existing != nil && !existing.PartitionBy.Equal(desired.PartitionBy) {
+		m.errs = append(m.errs, fmt.Errorf("the partitioning of table %s cannot be changed in place, recreate the table instead", parent))
+		return nil
+	}
+	if desired.PartitionBy == nil {
+		return nil
+	}
+
+	existingPartitions := []*objects.Partition{}
+	if existing != nil {
+		existingPartitions = existing.Partitions
+	}
+
+	detach, attach := []string{}, []string{}
+	for _, existingPart := range existingPartitions {
+		desiredPart := desired.GetPartition(existingPart.Name)
+		if desiredPart != nil && desiredPart.Equal(existingPart) {
+			continue
+		}
+		detach = append(detach, fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s;", parent, m.qualify(existingPart.Name)))
+		if desiredPart != nil {
+			attach = append(attach, fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s %s;", parent, m.qualify(desiredPart.Name), desiredPart.BoundSQL()))
+		} else if getTable(m.desired, existingPart.Name) == nil {
+			detach = append(detach, fmt.Sprintf("DROP TABLE %s;", m.qualify(existingPart.Name)))
+		}
+	}
+
+	for _, desiredPart := range desired.Partitions {
+		if existing != nil && existing.GetPartition(desiredPart.Name) != nil {
+			continue
+		}
+		if getTable(m.existing, desiredPart.Name) != nil {
+			attach = append(attach, fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s %s;", parent, m.qualify(desiredPart.Name), desiredPart.BoundSQL()))
+		} else {
+			attach = append(attach, fmt.Sprintf("CREATE TABLE %s PARTITION OF %s %s;", m.qualify(desiredPart.Name), parent, desiredPart.BoundSQL()))
+		}
+	}
+
+	return append(detach, attach...)
+}
+
 func (m *Migrator) compareColumns(existing, desired *objects.Table) []string {
 	diff := []string{}
-	nsName := m.namespaceName()
 
 	if existing == nil || len(existing.Columns) == 0 {
 		for _, col := range desired.Columns {
-			diff = append(diff, fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s;", nsName, desired.Name, col.String()))
+			diff = append(diff, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", m.qualify(desired.Name), col.String()))
+			diff = append(diff, commentAction(fmt.Sprintf("COLUMN %s.%s", m.qualify(desired.Name), objects.QuoteIdent(col.Name)), nil, col.Comment)...)
 		}
 		return diff
 	}
@@ -164,24 +498,13 @@ func (m *Migrator) compareColumns(existing, desired *objects.Table) []string {
 		for _, desiredCol := range desired.Columns {
 			if desiredCol.Name == existingCol.Name {
 				found = true
-				if desiredCol.Type != existingCol.Type {
-					diff = append(diff, fmt.Sprintf("ALTER TABLE %s.%s ALTER COLUMN %s TYPE %s;", nsName, existing.Name, desiredCol.Name, desiredCol.Type))
-				}
-
-				if desiredCol.Default != existingCol.Default {
-					d := fmt.Sprintf("ALTER TABLE %s.%s ALTER COLUMN %s %s;", nsName, existing.Name, desiredCol.Name, columnDefaultAction(desiredCol))
-					diff = append(diff, d)
-				}
-
-				if desiredCol.Nullable != existingCol.Nullable {
-					d := fmt.Sprintf("ALTER TABLE %s.%s ALTER COLUMN %s %s;", nsName, existing.Name, desiredCol.Name, columnNullableAction(desiredCol))
-					diff = append(diff, d)
-				}
+				diff = append(diff, m.compareColumn(existing.Name, existingCol, desiredCol)...)
+				diff = append(diff, commentAction(fmt.Sprintf("COLUMN %s.%s", m.qualify(existing.Name), objects.QuoteIdent(existingCol.Name)), existingCol.Comment, desiredCol.Comment)...)
 				break
 			}
 		}
 		if !found {
-			diff = append(diff, fmt.Sprintf("ALTER TABLE %s.%s DROP COLUMN %s;", nsName, existing.Name, existingCol.Name))
+			diff = append(diff, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", m.qualify(existing.Name), objects.QuoteIdent(existingCol.Name)))
 		}
 	}
 
@@ -194,7 +517,57 @@ func (m *Migrator) compareColumns(existing, desired *objects.Table) []string {
 			}
 		}
 		if !found {
-			diff = append(diff, fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s;", nsName, desired.Name, col.String()))
+			diff = append(diff, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", m.qualify(desired.Name), col.String()))
+			diff = append(diff, commentAction(fmt.Sprintf("COLUMN %s.%s", m.qualify(desired.Name), objects.QuoteIdent(col.Name)), nil, col.Comment)...)
+		}
+	}
+
+	return diff
+}
+
+func (m *Migrator) compareColumn(table string, existingCol, desiredCol *objects.Column) []string {
+	diff := []string{}
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", m.qualify(table), objects.QuoteIdent(desiredCol.Name))
+	path := fmt.Sprintf("%s.%s.%s", m.namespaceName(), table, desiredCol.Name)
+
+	if existingCol.Generated == "" && desiredCol.Generated != "" {
+		m.errs = append(m.errs, fmt.Errorf("column %s cannot be turned into a generated column in place, drop and re-add it instead", path))
+		return diff
+	}
+	if existingCol.Generated != "" && desiredCol.Generated != "" && !objects.ExpressionsEqual(existingCol.Generated, desiredCol.Generated) {
+		m.errs = append(m.errs, fmt.Errorf("the generation expression of column %s cannot be changed in place, drop and re-add it instead", path))
+		return diff
+	}
+	if existingCol.Generated != "" && desiredCol.Identity != nil {
+		m.errs = append(m.errs, fmt.Errorf("generated column %s cannot be turned into an identity column in place, drop and re-add it instead", path))
+		return diff
+	}
+
+	if desiredCol.TypeSQL() != existingCol.TypeSQL() || desiredCol.GetCollation() != existingCol.GetCollation() {
+		diff = append(diff, fmt.Sprintf("%s TYPE %s%s;", alter, desiredCol.TypeSQL(), desiredCol.CollationSQL()))
+	}
+
+	if existingCol.Generated != "" && desiredCol.Generated == "" {
+		diff = append(diff, fmt.Sprintf("%s DROP EXPRESSION;", alter))
+	}
+
+	if existingCol.Identity != nil && desiredCol.Identity == nil {
+		diff = append(diff, fmt.Sprintf("%s DROP IDENTITY;", alter))
+	}
+
+	if !objects.ExpressionsEqualIn(m.namespaceName(), desiredCol.Default, existingCol.Default) {
+		diff = append(diff, fmt.Sprintf("%s %s;", alter, columnDefaultAction(desiredCol)))
+	}
+
+	if desiredCol.Nullable != existingCol.Nullable {
+		diff = append(diff, fmt.Sprintf("%s %s;", alter, columnNullableAction(desiredCol)))
+	}
+
+	if existingCol.Identity == nil && desiredCol.Identity != nil {
+		diff = append(diff, fmt.Sprintf("%s ADD %s;", alter, desiredCol.Identity.SQL()))
+	} else if existingCol.Identity != nil && desiredCol.Identity != nil {
+		if changes := columnIdentityChanges(existingCol.Identity, desiredCol.Identity, desiredCol.Type); len(changes) > 0 {
+			diff = append(diff, fmt.Sprintf("%s %s;", alter, strings.Join(changes, " ")))
 		}
 	}
 
@@ -207,7 +580,8 @@ func (m *Migrator) compareConstraints(existing, desired *objects.Table) []string
 
 	if existing == nil || len(existing.Constraints) == 0 {
 		for _, c := range desired.Constraints {
-			diff = append(diff, fmt.Sprintf("ALTER TABLE %s.%s ADD %s;", nsName, desired.Name, c.SQL()))
+			diff = append(diff, fmt.Sprintf("ALTER TABLE %s ADD %s;", m.qualify(desired.Name), c.SQL()))
+			diff = append(diff, commentAction(constraintCommentTarget(nsName, desired.Name, c), nil, c.Comment)...)
 		}
 		return diff
 	}
@@ -217,15 +591,22 @@ func (m *Migrator) compareConstraints(existing, desired *objects.Table) []string
 		for _, desiredCon := range desired.Constraints {
 			if desiredCon.Name == existingCon.Name {
 				found = true
-				if !desiredCon.Equal(existingCon) {
-					diff = append(diff, fmt.Sprintf("ALTER TABLE %s.%s DROP CONSTRAINT %s;", nsName, existing.Name, existingCon.Name))
-					diff = append(diff, fmt.Sprintf("ALTER TABLE %s.%s ADD %s;", nsName, existing.Name, desiredCon.SQL()))
+				if onlyDeferralDiffers(existingCon, desiredCon) {
+					diff = append(diff, fmt.Sprintf("ALTER TABLE %s ALTER CONSTRAINT %s %s;", m.qualify(existing.Name), objects.QuoteIdent(existingCon.Name), desiredCon.DeferralSQL()))
+				} else if !desiredCon.Equal(existingCon) {
+					diff = append(diff, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", m.qualify(existing.Name), objects.QuoteIdent(existingCon.Name)))
+					diff = append(diff, fmt.Sprintf("ALTER TABLE %s ADD %s;", m.qualify(existing.Name), desiredCon.SQL()))
+					diff = append(diff, commentAction(constraintCommentTarget(nsName, existing.Name, desiredCon), nil, desiredCon.Comment)...)
+					break
+				} else if existingCon.NotValid && !desiredCon.NotValid {
+					diff = append(diff, fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s;", m.qualify(existing.Name), objects.QuoteIdent(existingCon.Name)))
 				}
+				diff = append(diff, commentAction(constraintCommentTarget(nsName, existing.Name, desiredCon), existingCon.Comment, desiredCon.Comment)...)
 				break
 			}
 		}
 		if !found {
-			diff = append(diff, fmt.Sprintf("ALTER TABLE %s.%s DROP CONSTRAINT %s;", nsName, existing.Name, existingCon.Name))
+			diff = append(diff, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", m.qualify(existing.Name), objects.QuoteIdent(existingCon.Name)))
 		}
 	}
 
@@ -238,7 +619,8 @@ func (m *Migrator) compareConstraints(existing, desired *objects.Table) []string
 			}
 		}
 		if !found {
-			diff = append(diff, fmt.Sprintf("ALTER TABLE %s.%s ADD %s;", nsName, desired.Name, desiredCon.SQL()))
+			diff = append(diff, fmt.Sprintf("ALTER TABLE %s ADD %s;", m.qualify(desired.Name), desiredCon.SQL()))
+			diff = append(diff, commentAction(constraintCommentTarget(nsName, desired.Name, desiredCon), nil, desiredCon.Comment)...)
 		}
 	}
 
@@ -252,6 +634,7 @@ func (m *Migrator) compareIndices(existing, desired *objects.Table) []string {
 	if existing == nil || len(existing.Indices) == 0 {
 		for _, idx := range desired.Indices {
 			diff = append(diff, indexCreateSQL(nsName, desired.Name, idx))
+			diff = append(diff, commentAction("INDEX "+m.qualify(idx.Name), nil, idx.Comment)...)
 		}
 		return diff
 	}
@@ -262,14 +645,17 @@ func (m *Migrator) compareIndices(existing, desired *objects.Table) []string {
 			if desiredIdx.Name == existingIdx.Name {
 				found = true
 				if !desiredIdx.Equal(existingIdx) {
-					diff = append(diff, fmt.Sprintf("DROP INDEX %s.%s;", nsName, existingIdx.Name))
+					diff = append(diff, fmt.Sprintf("DROP INDEX %s;", m.qualify(existingIdx.Name)))
 					diff = append(diff, indexCreateSQL(nsName, existing.Name, desiredIdx))
+					diff = append(diff, commentAction("INDEX "+m.qualify(desiredIdx.Name), nil, desiredIdx.Comment)...)
+				} else {
+					diff = append(diff, commentAction("INDEX "+m.qualify(desiredIdx.Name), existingIdx.Comment, desiredIdx.Comment)...)
 				}
 				break
 			}
 		}
 		if !found {
-			diff = append(diff, fmt.Sprintf("DROP INDEX %s.%s;", nsName, existingIdx.Name))
+			diff = append(diff, fmt.Sprintf("DROP INDEX %s;", m.qualify(existingIdx.Name)))
 		}
 	}
 
@@ -283,22 +669,477 @@ func (m *Migrator) compareIndices(existing, desired *objects.Table) []string {
 		}
 		if !found {
 			diff = append(diff, indexCreateSQL(nsName, desired.Name, desiredIdx))
+			diff = append(diff, commentAction("INDEX "+m.qualify(desiredIdx.Name), nil, desiredIdx.Comment)...)
+		}
+	}
+
+	return diff
+}
+
+// compareFunctions diffs the grants on the functions of the namespace. The
+// functions themselves are not managed and must already exist.
+func (m *Migrator) compareFunctions() []string {
+	diff := []string{}
+	nsName := m.namespaceName()
+
+	for _, desiredFn := range m.desired.Functions {
+		var existingFn *objects.Function
+		if m.existing != nil {
+			for _, fn := range m.existing.Functions {
+				if fn.Name == desiredFn.Name && functionArgumentsEqual(fn.Arguments, desiredFn.Arguments) {
+					existingFn = fn
+					break
+				}
+			}
+		}
+		if existingFn == nil {
+			m.errs = append(m.errs, fmt.Errorf("function %s does not exist, only the grants on functions are managed", desiredFn.Signature(nsName)))
+			continue
+		}
+		diff = append(diff, grantActions("", "FUNCTION", "FUNCTION "+existingFn.Signature(nsName), existingFn.Grants, desiredFn.Grants)...)
+	}
+
+	return diff
+}
+
+// compareDefaultPrivileges diffs the privileges granted on objects created in
+// the namespace later on. Default privileges of other roles or object kinds
+// that are no longer desired are revoked.
+func (m *Migrator) compareDefaultPrivileges() []string {
+	diff := []string{}
+	if m.desired.DefaultPrivileges == nil {
+		return diff
+	}
+	nsName := m.namespaceName()
+
+	existing := []*objects.DefaultPrivilege{}
+	if m.existing != nil {
+		existing = m.existing.DefaultPrivileges
+	}
+
+	for _, existingDef := range existing {
+		found := false
+		for _, desiredDef := range m.desired.DefaultPrivileges {
+			if desiredDef.Role == existingDef.Role && desiredDef.GetOn() == existingDef.GetOn() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, defaultPrivilegeActions(nsName, existingDef.Role, existingDef.GetOn(), existingDef.Grants, []*objects.Grant{})...)
 		}
 	}
 
+	for _, desiredDef := range m.desired.DefaultPrivileges {
+		var grants []*objects.Grant
+		for _, existingDef := range existing {
+			if existingDef.Role == desiredDef.Role && existingDef.GetOn() == desiredDef.GetOn() {
+				grants = existingDef.Grants
+				break
+			}
+		}
+		diff = append(diff, defaultPrivilegeActions(nsName, desiredDef.Role, desiredDef.GetOn(), grants, desiredDef.Grants)...)
+	}
+
 	return diff
 }
 
+func defaultPrivilegeActions(namespace, role, on string, existing, desired []*objects.Grant) []string {
+	prefix := fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA %s ", objects.QuoteIdent(role), objects.QuoteIdent(namespace))
+	return grantActions(prefix, on, on, existing, desired)
+}
+
+// comparePolicies diffs the row level security policies of a table and then
+// whether they are enforced. Policies whose command or kind changes, or that
+// lose an expression, cannot be altered and are recreated.
+func (m *Migrator) comparePolicies(existing, desired *objects.Table) []string {
+	diff := []string{}
+	table := m.qualify(desired.Name)
+
+	existingPolicies := []*objects.Policy{}
+	existingRLS := objects.RowLevelSecurityDisabled
+	if existing != nil {
+		existingPolicies = existing.Policies
+		existingRLS = existing.GetRowLevelSecurity()
+	}
+
+	for _, existingPol := range existingPolicies {
+		found := false
+		for _, desiredPol := range desired.Policies {
+			if desiredPol.Name == existingPol.Name {
+				found = true
+				if desiredPol.Equal(existingPol) {
+					break
+				}
+				if policyAlterable(existingPol, desiredPol) {
+					diff = append(diff, desiredPol.AlterSQL(table))
+				} else {
+					diff = append(diff, fmt.Sprintf("DROP POLICY %s ON %s;", objects.QuoteIdent(existingPol.Name), table))
+					diff = append(diff, desiredPol.SQL(table))
+				}
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, fmt.Sprintf("DROP POLICY %s ON %s;", objects.QuoteIdent(existingPol.Name), table))
+		}
+	}
+
+	for _, desiredPol := range desired.Policies {
+		found := false
+		for _, existingPol := range existingPolicies {
+			if existingPol.Name == desiredPol.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, desiredPol.SQL(table))
+		}
+	}
+
+	return append(diff, rowLevelSecurityActions(table, existingRLS, desired.GetRowLevelSecurity())...)
+}
+
 func indexCreateSQL(namespace, table string, idx *objects.Index) string {
 	unique := ""
 	if idx.Unique {
 		unique = "UNIQUE "
 	}
-	algo := string(idx.Algorithm)
-	if algo == "" {
-		algo = "btree"
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s %s;", unique, objects.QuoteIdent(idx.Name), objects.QualifiedName(namespace, table), idx.DefinitionSQL())
+}
+
+// CompareDatabase diffs two databases. Database-level objects such as
+// extensions are created by a leading migrator so that they exist before any
+// namespace action relies on them, and dropped by a trailing one once nothing
+// depends on them anymore. Publications are the other way around: tables are
+// removed from them by the leading migrator, before they are dropped, and
+// added by the trailing one, once they exist. Subscriptions likewise are
+// dropped by the leading migrator and created or altered by the trailing one,
+// once the tables they replicate into exist.
+func CompareDatabase(existing, desired *objects.Database) []*Migrator {
+	if existing == nil {
+		existing = &objects.Database{}
+	}
+	if desired == nil {
+		desired = &objects.Database{}
+	}
+
+	diff := []*Migrator{}
+
+	name := existing.Name
+	if name == "" {
+		name = desired.Name
+	}
+	if name == "" {
+		name = "database"
+	}
+
+	create, drop := compareRoles(existing.Roles, desired.Roles)
+	schemas := extensionSchemas(existing, desired)
+	for _, schema := range schemas {
+		create = append(create, fmt.Sprintf("CREATE SCHEMA %s;", objects.QuoteIdent(schema)))
+	}
+	createExt, dropExt := compareExtensions(existing.Extensions, desired.Extensions)
+	unpublish, publish := comparePublications(existing.Publications, desired.Publications, droppedTables(existing.Namespaces, desired.Namespaces))
+	create = append(create, createExt...)
+	unsubscribe, subscribe := compareSubscriptions(existing.Subscriptions, desired.Subscriptions)
+	create = append(create, unpublish...)
+	create = append(create, unsubscribe...)
+	drop = append(dropExt, drop...)
+	drop = append(subscribe, drop...)
+	drop = append(publish, drop...)
+	if len(create) > 0 {
+		diff = append(diff, &Migrator{database: name, actions: create})
+	}
+
+	for _, m := range Compare(existing.Namespaces, desired.Namespaces) {
+		if m.existing == nil && m.desired != nil && containsString(schemas, m.desired.Name) {
+			m.actions = m.actions[1:]
+		}
+		diff = append(diff, m)
+	}
+
+	if len(drop) > 0 {
+		diff = append(diff, &Migrator{database: name, actions: drop})
+	}
+
+	return diff
+}
+
+// extensionSchemas returns the new schemas that desired extensions are
+// installed into. Extensions are created before the namespaces, as their
+// tables may use them, so these schemas are created ahead of the extensions
+// rather than by their namespace.
+func extensionSchemas(existing, desired *objects.Database) []string {
+	schemas := []string{}
+	if desired.Extensions == nil {
+		return schemas
+	}
+	for _, ext := range desired.Extensions {
+		if ext.Schema == "" || containsString(schemas, ext.Schema) || getNamespace(existing.Namespaces, ext.Schema) != nil {
+			continue
+		}
+		if getNamespace(desired.Namespaces, ext.Schema) != nil {
+			schemas = append(schemas, ext.Schema)
+		}
+	}
+	return schemas
+}
+
+// compareRoles diffs the roles of the cluster. Roles are created before their
+// memberships are granted, as they may be members of each other. A nil desired
+// list means the roles are not managed, so nothing is dropped.
+func compareRoles(existing, desired []*objects.Role) (diff, drop []string) {
+	if desired == nil {
+		return
+	}
+
+	memberships := []string{}
+	for _, existingRole := range existing {
+		found := false
+		for _, desiredRole := range desired {
+			if desiredRole.Name == existingRole.Name {
+				found = true
+				name := objects.QuoteIdent(existingRole.Name)
+				if desiredRole.Login != existingRole.Login {
+					option := "NOLOGIN"
+					if desiredRole.Login {
+						option = "LOGIN"
+					}
+					diff = append(diff, fmt.Sprintf("ALTER ROLE %s %s;", name, option))
+				}
+				if desiredRole.GetInherit() != existingRole.GetInherit() {
+					option := "NOINHERIT"
+					if desiredRole.GetInherit() {
+						option = "INHERIT"
+					}
+					diff = append(diff, fmt.Sprintf("ALTER ROLE %s %s;", name, option))
+				}
+				for _, parent := range existingRole.MemberOf {
+					if !containsString(desiredRole.MemberOf, parent) {
+						memberships = append(memberships, fmt.Sprintf("REVOKE %s FROM %s;", objects.QuoteIdent(parent), name))
+					}
+				}
+				for _, parent := range desiredRole.MemberOf {
+					if !containsString(existingRole.MemberOf, parent) {
+						memberships = append(memberships, fmt.Sprintf("GRANT %s TO %s;", objects.QuoteIdent(parent), name))
+					}
+				}
+				break
+			}
+		}
+		if !found {
+			drop = append(drop, fmt.Sprintf("DROP ROLE %s;", objects.QuoteIdent(existingRole.Name)))
+		}
+	}
+
+	for _, desiredRole := range desired {
+		found := false
+		for _, existingRole := range existing {
+			if existingRole.Name == desiredRole.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, desiredRole.SQL())
+			for _, parent := range desiredRole.MemberOf {
+				memberships = append(memberships, fmt.Sprintf("GRANT %s TO %s;", objects.QuoteIdent(parent), objects.QuoteIdent(desiredRole.Name)))
+			}
+		}
+	}
+
+	diff = append(diff, memberships...)
+	return
+}
+
+// compareExtensions diffs installed extensions. A nil desired list means the
+// extensions are not managed, so nothing is dropped.
+func compareExtensions(existing, desired []*objects.Extension) (diff, drop []string) {
+	if desired == nil {
+		return
+	}
+
+	for _, existingExt := range existing {
+		found := false
+		for _, desiredExt := range desired {
+			if desiredExt.Name == existingExt.Name {
+				found = true
+				if desiredExt.Schema != "" && desiredExt.Schema != existingExt.Schema {
+					diff = append(diff, fmt.Sprintf("ALTER EXTENSION %s SET SCHEMA %s;", objects.QuoteIdent(existingExt.Name), objects.QuoteIdent(desiredExt.Schema)))
+				}
+				if desiredExt.Version != "" && desiredExt.Version != existingExt.Version {
+					diff = append(diff, fmt.Sprintf("ALTER EXTENSION %s UPDATE TO '%s';", objects.QuoteIdent(existingExt.Name), desiredExt.Version))
+				}
+				diff = append(diff, commentAction("EXTENSION "+objects.QuoteIdent(existingExt.Name), existingExt.Comment, desiredExt.Comment)...)
+				break
+			}
+		}
+		if !found {
+			drop = append(drop, fmt.Sprintf("DROP EXTENSION %s;", objects.QuoteIdent(existingExt.Name)))
+		}
+	}
+
+	for _, desiredExt := range desired {
+		found := false
+		for _, existingExt := range existing {
+			if existingExt.Name == desiredExt.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, desiredExt.SQL())
+			diff = append(diff, commentAction("EXTENSION "+objects.QuoteIdent(desiredExt.Name), nil, desiredExt.Comment)...)
+		}
+	}
+
+	return
+}
+
+// comparePublications diffs the publications of the database. Tables that are
+// dropped leave their publications explicitly, even when publications are not
+// managed, so that the plan shows it. A nil desired list otherwise leaves the
+// publications untouched. Tables whose row filter or column list changes are
+// removed and added again.
+func comparePublications(existing, desired []*objects.Publication, dropped map[string]bool) (before, after []string) {
+	for _, existingPub := range existing {
+		var desiredPub *objects.Publication
+		for _, pub := range desired {
+			if pub.Name == existingPub.Name {
+				desiredPub = pub
+				break
+			}
+		}
+		name := objects.QuoteIdent(existingPub.Name)
+
+		if desired == nil {
+			for _, t := range existingPub.Tables {
+				if dropped[t.Table] {
+					before = append(before, fmt.Sprintf("ALTER PUBLICATION %s DROP TABLE %s;", name, t.TableSQL()))
+				}
+			}
+			continue
+		}
+
+		if desiredPub == nil || desiredPub.AllTables != existingPub.AllTables {
+			before = append(before, fmt.Sprintf("DROP PUBLICATION %s;", name))
+			if desiredPub != nil {
+				after = append(after, desiredPub.SQL())
+				after = append(after, commentAction("PUBLICATION "+name, nil, desiredPub.Comment)...)
+			}
+			continue
+		}
+
+		for _, t := range existingPub.Tables {
+			desiredTable := desiredPub.GetTable(t.Table)
+			if desiredTable == nil || !desiredTable.Equal(t) {
+				before = append(before, fmt.Sprintf("ALTER PUBLICATION %s DROP TABLE %s;", name, t.TableSQL()))
+			}
+			if desiredTable != nil && !desiredTable.Equal(t) {
+				after = append(after, fmt.Sprintf("ALTER PUBLICATION %s ADD TABLE %s;", name, desiredTable.SQL()))
+			}
+		}
+		for _, t := range desiredPub.Tables {
+			if existingPub.GetTable(t.Table) == nil {
+				after = append(after, fmt.Sprintf("ALTER PUBLICATION %s ADD TABLE %s;", name, t.SQL()))
+			}
+		}
+		if desiredPub.PublishSQL() != existingPub.PublishSQL() {
+			after = append(after, fmt.Sprintf("ALTER PUBLICATION %s SET (publish = %s);", name, desiredPub.PublishSQL()))
+		}
+		after = append(after, commentAction("PUBLICATION "+name, existingPub.Comment, desiredPub.Comment)...)
+	}
+
+	for _, desiredPub := range desired {
+		found := false
+		for _, existingPub := range existing {
+			if existingPub.Name == desiredPub.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			after = append(after, desiredPub.SQL())
+			after = append(after, commentAction("PUBLICATION "+objects.QuoteIdent(desiredPub.Name), nil, desiredPub.Comment)...)
+		}
 	}
-	return fmt.Sprintf("CREATE %sINDEX %s ON %s.%s USING %s (%s);", unique, idx.Name, namespace, table, algo, strings.Join(idx.Columns, ", "))
+
+	return
+}
+
+// compareSubscriptions diffs the subscriptions of the database. A nil desired
+// list leaves the subscriptions untouched. Every statement can run in the
+// migration transaction: replication slots are neither created nor dropped,
+// a dropped subscription is detached from its slot first, and publications
+// are changed without a refresh. A connection that could not be read from
+// the database is left as it is.
+func compareSubscriptions(existing, desired []*objects.Subscription) (before, after []string) {
+	if desired == nil {
+		return
+	}
+
+	for _, existingSub := range existing {
+		var desiredSub *objects.Subscription
+		for _, sub := range desired {
+			if sub.Name == existingSub.Name {
+				desiredSub = sub
+				break
+			}
+		}
+		name := objects.QuoteIdent(existingSub.Name)
+
+		if desiredSub == nil {
+			if existingSub.GetEnabled() {
+				before = append(before, fmt.Sprintf("ALTER SUBSCRIPTION %s DISABLE;", name))
+			}
+			before = append(before, fmt.Sprintf("ALTER SUBSCRIPTION %s SET (slot_name = NONE);", name))
+			before = append(before, fmt.Sprintf("DROP SUBSCRIPTION %s;", name))
+			continue
+		}
+
+		enabled := existingSub.GetEnabled()
+		if desiredSub.GetSlotName() != existingSub.GetSlotName() {
+			if enabled {
+				after = append(after, fmt.Sprintf("ALTER SUBSCRIPTION %s DISABLE;", name))
+				enabled = false
+			}
+			after = append(after, fmt.Sprintf("ALTER SUBSCRIPTION %s SET (slot_name = %s);", name, objects.QuoteLiteral(desiredSub.GetSlotName())))
+		}
+		if existingSub.Connection != "" && desiredSub.Connection != existingSub.Connection {
+			after = append(after, fmt.Sprintf("ALTER SUBSCRIPTION %s CONNECTION %s;", name, objects.QuoteLiteral(desiredSub.Connection)))
+		}
+		if desiredSub.PublicationsSQL() != existingSub.PublicationsSQL() {
+			after = append(after, fmt.Sprintf("ALTER SUBSCRIPTION %s SET PUBLICATION %s WITH (refresh = false);", name, desiredSub.PublicationsSQL()))
+		}
+		if desiredSub.GetEnabled() && !enabled {
+			after = append(after, fmt.Sprintf("ALTER SUBSCRIPTION %s ENABLE;", name))
+		} else if !desiredSub.GetEnabled() && enabled {
+			after = append(after, fmt.Sprintf("ALTER SUBSCRIPTION %s DISABLE;", name))
+		}
+		after = append(after, commentAction("SUBSCRIPTION "+name, existingSub.Comment, desiredSub.Comment)...)
+	}
+
+	for _, desiredSub := range desired {
+		found := false
+		for _, existingSub := range existing {
+			if existingSub.Name == desiredSub.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			name := objects.QuoteIdent(desiredSub.Name)
+			after = append(after, desiredSub.SQL())
+			if desiredSub.GetEnabled() {
+				after = append(after, fmt.Sprintf("ALTER SUBSCRIPTION %s ENABLE;", name))
+			}
+			after = append(after, commentAction("SUBSCRIPTION "+name, nil, desiredSub.Comment)...)
+		}
+	}
+
+	return
 }
 
 func Compare(existing, desired []*objects.Namespace) []*Migrator {
@@ -337,21 +1178,91 @@ func Compare(existing, desired []*objects.Namespace) []*Migrator {
 
 	for _, m := range diff {
 		if m.existing == nil {
-			m.actions = []string{fmt.Sprintf("CREATE SCHEMA %s;", m.desired.Name)}
+			types, dropTypes := m.compareTypes()
+			sequences, after := m.compareSequences()
+			m.actions = []string{fmt.Sprintf("CREATE SCHEMA %s;", objects.QuoteIdent(m.desired.Name))}
+			m.actions = append(m.actions, commentAction("SCHEMA "+objects.QuoteIdent(m.desired.Name), nil, m.desired.Comment)...)
+			m.actions = append(m.actions, grantActions("", "SCHEMA", "SCHEMA "+objects.QuoteIdent(m.desired.Name), nil, m.desired.Grants)...)
+			m.actions = append(m.actions, types...)
+			m.actions = append(m.actions, sequences...)
 			m.actions = append(m.actions, m.compareTables()...)
-			m.actions = append(m.actions, m.compareSequences()...)
+			m.actions = append(m.actions, after...)
+			m.actions = append(m.actions, dropTypes...)
+			m.actions = append(m.actions, m.compareFunctions()...)
+			m.actions = append(m.actions, m.compareDefaultPrivileges()...)
 			continue
 		}
 
 		if m.desired == nil {
-			m.actions = []string{fmt.Sprintf("DROP SCHEMA %s CASCADE;", m.existing.Name)}
+			m.actions = []string{fmt.Sprintf("DROP SCHEMA %s CASCADE;", objects.QuoteIdent(m.existing.Name))}
 			m.locked = true
 			continue
 		}
 
-		m.actions = m.compareTables()
-		m.actions = append(m.actions, m.compareSequences()...)
+		types, dropTypes := m.compareTypes()
+		sequences, after := m.compareSequences()
+		m.actions = commentAction("SCHEMA "+objects.QuoteIdent(m.desired.Name), m.existing.Comment, m.desired.Comment)
+		m.actions = append(m.actions, grantActions("", "SCHEMA", "SCHEMA "+objects.QuoteIdent(m.desired.Name), m.existing.Grants, m.desired.Grants)...)
+		m.actions = append(m.actions, types...)
+		m.actions = append(m.actions, sequences...)
+		m.actions = append(m.actions, m.compareTables()...)
+		m.actions = append(m.actions, after...)
+		m.actions = append(m.actions, dropTypes...)
+		m.actions = append(m.actions, m.compareFunctions()...)
+		m.actions = append(m.actions, m.compareDefaultPrivileges()...)
 	}
 
 	return diff
 }
+
+// ColumnOrderWarnings reports the tables whose columns will not be in the
+// desired order once the migration is applied. PostgreSQL appends added
+// columns at the end and cannot reorder columns without recreating the table,
+// so such differences are reported rather than planned.
+func ColumnOrderWarnings(existing, desired *objects.Database) []string {
+	if existing == nil || desired == nil {
+		return nil
+	}
+
+	warnings := []string{}
+	for _, desiredNs := range desired.Namespaces {
+		var existingNs *objects.Namespace
+		for _, ns := range existing.Namespaces {
+			if ns.Name == desiredNs.Name {
+				existingNs = ns
+				break
+			}
+		}
+		if existingNs == nil {
+			continue
+		}
+
+		for _, table := range desiredNs.Tables {
+			existingTable := getTable(existingNs, table.Name)
+			if existingTable == nil {
+				continue
+			}
+
+			wanted, live := []string{}, []string{}
+			for _, col := range table.Columns {
+				wanted = append(wanted, col.Name)
+			}
+			for _, col := range existingTable.Columns {
+				if containsString(wanted, col.Name) {
+					live = append(live, col.Name)
+				}
+			}
+			for _, col := range table.Columns {
+				if !containsString(live, col.Name) {
+					live = append(live, col.Name)
+				}
+			}
+
+			if strings.Join(live, ", ") != strings.Join(wanted, ", ") {
+				warnings = append(warnings, fmt.Sprintf("columns of table %s.%s will be ordered (%s) instead of (%s), as they cannot be reordered without recreating the table",
+					desiredNs.Name, table.Name, strings.Join(live, ", "), strings.Join(wanted, ", ")))
+			}
+		}
+	}
+	return warnings
+}
@@ -235,6 +235,131 @@ func TestCompare_AlterSequenceType(t *testing.T) {
 	assertContains(t, actions, "ALTER SEQUENCE public.counter_seq AS bigint")
 }
 
+func TestCompare_CreateSequenceWithOptions(t *testing.T) {
+	start := int64(1000)
+	existing := []*objects.Namespace{
+		{Name: "public"},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public",
+			Tables: []*objects.Table{
+				{Name: "tickets", Columns: []*objects.Column{
+					{Name: "id", Type: "INTEGER", Default: "nextval('tickets_id_seq')"},
+				}},
+			},
+			Sequences: []*objects.Sequence{
+				{Name: "tickets_id_seq", Type: "integer", SequenceOptions: objects.SequenceOptions{Start: &start, Increment: 10, Cycle: true}, OwnedBy: "tickets.id"},
+			},
+		},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	create, table, owned := -1, -1, -1
+	for i, action := range actions {
+		switch action {
+		case "CREATE SEQUENCE public.tickets_id_seq AS integer START WITH 1000 INCREMENT BY 10 CYCLE;":
+			create = i
+		case "CREATE TABLE public.tickets ();":
+			table = i
+		case "ALTER SEQUENCE public.tickets_id_seq OWNED BY public.tickets.id;":
+			owned = i
+		}
+	}
+	if create == -1 || table == -1 || owned == -1 {
+		t.Fatalf("missing expected actions in: %v", actions)
+	}
+	if create > table || owned < table {
+		t.Errorf("expected sequence to be created before and owned after the table, got: %v", actions)
+	}
+}
+
+func TestCompare_AlterSequenceOptions(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Sequences: []*objects.Sequence{
+			{Name: "counter_seq", Type: "bigint", SequenceOptions: objects.SequenceOptions{Increment: 10}, OwnedBy: "counters.id"},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Sequences: []*objects.Sequence{
+			{Name: "counter_seq", Type: "bigint", SequenceOptions: objects.SequenceOptions{Increment: 5, Cache: 20}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	assertContains(t, actions, "ALTER SEQUENCE public.counter_seq INCREMENT BY 5 CACHE 20;")
+	assertContains(t, actions, "ALTER SEQUENCE public.counter_seq OWNED BY NONE;")
+}
+
+func TestCompare_SequenceOptionsNoop(t *testing.T) {
+	start, minValue, maxValue := int64(1), int64(1), int64(2147483647)
+	existing := []*objects.Namespace{
+		{Name: "public", Sequences: []*objects.Sequence{
+			{Name: "counter_seq", Type: "integer", SequenceOptions: objects.SequenceOptions{Start: &start, Increment: 1, MinValue: &minValue, MaxValue: &maxValue, Cache: 1}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Sequences: []*objects.Sequence{
+			{Name: "counter_seq", Type: "integer"},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+	if len(actions) != 0 {
+		t.Errorf("expected no actions, got: %v", actions)
+	}
+}
+
+func TestCompare_DropOwnedSequenceWithTable(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public",
+			Tables:    []*objects.Table{{Name: "legacy"}},
+			Sequences: []*objects.Sequence{{Name: "legacy_id_seq", Type: "integer", OwnedBy: "legacy.id"}},
+		},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public"},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	assertContains(t, actions, "DROP TABLE public.legacy;")
+	for _, action := range actions {
+		if strings.Contains(action, "DROP SEQUENCE") {
+			t.Errorf("expected owned sequence to be dropped with its table, got: %v", actions)
+		}
+	}
+}
+
+func TestCompare_DropOwnedSequenceWithColumn(t *testing.T) {
+	for _, ownedBy := range []string{"orders.legacy_id", "public.orders.legacy_id"} {
+		existing := []*objects.Namespace{
+			{Name: "public",
+				Tables: []*objects.Table{{Name: "orders", Columns: []*objects.Column{
+					{Name: "id", Type: "INTEGER"},
+					{Name: "legacy_id", Type: "INTEGER", Default: "nextval('orders_legacy_id_seq'::regclass)"},
+				}}},
+				Sequences: []*objects.Sequence{{Name: "orders_legacy_id_seq", Type: "integer", OwnedBy: ownedBy}},
+			},
+		}
+		desired := []*objects.Namespace{
+			{Name: "public", Tables: []*objects.Table{{Name: "orders", Columns: []*objects.Column{
+				{Name: "id", Type: "INTEGER"},
+			}}}},
+		}
+
+		actions := collectActions(Compare(existing, desired))
+
+		assertContains(t, actions, "ALTER TABLE public.orders DROP COLUMN legacy_id;")
+		for _, action := range actions {
+			if strings.Contains(action, "DROP SEQUENCE") {
+				t.Errorf("expected the sequence owned by %s to be dropped with its column, got: %v", ownedBy, actions)
+			}
+		}
+	}
+}
+
 func TestCompare_AddConstraint(t *testing.T) {
 	existing := []*objects.Namespace{
 		{Name: "public", Tables: []*objects.Table{
@@ -342,6 +467,268 @@ func TestCompare_ModifyIndex(t *testing.T) {
 	assertContains(t, actions, "CREATE UNIQUE INDEX idx_users_email")
 }
 
+func TestCompare_AddPartialExpressionIndex(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users"},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Indices: []*objects.Index{
+				{
+					Name:   "idx_users_email_lower",
+					Unique: true,
+					Keys: []*objects.IndexKey{
+						{Expression: "lower(email)", Opclass: "text_pattern_ops"},
+						{Column: "created_at", Order: objects.IndexOrderDesc, Nulls: objects.IndexNullsLast},
+					},
+					Include: []string{"name"},
+					Where:   "deleted_at IS NULL",
+					With:    map[string]string{"fillfactor": "70"},
+				},
+			}},
+		}},
+	}
+
+	migrators := Compare(existing, desired)
+	actions := collectActions(migrators)
+
+	assertContains(t, actions, "CREATE UNIQUE INDEX idx_users_email_lower ON public.users USING btree ((lower(email)) text_pattern_ops, created_at DESC NULLS LAST) INCLUDE (name) WITH (fillfactor=70) WHERE deleted_at IS NULL;")
+}
+
+func TestCompare_IndexMatchesIntrospectedForm(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Indices: []*objects.Index{
+				{Name: "idx_users_email", Algorithm: "btree", Columns: []string{"email"}},
+				{
+					Name:      "idx_users_active",
+					Algorithm: "btree",
+					Keys:      []*objects.IndexKey{{Expression: "lower((email)::text)"}},
+					Where:     "(deleted_at IS NULL)",
+				},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Indices: []*objects.Index{
+				{Name: "idx_users_email", Keys: []*objects.IndexKey{{Column: "email", Order: objects.IndexOrderAsc}}},
+				{
+					Name:  "idx_users_active",
+					Keys:  []*objects.IndexKey{{Expression: "lower(email)"}},
+					Where: "deleted_at is null",
+				},
+			}},
+		}},
+	}
+
+	migrators := Compare(existing, desired)
+	actions := collectActions(migrators)
+
+	if len(actions) != 0 {
+		t.Errorf("expected no actions, got: %v", actions)
+	}
+}
+
+func TestCompare_ModifyIndexPredicate(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Indices: []*objects.Index{
+				{Name: "idx_users_email", Algorithm: "btree", Columns: []string{"email"}, Where: "deleted_at IS NULL"},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Indices: []*objects.Index{
+				{Name: "idx_users_email", Algorithm: "btree", Columns: []string{"email"}},
+			}},
+		}},
+	}
+
+	migrators := Compare(existing, desired)
+	actions := collectActions(migrators)
+
+	assertContains(t, actions, "DROP INDEX public.idx_users_email")
+	assertContains(t, actions, "CREATE INDEX idx_users_email ON public.users USING btree (email);")
+}
+
+func TestCompare_ChangeIndexAlgorithm(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "events", Indices: []*objects.Index{
+				{Name: "idx_events_payload", Algorithm: "btree", Columns: []string{"payload"}},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "events", Indices: []*objects.Index{
+				{Name: "idx_events_payload", Algorithm: objects.IndexAlgorithmGIN, Keys: []*objects.IndexKey{{Column: "payload", Opclass: "jsonb_path_ops"}}},
+			}},
+		}},
+	}
+
+	migrators := Compare(existing, desired)
+	actions := collectActions(migrators)
+
+	assertContains(t, actions, "DROP INDEX public.idx_events_payload")
+	assertContains(t, actions, "CREATE INDEX idx_events_payload ON public.events USING gin (payload jsonb_path_ops);")
+}
+
+func TestCompare_AddExclusionConstraint(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "bookings"},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "bookings", Constraints: []*objects.Constraint{
+				{
+					Name: "bookings_no_overlap",
+					Type: objects.ConstraintTypeExclusion,
+					Exclusions: []*objects.ExclusionElement{
+						{IndexKey: objects.IndexKey{Column: "room_id"}, Operator: "="},
+						{IndexKey: objects.IndexKey{Column: "during"}, Operator: "&&"},
+					},
+					Where:      "NOT cancelled",
+					Deferrable: true,
+				},
+			}},
+		}},
+	}
+
+	migrators := Compare(existing, desired)
+	actions := collectActions(migrators)
+
+	assertContains(t, actions, "ALTER TABLE public.bookings ADD CONSTRAINT bookings_no_overlap EXCLUDE USING gist (room_id WITH =, during WITH &&) WHERE (NOT cancelled) DEFERRABLE INITIALLY IMMEDIATE;")
+}
+
+func TestCompare_ForeignKeyDeferralAndValidation(t *testing.T) {
+	fk := func(deferrable, notValid bool) *objects.Constraint {
+		return &objects.Constraint{
+			Name:              "orders_user_fk",
+			Type:              objects.ConstraintTypeForeignKey,
+			Targets:           []string{"user_id"},
+			Reference:         &objects.ConstraintReference{Table: "users", Columns: []string{"id"}},
+			MatchFull:         true,
+			Deferrable:        deferrable,
+			InitiallyDeferred: deferrable,
+			NotValid:          notValid,
+		}
+	}
+
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "orders", Constraints: []*objects.Constraint{fk(false, true)}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "orders", Constraints: []*objects.Constraint{fk(true, false)}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+	assertContains(t, actions, "ALTER TABLE public.orders ALTER CONSTRAINT orders_user_fk DEFERRABLE INITIALLY DEFERRED;")
+
+	desired[0].Tables[0].Constraints = []*objects.Constraint{fk(false, false)}
+	actions = collectActions(Compare(existing, desired))
+	if len(actions) != 1 || actions[0] != "ALTER TABLE public.orders VALIDATE CONSTRAINT orders_user_fk;" {
+		t.Errorf("expected only VALIDATE CONSTRAINT, got: %v", actions)
+	}
+
+	existing[0].Tables[0].Constraints = []*objects.Constraint{}
+	actions = collectActions(Compare(existing, desired))
+	assertContains(t, actions, "REFERENCES users (id) MATCH FULL")
+}
+
+func TestCompare_CrossSchemaForeignKey(t *testing.T) {
+	reference := &objects.Constraint{
+		Name:      "visits_customer_fk",
+		Type:      objects.ConstraintTypeForeignKey,
+		Targets:   []string{"customer_id"},
+		Reference: &objects.ConstraintReference{Schema: "public", Table: "customers", Columns: []string{"id"}},
+	}
+	existing := []*objects.Namespace{
+		{Name: "analytics", Tables: []*objects.Table{
+			{Name: "visits"},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "analytics", Tables: []*objects.Table{
+			{Name: "visits", Constraints: []*objects.Constraint{reference}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+	assertContains(t, actions, "ALTER TABLE analytics.visits ADD CONSTRAINT visits_customer_fk FOREIGN KEY (customer_id) REFERENCES public.customers (id);")
+
+	moved := *reference
+	moved.Reference = &objects.ConstraintReference{Schema: "archive", Table: "customers", Columns: []string{"id"}}
+	existing[0].Tables[0].Constraints = []*objects.Constraint{reference}
+	desired[0].Tables[0].Constraints = []*objects.Constraint{&moved}
+	actions = collectActions(Compare(existing, desired))
+	assertContains(t, actions, "REFERENCES archive.customers (id)")
+}
+
+func TestCompare_Comments(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Comment: comment("Registered users"), Columns: []*objects.Column{
+				{Name: "email", Type: "TEXT", Nullable: true, Comment: comment("Login address")},
+				{Name: "name", Type: "TEXT", Nullable: true},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Comment: comment("Application data"), Tables: []*objects.Table{
+			{Name: "users", Comment: comment("Registered users"), Columns: []*objects.Column{
+				{Name: "email", Type: "TEXT", Nullable: true, Comment: comment("")},
+				{Name: "name", Type: "TEXT", Nullable: true, Comment: comment("User's display name")},
+			}, Indices: []*objects.Index{
+				{Name: "idx_users_name", Columns: []string{"name"}, Comment: comment("Lookup by name")},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	assertContains(t, actions, "COMMENT ON SCHEMA public IS 'Application data';")
+	assertContains(t, actions, "COMMENT ON COLUMN public.users.email IS NULL;")
+	assertContains(t, actions, "COMMENT ON COLUMN public.users.name IS 'User''s display name';")
+	assertContains(t, actions, "COMMENT ON INDEX public.idx_users_name IS 'Lookup by name';")
+	for _, action := range actions {
+		if strings.Contains(action, "COMMENT ON TABLE") {
+			t.Errorf("expected unchanged table comment to be left alone, got: %s", action)
+		}
+	}
+}
+
+func TestCompare_UnmanagedComments(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Comment: comment("Application data"), Tables: []*objects.Table{
+			{Name: "users", Comment: comment("Registered users"), Columns: []*objects.Column{
+				{Name: "email", Type: "TEXT", Nullable: true, Comment: comment("Login address")},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "email", Type: "TEXT", Nullable: true},
+			}},
+		}},
+	}
+
+	if actions := collectActions(Compare(existing, desired)); len(actions) != 0 {
+		t.Errorf("expected omitted comments to be left alone, got: %v", actions)
+	}
+}
+
 func collectActions(migrators []*Migrator) []string {
 	var all []string
 	for _, m := range migrators {
@@ -350,6 +737,10 @@ func collectActions(migrators []*Migrator) []string {
 	return all
 }
 
+func comment(text string) *string {
+	return &text
+}
+
 func assertContains(t *testing.T, actions []string, substr string) {
 	t.Helper()
 	for _, a := range actions {
@@ -359,3 +750,1247 @@ func assertContains(t *testing.T, actions []string, substr string) {
 	}
 	t.Errorf("expected actions to contain %q, got: %v", substr, actions)
 }
+
+func TestCompareDatabase_CreateExtensionBeforeTables(t *testing.T) {
+	existing := &objects.Database{Name: "app"}
+	desired := &objects.Database{
+		Extensions: []*objects.Extension{
+			{Name: "uuid-ossp", Schema: "public"},
+			{Name: "citext", Version: "1.6"},
+		},
+		Namespaces: []*objects.Namespace{{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{{Name: "email", Type: "CITEXT", Nullable: true}}},
+		}}},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	expected := []string{
+		"CREATE SCHEMA public;",
+		`CREATE EXTENSION "uuid-ossp" SCHEMA public;`,
+		"CREATE EXTENSION citext VERSION '1.6';",
+	}
+	if len(actions) < 4 {
+		t.Fatalf("expected schema, extension and table actions, got: %v", actions)
+	}
+	for i, action := range expected {
+		if actions[i] != action {
+			t.Errorf("expected action %d to be %s, got: %s", i, action, actions[i])
+		}
+	}
+	assertContains(t, actions[3:], "CREATE TABLE public.users")
+	for _, action := range actions[3:] {
+		if strings.Contains(action, "CREATE SCHEMA") {
+			t.Errorf("expected the schema to be created once, got: %v", actions)
+		}
+	}
+}
+
+func TestCompareDatabase_CreateExtensionSchemaFirst(t *testing.T) {
+	existing := &objects.Database{Namespaces: []*objects.Namespace{{Name: "public"}}}
+	desired := &objects.Database{
+		Extensions: []*objects.Extension{{Name: "pgcrypto", Schema: "ext"}},
+		Namespaces: []*objects.Namespace{{Name: "public"}, {Name: "ext", Comment: comment("Extensions")}},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	expected := []string{
+		"CREATE SCHEMA ext;",
+		"CREATE EXTENSION pgcrypto SCHEMA ext;",
+		"COMMENT ON SCHEMA ext IS 'Extensions';",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected the schema to be created before its extension, got: %v", actions)
+	}
+}
+
+func TestCompareDatabase_DropExtensionAfterNamespaces(t *testing.T) {
+	existing := &objects.Database{
+		Extensions: []*objects.Extension{{Name: "citext", Schema: "public", Version: "1.6"}},
+		Namespaces: []*objects.Namespace{{Name: "public", Tables: []*objects.Table{{Name: "users"}}}},
+	}
+	desired := &objects.Database{
+		Extensions: []*objects.Extension{},
+		Namespaces: []*objects.Namespace{{Name: "public"}},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	if len(actions) != 2 {
+		t.Fatalf("expected 2 actions, got: %v", actions)
+	}
+	if actions[1] != "DROP EXTENSION citext;" {
+		t.Errorf("expected extension to be dropped last, got: %v", actions)
+	}
+}
+
+func TestCompareDatabase_UnmanagedExtensions(t *testing.T) {
+	existing := &objects.Database{
+		Extensions: []*objects.Extension{{Name: "citext", Schema: "public", Version: "1.6"}},
+	}
+	desired := &objects.Database{}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+	if len(actions) != 0 {
+		t.Errorf("expected no actions when extensions are omitted, got: %v", actions)
+	}
+}
+
+func TestCompareDatabase_UpdateExtension(t *testing.T) {
+	existing := &objects.Database{
+		Extensions: []*objects.Extension{{Name: "postgis", Schema: "public", Version: "3.3.0"}},
+	}
+	desired := &objects.Database{
+		Extensions: []*objects.Extension{{Name: "postgis", Schema: "extensions", Version: "3.4.0"}},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	assertContains(t, actions, "ALTER EXTENSION postgis SET SCHEMA extensions;")
+	assertContains(t, actions, "ALTER EXTENSION postgis UPDATE TO '3.4.0';")
+}
+
+func TestCompare_AddIdentity(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "INTEGER", Nullable: false, Default: "nextval('users_id_seq')"},
+			}},
+		}},
+	}
+	start := int64(1000)
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "INTEGER", Nullable: false, Identity: &objects.ColumnIdentity{
+					SequenceOptions: objects.SequenceOptions{Start: &start},
+				}},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	if len(actions) != 2 {
+		t.Fatalf("expected 2 actions, got: %v", actions)
+	}
+	if actions[0] != "ALTER TABLE public.users ALTER COLUMN id DROP DEFAULT;" {
+		t.Errorf("expected default to be dropped first, got: %s", actions[0])
+	}
+	if actions[1] != "ALTER TABLE public.users ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (START WITH 1000);" {
+		t.Errorf("expected identity to be added, got: %s", actions[1])
+	}
+}
+
+func TestCompare_DropIdentity(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "BIGINT", Identity: &objects.ColumnIdentity{Generation: objects.IdentityGenerationByDefault}},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "BIGINT", Default: "0"},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	if len(actions) != 2 || actions[0] != "ALTER TABLE public.users ALTER COLUMN id DROP IDENTITY;" {
+		t.Fatalf("expected identity to be dropped before the default is set, got: %v", actions)
+	}
+}
+
+func TestCompare_AlterIdentity(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "BIGINT", Identity: &objects.ColumnIdentity{Generation: objects.IdentityGenerationAlways}},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "BIGINT", Identity: &objects.ColumnIdentity{
+					Generation:      objects.IdentityGenerationByDefault,
+					SequenceOptions: objects.SequenceOptions{Increment: 10},
+				}},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	assertContains(t, actions, "ALTER COLUMN id SET GENERATED BY DEFAULT SET INCREMENT BY 10;")
+}
+
+func TestCompare_IdentityUnchanged(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "INTEGER", Identity: &objects.ColumnIdentity{Generation: objects.IdentityGenerationAlways}},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "INTEGER", Identity: &objects.ColumnIdentity{}},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+	if len(actions) != 0 {
+		t.Errorf("expected no actions, got: %v", actions)
+	}
+}
+
+func TestCompare_DropGeneratedExpression(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "order_items", Columns: []*objects.Column{
+				{Name: "total", Type: "INTEGER", Nullable: true, Generated: "quantity * unit_price"},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "order_items", Columns: []*objects.Column{
+				{Name: "total", Type: "INTEGER", Nullable: true},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+	assertContains(t, actions, "ALTER COLUMN total DROP EXPRESSION;")
+}
+
+func TestCompare_RefusesGeneratedConversion(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "order_items", Columns: []*objects.Column{
+				{Name: "total", Type: "INTEGER", Nullable: true},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "order_items", Columns: []*objects.Column{
+				{Name: "total", Type: "INTEGER", Nullable: true, Generated: "quantity * unit_price"},
+			}},
+		}},
+	}
+
+	migrators := Compare(existing, desired)
+	err := migrators[0].Err()
+	if err == nil {
+		t.Fatal("expected an error for an in-place generated column conversion")
+	}
+	if !strings.Contains(err.Error(), "public.order_items.total") {
+		t.Errorf("expected error to name the column, got: %v", err)
+	}
+}
+
+func TestCompare_CreateGeneratedColumn(t *testing.T) {
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "order_items", Columns: []*objects.Column{
+				{Name: "total", Type: "INTEGER", Nullable: true, Generated: "quantity * unit_price"},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(nil, desired))
+	assertContains(t, actions, "ADD COLUMN total INTEGER NULL GENERATED ALWAYS AS (quantity * unit_price) STORED;")
+}
+
+func TestCompare_AlterColumnPrecision(t *testing.T) {
+	ten, twelve := 10, 12
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "orders", Columns: []*objects.Column{
+				{Name: "total", Type: "NUMERIC", Precision: &ten, Scale: 2, Nullable: true},
+				{Name: "tags", Type: "TEXT", ArrayDimensions: 1, Nullable: true},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "orders", Columns: []*objects.Column{
+				{Name: "total", Type: "NUMERIC", Precision: &twelve, Scale: 2, Nullable: true},
+				{Name: "tags", Type: "TEXT", ArrayDimensions: 1, Nullable: true},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action, got: %v", actions)
+	}
+	assertContains(t, actions, "ALTER COLUMN total TYPE NUMERIC(12,2);")
+}
+
+func TestCompare_TypeAliasesDoNotDiff(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "INTEGER", Nullable: true},
+				{Name: "email", Type: "CHARACTER VARYING", MaxLength: 255, Nullable: true},
+				{Name: "created_at", Type: "TIMESTAMP WITH TIME ZONE", Nullable: true},
+				{Name: "active", Type: "BOOLEAN", Nullable: true},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "id", Type: "int4", Nullable: true},
+				{Name: "email", Type: "varchar(255)", Nullable: true},
+				{Name: "created_at", Type: "timestamptz", Nullable: true},
+				{Name: "active", Type: "bool", Nullable: true},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+	if len(actions) != 0 {
+		t.Errorf("expected no actions for aliased types, got: %v", actions)
+	}
+}
+
+func TestCompareDatabase_CreateRolesBeforeMemberships(t *testing.T) {
+	noInherit := false
+	existing := &objects.Database{}
+	desired := &objects.Database{
+		Roles: []*objects.Role{
+			{Name: "app", Login: true, Inherit: &noInherit, MemberOf: []string{"readers"}},
+			{Name: "readers"},
+		},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	expected := []string{
+		"CREATE ROLE app LOGIN NOINHERIT;",
+		"CREATE ROLE readers;",
+		"GRANT readers TO app;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompareDatabase_AlterAndDropRoles(t *testing.T) {
+	existing := &objects.Database{
+		Roles: []*objects.Role{
+			{Name: "app", MemberOf: []string{"readers"}},
+			{Name: "legacy", Login: true},
+			{Name: "readers"},
+			{Name: "writers"},
+		},
+		Namespaces: []*objects.Namespace{{Name: "public"}},
+	}
+	desired := &objects.Database{
+		Roles: []*objects.Role{
+			{Name: "app", Login: true, MemberOf: []string{"writers"}},
+			{Name: "readers"},
+			{Name: "writers"},
+		},
+		Namespaces: []*objects.Namespace{{Name: "public"}},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	expected := []string{
+		"ALTER ROLE app LOGIN;",
+		"REVOKE readers FROM app;",
+		"GRANT writers TO app;",
+		"DROP ROLE legacy;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompareDatabase_UnmanagedRoles(t *testing.T) {
+	existing := &objects.Database{Roles: []*objects.Role{{Name: "app"}}}
+	desired := &objects.Database{}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+	if len(actions) != 0 {
+		t.Errorf("expected no actions when roles are omitted, got: %v", actions)
+	}
+}
+
+func TestCompare_TableGrants(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Grants: []*objects.Grant{
+				{Role: "reader", Privileges: []string{"SELECT", "UPDATE"}},
+				{Role: "legacy", Privileges: []string{"SELECT"}},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "users", Grants: []*objects.Grant{
+				{Role: "reader", Privileges: []string{"select"}},
+				{Role: "writer", Privileges: []string{"ALL"}},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	expected := []string{
+		"REVOKE SELECT ON TABLE public.users FROM legacy;",
+		"REVOKE UPDATE ON TABLE public.users FROM reader;",
+		"GRANT SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER ON TABLE public.users TO writer;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_UnmanagedGrants(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Grants: []*objects.Grant{{Role: "PUBLIC", Privileges: []string{"USAGE"}}}, Tables: []*objects.Table{
+			{Name: "users", Grants: []*objects.Grant{{Role: "reader", Privileges: []string{"SELECT"}}}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{{Name: "users"}}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+	if len(actions) != 0 {
+		t.Errorf("expected no actions when grants are omitted, got: %v", actions)
+	}
+}
+
+func TestCompare_GrantsOnNewObjects(t *testing.T) {
+	desired := []*objects.Namespace{
+		{
+			Name:      "app",
+			Grants:    []*objects.Grant{{Role: "public", Privileges: []string{"USAGE"}}},
+			Sequences: []*objects.Sequence{{Name: "ids", Type: "bigint", Grants: []*objects.Grant{{Role: "writer", Privileges: []string{"USAGE"}}}}},
+			Tables: []*objects.Table{
+				{Name: "users", Grants: []*objects.Grant{{Role: "reader", Privileges: []string{"SELECT"}}}},
+			},
+		},
+	}
+
+	actions := collectActions(Compare(nil, desired))
+
+	assertContains(t, actions, "GRANT USAGE ON SCHEMA app TO PUBLIC;")
+	assertContains(t, actions, "GRANT USAGE ON SEQUENCE app.ids TO writer;")
+	assertContains(t, actions, "GRANT SELECT ON TABLE app.users TO reader;")
+	if actions[len(actions)-1] != "GRANT SELECT ON TABLE app.users TO reader;" {
+		t.Errorf("expected the table grant after the table is created, got: %v", actions)
+	}
+}
+
+func TestCompare_FunctionGrants(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Functions: []*objects.Function{
+			{Name: "add_user", Arguments: "text, integer", Grants: []*objects.Grant{{Role: "PUBLIC", Privileges: []string{"EXECUTE"}}}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Functions: []*objects.Function{
+			{Name: "add_user", Arguments: "TEXT,integer", Grants: []*objects.Grant{{Role: "app", Privileges: []string{"EXECUTE"}}}},
+		}},
+	}
+
+	m := Compare(existing, desired)[0]
+
+	expected := []string{
+		"REVOKE EXECUTE ON FUNCTION public.add_user(text, integer) FROM PUBLIC;",
+		"GRANT EXECUTE ON FUNCTION public.add_user(text, integer) TO app;",
+	}
+	if strings.Join(m.GetActions(), "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, m.GetActions())
+	}
+	if m.Err() != nil {
+		t.Errorf("expected no error, got: %v", m.Err())
+	}
+}
+
+func TestCompare_FunctionGrantsOnMissingFunction(t *testing.T) {
+	desired := []*objects.Namespace{
+		{Name: "public", Functions: []*objects.Function{
+			{Name: "add_user", Arguments: "text", Grants: []*objects.Grant{{Role: "app", Privileges: []string{"EXECUTE"}}}},
+		}},
+	}
+
+	m := Compare([]*objects.Namespace{{Name: "public"}}, desired)[0]
+
+	if m.Err() == nil || !strings.Contains(m.Err().Error(), "public.add_user(text) does not exist") {
+		t.Errorf("expected an error for the missing function, got: %v", m.Err())
+	}
+}
+
+func TestCompare_DefaultPrivileges(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", DefaultPrivileges: []*objects.DefaultPrivilege{
+			{Role: "owner", On: "TABLES", Grants: []*objects.Grant{{Role: "reader", Privileges: []string{"SELECT"}}}},
+			{Role: "owner", On: "SEQUENCES", Grants: []*objects.Grant{{Role: "reader", Privileges: []string{"USAGE"}}}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", DefaultPrivileges: []*objects.DefaultPrivilege{
+			{Role: "owner", On: "tables", Grants: []*objects.Grant{{Role: "reader", Privileges: []string{"SELECT", "INSERT"}}}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	expected := []string{
+		"ALTER DEFAULT PRIVILEGES FOR ROLE owner IN SCHEMA public REVOKE USAGE ON SEQUENCES FROM reader;",
+		"ALTER DEFAULT PRIVILEGES FOR ROLE owner IN SCHEMA public GRANT INSERT ON TABLES TO reader;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_CreatePoliciesAndEnableRLS(t *testing.T) {
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{
+				Name:             "documents",
+				Columns:          []*objects.Column{{Name: "tenant_id", Type: "UUID"}},
+				RowLevelSecurity: objects.RowLevelSecurityForced,
+				Policies: []*objects.Policy{
+					{Name: "tenant_isolation", Roles: []string{"app"}, Using: "tenant_id = current_setting('app.tenant')::uuid"},
+					{Name: "no_deletes", Command: objects.PolicyCommandDelete, Restrictive: true, Using: "false"},
+				},
+			},
+		}},
+	}
+
+	actions := collectActions(Compare(nil, desired))
+
+	expected := []string{
+		"CREATE POLICY tenant_isolation ON public.documents FOR ALL TO app USING (tenant_id = current_setting('app.tenant')::uuid);",
+		"CREATE POLICY no_deletes ON public.documents AS RESTRICTIVE FOR DELETE TO PUBLIC USING (false);",
+		"ALTER TABLE public.documents ENABLE ROW LEVEL SECURITY;",
+		"ALTER TABLE public.documents FORCE ROW LEVEL SECURITY;",
+	}
+	got := actions[len(actions)-len(expected):]
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_AlterAndRecreatePolicies(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{
+				Name:             "documents",
+				RowLevelSecurity: objects.RowLevelSecurityForced,
+				Policies: []*objects.Policy{
+					{Name: "readers", Command: objects.PolicyCommandSelect, Using: "(owner = CURRENT_USER)"},
+					{Name: "writers", Command: objects.PolicyCommandUpdate, Using: "true", WithCheck: "true"},
+					{Name: "legacy", Using: "true"},
+				},
+			},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{
+				Name:             "documents",
+				RowLevelSecurity: objects.RowLevelSecurityEnabled,
+				Policies: []*objects.Policy{
+					{Name: "readers", Command: "select", Roles: []string{"app"}, Using: "owner = current_user"},
+					{Name: "writers", Command: objects.PolicyCommandUpdate, Using: "true"},
+				},
+			},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	expected := []string{
+		"ALTER POLICY readers ON public.documents TO app USING (owner = current_user);",
+		"DROP POLICY writers ON public.documents;",
+		"CREATE POLICY writers ON public.documents FOR UPDATE TO PUBLIC USING (true);",
+		"DROP POLICY legacy ON public.documents;",
+		"ALTER TABLE public.documents NO FORCE ROW LEVEL SECURITY;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_DisableRLS(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{{Name: "documents", RowLevelSecurity: objects.RowLevelSecurityEnabled}}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{{Name: "documents", RowLevelSecurity: "disabled"}}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	if len(actions) != 1 || actions[0] != "ALTER TABLE public.documents DISABLE ROW LEVEL SECURITY;" {
+		t.Errorf("expected row level security to be disabled, got: %v", actions)
+	}
+}
+
+func TestCompare_CreatePartitionedTable(t *testing.T) {
+	desired := []*objects.Namespace{
+		{Name: "analytics", Tables: []*objects.Table{
+			{
+				Name: "page_views",
+				Columns: []*objects.Column{
+					{Name: "id", Type: "BIGINT"},
+					{Name: "viewed_at", Type: "DATE", Comment: comment("Day of the view")},
+				},
+				PartitionBy: &objects.PartitionBy{Strategy: objects.PartitionStrategyRange, Columns: []string{"viewed_at"}},
+				Partitions: []*objects.Partition{
+					{Name: "page_views_2024_01", Values: "FROM ('2024-01-01') TO ('2024-02-01')"},
+					{Name: "page_views_default", Default: true},
+				},
+			},
+		}},
+	}
+
+	actions := collectActions(Compare(nil, desired))
+
+	expected := []string{
+		"CREATE SCHEMA analytics;",
+		"CREATE TABLE analytics.page_views (id BIGINT NOT NULL, viewed_at DATE NOT NULL) PARTITION BY RANGE (viewed_at);",
+		"COMMENT ON COLUMN analytics.page_views.viewed_at IS 'Day of the view';",
+		"CREATE TABLE analytics.page_views_2024_01 PARTITION OF analytics.page_views FOR VALUES FROM ('2024-01-01') TO ('2024-02-01');",
+		"CREATE TABLE analytics.page_views_default PARTITION OF analytics.page_views DEFAULT;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_AttachAndDetachPartitions(t *testing.T) {
+	partitionBy := &objects.PartitionBy{Strategy: objects.PartitionStrategyList, Columns: []string{"region"}}
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "orders", PartitionBy: partitionBy, Partitions: []*objects.Partition{
+				{Name: "orders_eu", Values: "IN ('eu')"},
+				{Name: "orders_us", Values: "IN ('us')"},
+				{Name: "orders_apac", Values: "IN ('apac')"},
+				{Name: "orders_other", Default: true},
+			}},
+			{Name: "orders_latam"},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "orders", PartitionBy: partitionBy, Partitions: []*objects.Partition{
+				{Name: "orders_eu", Values: "IN ('eu')"},
+				{Name: "orders_us", Values: "IN ('us', 'ca')"},
+				{Name: "orders_latam", Values: "IN ('br')"},
+				{Name: "orders_africa", Values: "IN ('za')"},
+			}},
+			{Name: "orders_other"},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	expected := []string{
+		"ALTER TABLE public.orders DETACH PARTITION public.orders_us;",
+		"ALTER TABLE public.orders DETACH PARTITION public.orders_apac;",
+		"DROP TABLE public.orders_apac;",
+		"ALTER TABLE public.orders DETACH PARTITION public.orders_other;",
+		"ALTER TABLE public.orders ATTACH PARTITION public.orders_us FOR VALUES IN ('us', 'ca');",
+		"ALTER TABLE public.orders ATTACH PARTITION public.orders_latam FOR VALUES IN ('br');",
+		"CREATE TABLE public.orders_africa PARTITION OF public.orders FOR VALUES IN ('za');",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_ChangePartitionKey(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "orders", PartitionBy: &objects.PartitionBy{Strategy: objects.PartitionStrategyList, Columns: []string{"region"}}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{Name: "orders", PartitionBy: &objects.PartitionBy{Strategy: objects.PartitionStrategyHash, Columns: []string{"id"}}},
+		}},
+	}
+
+	m := Compare(existing, desired)[0]
+
+	if m.Err() == nil || !strings.Contains(m.Err().Error(), "partitioning of table public.orders cannot be changed") {
+		t.Errorf("expected an error for the changed partition key, got: %v", m.Err())
+	}
+}
+
+func TestCompare_CreateDomainsAndTypesBeforeTables(t *testing.T) {
+	desired := []*objects.Namespace{
+		{
+			Name: "app",
+			Domains: []*objects.Domain{
+				{Name: "email", Type: "citext", NotNull: true, Checks: []*objects.DomainCheck{{Name: "email_format", Expression: "VALUE ~ '@'"}}},
+			},
+			CompositeTypes: []*objects.CompositeType{
+				{Name: "money_amount", Attributes: []*objects.Attribute{{Name: "amount", Type: "numeric(12,2)"}, {Name: "currency", Type: "char(3)"}}},
+			},
+			Tables: []*objects.Table{
+				{Name: "users", Columns: []*objects.Column{{Name: "email", Type: "app.email", Nullable: true}}},
+			},
+		},
+	}
+
+	actions := collectActions(Compare(nil, desired))
+
+	expected := []string{
+		"CREATE SCHEMA app;",
+		"CREATE DOMAIN app.email AS CITEXT NOT NULL CONSTRAINT email_format CHECK (VALUE ~ '@');",
+		"CREATE TYPE app.money_amount AS (amount NUMERIC(12,2), currency CHARACTER(3));",
+		"CREATE TABLE app.users ();",
+		"ALTER TABLE app.users ADD COLUMN email APP.EMAIL NULL;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_AlterDomain(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "app", Domains: []*objects.Domain{
+			{Name: "positive", Type: "INTEGER", Checks: []*objects.DomainCheck{
+				{Name: "positive_check", Expression: "(VALUE > 0)"},
+				{Name: "small_check", Expression: "(VALUE < 100)"},
+			}},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "app", Domains: []*objects.Domain{
+			{Name: "positive", Type: "int4", Default: "1", NotNull: true, Checks: []*objects.DomainCheck{
+				{Name: "positive_check", Expression: "VALUE > 0"},
+				{Name: "small_check", Expression: "VALUE < 1000"},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	expected := []string{
+		"ALTER DOMAIN app.positive SET DEFAULT 1;",
+		"ALTER DOMAIN app.positive SET NOT NULL;",
+		"ALTER DOMAIN app.positive DROP CONSTRAINT small_check;",
+		"ALTER DOMAIN app.positive ADD CONSTRAINT small_check CHECK (VALUE < 1000);",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_ChangeDomainType(t *testing.T) {
+	existing := []*objects.Namespace{{Name: "app", Domains: []*objects.Domain{{Name: "code", Type: "TEXT"}}}}
+	desired := []*objects.Namespace{{Name: "app", Domains: []*objects.Domain{{Name: "code", Type: "varchar(10)"}}}}
+
+	m := Compare(existing, desired)[0]
+
+	if m.Err() == nil || !strings.Contains(m.Err().Error(), "type of domain app.code cannot be changed") {
+		t.Errorf("expected an error for the changed domain type, got: %v", m.Err())
+	}
+}
+
+func TestCompare_AlterAndDropTypes(t *testing.T) {
+	existing := []*objects.Namespace{
+		{
+			Name:    "app",
+			Domains: []*objects.Domain{{Name: "legacy_code", Type: "TEXT"}},
+			CompositeTypes: []*objects.CompositeType{
+				{Name: "address", Attributes: []*objects.Attribute{
+					{Name: "street", Type: "TEXT"},
+					{Name: "zip", Type: "INTEGER"},
+					{Name: "fax", Type: "TEXT"},
+				}},
+				{Name: "legacy_pair", Attributes: []*objects.Attribute{{Name: "a", Type: "TEXT"}}},
+			},
+			Tables: []*objects.Table{{Name: "users"}},
+		},
+	}
+	desired := []*objects.Namespace{
+		{
+			Name: "app",
+			CompositeTypes: []*objects.CompositeType{
+				{Name: "address", Attributes: []*objects.Attribute{
+					{Name: "street", Type: "text"},
+					{Name: "zip", Type: "varchar(10)"},
+					{Name: "country", Type: "text"},
+				}},
+			},
+		},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	expected := []string{
+		"ALTER TYPE app.address ALTER ATTRIBUTE zip TYPE CHARACTER VARYING(10);",
+		"ALTER TYPE app.address DROP ATTRIBUTE fax;",
+		"ALTER TYPE app.address ADD ATTRIBUTE country TEXT;",
+		"DROP TABLE app.users;",
+		"DROP TYPE app.legacy_pair;",
+		"DROP DOMAIN app.legacy_code;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_CreateTableWithStorage(t *testing.T) {
+	desired := []*objects.Namespace{
+		{
+			Name: "app",
+			Tables: []*objects.Table{
+				{
+					Name:       "events",
+					Unlogged:   true,
+					Tablespace: "fast_ssd",
+					With:       map[string]string{"fillfactor": "70", "autovacuum_vacuum_scale_factor": "0.01"},
+					Columns:    []*objects.Column{{Name: "name", Type: "text", Nullable: true, Collation: "C"}},
+				},
+			},
+		},
+	}
+
+	actions := collectActions(Compare(nil, desired))
+
+	expected := []string{
+		"CREATE SCHEMA app;",
+		"CREATE UNLOGGED TABLE app.events () WITH (autovacuum_vacuum_scale_factor=0.01, fillfactor=70) TABLESPACE fast_ssd;",
+		`ALTER TABLE app.events ADD COLUMN name TEXT COLLATE "C" NULL;`,
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_AlterTableStorage(t *testing.T) {
+	existing := []*objects.Namespace{
+		{
+			Name: "app",
+			Tables: []*objects.Table{
+				{
+					Name:       "events",
+					Unlogged:   true,
+					Tablespace: "fast_ssd",
+					With:       map[string]string{"fillfactor": "70", "toast.autovacuum_enabled": "false"},
+				},
+			},
+		},
+	}
+	desired := []*objects.Namespace{
+		{
+			Name: "app",
+			Tables: []*objects.Table{
+				{
+					Name: "events",
+					With: map[string]string{"fillfactor": "80", "AUTOVACUUM_ANALYZE_THRESHOLD": "500"},
+				},
+			},
+		},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	expected := []string{
+		"ALTER TABLE app.events SET LOGGED;",
+		"ALTER TABLE app.events SET TABLESPACE pg_default;",
+		"ALTER TABLE app.events RESET (toast.autovacuum_enabled);",
+		"ALTER TABLE app.events SET (autovacuum_analyze_threshold=500, fillfactor=80);",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_AlterColumnCollation(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "app", Tables: []*objects.Table{{Name: "users", Columns: []*objects.Column{
+			{Name: "name", Type: "TEXT", Nullable: true, Collation: "en_US"},
+			{Name: "code", Type: "TEXT", Nullable: true},
+			{Name: "email", Type: "TEXT", Nullable: true, Collation: "C"},
+		}}}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "app", Tables: []*objects.Table{{Name: "users", Columns: []*objects.Column{
+			{Name: "name", Type: "text", Nullable: true, Collation: "en_US"},
+			{Name: "code", Type: "text", Nullable: true, Collation: "C"},
+			{Name: "email", Type: "text", Nullable: true, Collation: "default"},
+		}}}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	expected := []string{
+		`ALTER TABLE app.users ALTER COLUMN code TYPE TEXT COLLATE "C";`,
+		"ALTER TABLE app.users ALTER COLUMN email TYPE TEXT;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestColumnOrderWarnings(t *testing.T) {
+	existing := &objects.Database{Namespaces: []*objects.Namespace{
+		{Name: "app", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{{Name: "id"}, {Name: "email"}, {Name: "legacy"}}},
+			{Name: "orders", Columns: []*objects.Column{{Name: "id"}, {Name: "total"}}},
+		}},
+	}}
+	desired := &objects.Database{Namespaces: []*objects.Namespace{
+		{Name: "app", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{{Name: "id"}, {Name: "name"}, {Name: "email"}}},
+			{Name: "orders", Columns: []*objects.Column{{Name: "id"}, {Name: "total"}, {Name: "currency"}}},
+			{Name: "invoices", Columns: []*objects.Column{{Name: "number"}, {Name: "id"}}},
+		}},
+	}}
+
+	warnings := ColumnOrderWarnings(existing, desired)
+
+	expected := []string{
+		"columns of table app.users will be ordered (id, email, name) instead of (id, name, email), as they cannot be reordered without recreating the table",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, warnings)
+	}
+}
+
+func TestCompare_CreateTableColumnsInOrder(t *testing.T) {
+	desired := []*objects.Namespace{
+		{Name: "app", Tables: []*objects.Table{
+			{Name: "users", Columns: []*objects.Column{
+				{Name: "name", Type: "text", Nullable: true},
+				{Name: "id", Type: "integer", Nullable: true},
+				{Name: "email", Type: "text", Nullable: true},
+			}},
+		}},
+	}
+
+	actions := collectActions(Compare(nil, desired))
+
+	expected := []string{
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users ();",
+		"ALTER TABLE app.users ADD COLUMN name TEXT NULL;",
+		"ALTER TABLE app.users ADD COLUMN id INTEGER NULL;",
+		"ALTER TABLE app.users ADD COLUMN email TEXT NULL;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompareDatabase_CreatePublicationsAfterTables(t *testing.T) {
+	existing := &objects.Database{}
+	desired := &objects.Database{
+		Namespaces: []*objects.Namespace{
+			{Name: "app", Tables: []*objects.Table{{Name: "orders"}}},
+		},
+		Publications: []*objects.Publication{
+			{Name: "cdc", Tables: []*objects.PublicationTable{{Table: "app.orders", Columns: []string{"id", "total"}, Where: "total > 0"}}, Publish: []string{"insert", "UPDATE"}},
+			{Name: "everything", AllTables: true, Comment: comment("Feeds the warehouse")},
+		},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	expected := []string{
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.orders ();",
+		"CREATE PUBLICATION cdc FOR TABLE app.orders (id, total) WHERE (total > 0) WITH (publish = 'insert, update');",
+		"CREATE PUBLICATION everything FOR ALL TABLES;",
+		"COMMENT ON PUBLICATION everything IS 'Feeds the warehouse';",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompareDatabase_AlterPublications(t *testing.T) {
+	existing := &objects.Database{
+		Namespaces: []*objects.Namespace{
+			{Name: "app", Tables: []*objects.Table{{Name: "orders"}, {Name: "users"}, {Name: "legacy"}}},
+		},
+		Publications: []*objects.Publication{
+			{Name: "cdc", Tables: []*objects.PublicationTable{
+				{Table: "app.legacy"},
+				{Table: "app.orders", Where: "(total > 0)"},
+			}},
+			{Name: "old", Tables: []*objects.PublicationTable{{Table: "app.users"}}},
+			{Name: "switched", AllTables: true},
+		},
+	}
+	desired := &objects.Database{
+		Namespaces: []*objects.Namespace{
+			{Name: "app", Tables: []*objects.Table{{Name: "orders"}, {Name: "users"}}},
+		},
+		Publications: []*objects.Publication{
+			{Name: "cdc", Tables: []*objects.PublicationTable{
+				{Table: "app.orders", Where: "total > 100"},
+				{Table: "app.users"},
+			}, Publish: []string{"insert"}},
+			{Name: "switched", Tables: []*objects.PublicationTable{{Table: "app.users"}}},
+		},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	expected := []string{
+		"ALTER PUBLICATION cdc DROP TABLE app.legacy;",
+		"ALTER PUBLICATION cdc DROP TABLE app.orders;",
+		"DROP PUBLICATION old;",
+		"DROP PUBLICATION switched;",
+		"DROP TABLE app.legacy;",
+		"ALTER PUBLICATION cdc ADD TABLE app.orders WHERE (total > 100);",
+		"ALTER PUBLICATION cdc ADD TABLE app.users;",
+		"ALTER PUBLICATION cdc SET (publish = 'insert');",
+		"CREATE PUBLICATION switched FOR TABLE app.users;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompareDatabase_UnmanagedPublicationsLoseDroppedTables(t *testing.T) {
+	existing := &objects.Database{
+		Namespaces: []*objects.Namespace{
+			{Name: "app", Tables: []*objects.Table{{Name: "orders"}, {Name: "legacy"}}},
+		},
+		Publications: []*objects.Publication{
+			{Name: "cdc", Tables: []*objects.PublicationTable{{Table: "app.legacy"}, {Table: "app.orders"}}},
+		},
+	}
+	desired := &objects.Database{
+		Namespaces: []*objects.Namespace{
+			{Name: "app", Tables: []*objects.Table{{Name: "orders"}}},
+		},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	expected := []string{
+		"ALTER PUBLICATION cdc DROP TABLE app.legacy;",
+		"DROP TABLE app.legacy;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompareDatabase_CreateSubscriptionsAfterTables(t *testing.T) {
+	disabled := false
+	existing := &objects.Database{}
+	desired := &objects.Database{
+		Namespaces: []*objects.Namespace{
+			{Name: "app", Tables: []*objects.Table{{Name: "orders"}}},
+		},
+		Subscriptions: []*objects.Subscription{
+			{Name: "orders_sub", Connection: "host=primary dbname=app", Publications: []string{"cdc", "audit"}, Comment: comment("Orders from the primary")},
+			{Name: "standby", Connection: "host=standby dbname=app", Publications: []string{"cdc"}, SlotName: "standby_slot", Enabled: &disabled},
+		},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	expected := []string{
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.orders ();",
+		"CREATE SUBSCRIPTION orders_sub CONNECTION 'host=primary dbname=app' PUBLICATION audit, cdc WITH (create_slot = false, enabled = false);",
+		"ALTER SUBSCRIPTION orders_sub ENABLE;",
+		"COMMENT ON SUBSCRIPTION orders_sub IS 'Orders from the primary';",
+		"CREATE SUBSCRIPTION standby CONNECTION 'host=standby dbname=app' PUBLICATION cdc WITH (create_slot = false, enabled = false, slot_name = 'standby_slot');",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompareDatabase_AlterSubscriptions(t *testing.T) {
+	disabled := false
+	existing := &objects.Database{
+		Namespaces: []*objects.Namespace{
+			{Name: "app", Tables: []*objects.Table{{Name: "orders"}, {Name: "legacy"}}},
+		},
+		Subscriptions: []*objects.Subscription{
+			{Name: "orders_sub", Connection: "host=primary dbname=app", Publications: []string{"cdc"}},
+			{Name: "moved", Connection: "host=old dbname=app", Publications: []string{"cdc"}},
+			{Name: "paused", Publications: []string{"cdc"}, Enabled: &disabled},
+			{Name: "legacy_sub", Connection: "host=primary dbname=app", Publications: []string{"legacy"}},
+		},
+	}
+	desired := &objects.Database{
+		Namespaces: []*objects.Namespace{
+			{Name: "app", Tables: []*objects.Table{{Name: "orders"}}},
+		},
+		Subscriptions: []*objects.Subscription{
+			{Name: "orders_sub", Connection: "host=primary dbname=app", Publications: []string{"cdc", "audit"}, SlotName: "orders"},
+			{Name: "moved", Connection: "host=new dbname=app", Publications: []string{"cdc"}, Enabled: &disabled},
+			{Name: "paused", Connection: "host=primary dbname=app", Publications: []string{"cdc"}},
+		},
+	}
+
+	actions := collectActions(CompareDatabase(existing, desired))
+
+	expected := []string{
+		"ALTER SUBSCRIPTION legacy_sub DISABLE;",
+		"ALTER SUBSCRIPTION legacy_sub SET (slot_name = NONE);",
+		"DROP SUBSCRIPTION legacy_sub;",
+		"DROP TABLE app.legacy;",
+		"ALTER SUBSCRIPTION orders_sub DISABLE;",
+		"ALTER SUBSCRIPTION orders_sub SET (slot_name = 'orders');",
+		"ALTER SUBSCRIPTION orders_sub SET PUBLICATION audit, cdc WITH (refresh = false);",
+		"ALTER SUBSCRIPTION orders_sub ENABLE;",
+		"ALTER SUBSCRIPTION moved CONNECTION 'host=new dbname=app';",
+		"ALTER SUBSCRIPTION moved DISABLE;",
+		"ALTER SUBSCRIPTION paused ENABLE;",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompareDatabase_UnmanagedSubscriptions(t *testing.T) {
+	existing := &objects.Database{
+		Subscriptions: []*objects.Subscription{
+			{Name: "orders_sub", Connection: "host=primary dbname=app", Publications: []string{"cdc"}},
+		},
+	}
+
+	if actions := collectActions(CompareDatabase(existing, &objects.Database{})); len(actions) != 0 {
+		t.Errorf("expected unmanaged subscriptions to be left alone, got: %v", actions)
+	}
+}
+
+func TestCompare_QuoteIdentifiers(t *testing.T) {
+	desired := []*objects.Namespace{
+		{Name: "Sales", Tables: []*objects.Table{
+			{
+				Name:    "order",
+				Comment: comment("Placed orders"),
+				Columns: []*objects.Column{
+					{Name: "id", Type: "SERIAL", IsPrimaryKey: true},
+					{Name: "createdAt", Type: "TIMESTAMP", Comment: comment("When the order was placed")},
+					{Name: "user", Type: "TEXT"},
+				},
+				Constraints: []*objects.Constraint{
+					{Name: "order-user", Type: objects.ConstraintTypeForeignKey, Targets: []string{"user"}, Reference: &objects.ConstraintReference{Schema: "auth", Table: "user", Columns: []string{"name"}}},
+				},
+				Indices: []*objects.Index{
+					{Name: "order_createdAt_idx", Columns: []string{"createdAt"}, Include: []string{"user"}},
+				},
+			},
+		}},
+	}
+	if err := desired[0].Normalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	actions := collectActions(Compare(nil, desired))
+
+	expected := []string{
+		`CREATE SCHEMA "Sales";`,
+		`CREATE SEQUENCE "Sales".order_id_seq AS integer;`,
+		`CREATE TABLE "Sales"."order" ();`,
+		`COMMENT ON TABLE "Sales"."order" IS 'Placed orders';`,
+		`ALTER TABLE "Sales"."order" ADD COLUMN id INTEGER NOT NULL DEFAULT nextval('"Sales".order_id_seq');`,
+		`ALTER TABLE "Sales"."order" ADD COLUMN "createdAt" TIMESTAMP WITHOUT TIME ZONE NOT NULL;`,
+		`COMMENT ON COLUMN "Sales"."order"."createdAt" IS 'When the order was placed';`,
+		`ALTER TABLE "Sales"."order" ADD COLUMN "user" TEXT NOT NULL;`,
+		`ALTER TABLE "Sales"."order" ADD CONSTRAINT order_pkey PRIMARY KEY (id);`,
+		`ALTER TABLE "Sales"."order" ADD CONSTRAINT "order-user" FOREIGN KEY ("user") REFERENCES auth."user" (name);`,
+		`CREATE INDEX "order_createdAt_idx" ON "Sales"."order" USING btree ("createdAt") INCLUDE ("user");`,
+		`ALTER SEQUENCE "Sales".order_id_seq OWNED BY "Sales"."order".id;`,
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_AlterQuotedIdentifiers(t *testing.T) {
+	existing := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{
+				Name: "user",
+				Columns: []*objects.Column{
+					{Name: "Name", Type: "TEXT"},
+					{Name: "group", Type: "TEXT", Nullable: true},
+				},
+				Constraints: []*objects.Constraint{
+					{Name: "user_Name_key", Type: objects.ConstraintTypeUnique, Targets: []string{"Name"}},
+				},
+				Policies: []*objects.Policy{{Name: "Own rows", Using: "true"}},
+			},
+		}},
+	}
+	desired := []*objects.Namespace{
+		{Name: "public", Tables: []*objects.Table{
+			{
+				Name: "user",
+				Columns: []*objects.Column{
+					{Name: "Name", Type: "VARCHAR(100)"},
+				},
+			},
+		}},
+	}
+
+	actions := collectActions(Compare(existing, desired))
+
+	expected := []string{
+		`ALTER TABLE public."user" ALTER COLUMN "Name" TYPE CHARACTER VARYING(100);`,
+		`ALTER TABLE public."user" DROP COLUMN "group";`,
+		`ALTER TABLE public."user" DROP CONSTRAINT "user_Name_key";`,
+		`DROP POLICY "Own rows" ON public."user";`,
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got: %v", expected, actions)
+	}
+}
+
+func TestCompare_SerialMatchesReportedDefault(t *testing.T) {
+	// PostgreSQL reports sequences outside the search path qualified and
+	// those on it, as public usually is, bare.
+	for _, tc := range []struct{ namespace, reported string }{
+		{"App", `nextval('"App".items_id_seq'::regclass)`},
+		{"App", "nextval('items_id_seq'::regclass)"},
+		{"sales", "nextval('items_id_seq'::regclass)"},
+		{"public", "nextval('items_id_seq'::regclass)"},
+	} {
+		namespace, reported := tc.namespace, tc.reported
+		existing := []*objects.Namespace{
+			{Name: namespace, Sequences: []*objects.Sequence{{Name: "items_id_seq", Type: "integer", OwnedBy: "items.id"}}, Tables: []*objects.Table{
+				{Name: "items", Columns: []*objects.Column{{Name: "id", Type: "INTEGER", Default: reported}}},
+			}},
+		}
+		desired := []*objects.Namespace{
+			{Name: namespace, Tables: []*objects.Table{
+				{Name: "items", Columns: []*objects.Column{{Name: "id", Type: "serial"}}},
+			}},
+		}
+		if err := desired[0].Normalize(); err != nil {
+			t.Fatal(err)
+		}
+
+		if actions := collectActions(Compare(existing, desired)); len(actions) != 0 {
+			t.Errorf("expected no actions for a serial column in %s reported as %s, got: %v", namespace, reported, actions)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package state
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"stijntratsaertit/terramigrate/objects"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// decodeYAML decodes a desired state file into req and returns every problem
+// found in it. The file is parsed once, and the parsed document is walked
+// along with the decoded request, so that each mapping is matched with the
+// object decoded from it: keys that match no field are reported, rather than
+// silently ignored, and the line every object is declared on is recorded in
+// found to locate later errors.
+func decodeYAML(data []byte, file string, req *Request, found objects.Positions) objects.ValidationErrors {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return decodeErrors(file, err)
+	}
+	if document.Kind == 0 {
+		return nil
+	}
+
+	problems := objects.ValidationErrors{}
+	if err := document.Decode(req); err != nil {
+		problems = decodeErrors(file, err)
+	}
+	problems = append(problems, locate(&document, reflect.ValueOf(req), file, found)...)
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+var decodeErrorRegex = regexp.MustCompile(`line (\d+): (.*)`)
+
+// decodeErrors splits the error of decoding a file into one error per
+// problem, located by the line yaml reports it on.
+func decodeErrors(file string, err error) objects.ValidationErrors {
+	messages := []string{err.Error()}
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	}
+
+	problems := objects.ValidationErrors{}
+	for _, msg := range messages {
+		problem := &objects.ValidationError{Position: objects.Position{File: file}, Err: fmt.Errorf("could not unmarshal yaml: %s", msg)}
+		if match := decodeErrorRegex.FindStringSubmatch(msg); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+			problem.Err = fmt.Errorf("%s", match[2])
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// locate records the position of the objects decoded from node into value
+// and returns the keys that match no field.
+func locate(node *yaml.Node, value reflect.Value, file string, found objects.Positions) objects.ValidationErrors {
+	unknown := objects.ValidationErrors{}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			unknown = append(unknown, locate(child, value, file, found)...)
+		}
+	case yaml.MappingNode:
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return nil
+			}
+			found[value.Interface()] = objects.Position{File: file, Line: node.Line}
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			return nil
+		}
+		fields := map[string]reflect.Value{}
+		yamlFields(value, fields)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if field, ok := fields[key.Value]; ok {
+				unknown = append(unknown, locate(node.Content[i+1], field, file, found)...)
+			} else if key.Value != "<<" {
+				unknown = append(unknown, &objects.ValidationError{Position: objects.Position{File: file, Line: key.Line}, Err: fmt.Errorf("unknown field %s", key.Value)})
+			}
+		}
+	case yaml.SequenceNode:
+		if value.Kind() != reflect.Slice {
+			return nil
+		}
+		for i, item := range node.Content {
+			if i < value.Len() {
+				unknown = append(unknown, locate(item, value.Index(i), file, found)...)
+			}
+		}
+	}
+	return unknown
+}
+
+// yamlFields collects the fields of a struct by their YAML key, including
+// those of inlined structs.
+func yamlFields(value reflect.Value, fields map[string]reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if len(tag) > 1 && tag[1] == "inline" {
+			yamlFields(value.Field(i), fields)
+			continue
+		}
+		if tag[0] != "" && tag[0] != "-" {
+			fields[tag[0]] = value.Field(i)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"stijntratsaertit/terramigrate/objects"
+	"strings"
+)
+
+// schemaEnum lists the values a string type of the desired state takes. When
+// anyCase is set the objects package normalizes the case, so both the upper
+// and lower case spellings are accepted.
+type schemaEnum struct {
+	values  []string
+	anyCase bool
+}
+
+// schemaEnums holds the values of every named string type of the desired
+// state. Generating the schema fails for a type that is not listed, so that a
+// new type cannot be left out.
+var schemaEnums = map[reflect.Type]schemaEnum{
+	reflect.TypeOf(objects.ConstraintType("")):     {values: enumValues(objects.ConstraintTypes())},
+	reflect.TypeOf(objects.ConstraintAction("")):   {values: enumValues(objects.ConstraintActions())},
+	reflect.TypeOf(objects.IndexAlgorithm("")):     {values: enumValues(objects.IndexAlgorithms()), anyCase: true},
+	reflect.TypeOf(objects.IndexOrder("")):         {values: enumValues([]objects.IndexOrder{objects.IndexOrderAsc, objects.IndexOrderDesc}), anyCase: true},
+	reflect.TypeOf(objects.IndexNulls("")):         {values: enumValues([]objects.IndexNulls{objects.IndexNullsFirst, objects.IndexNullsLast}), anyCase: true},
+	reflect.TypeOf(objects.PartitionStrategy("")):  {values: enumValues(objects.PartitionStrategies()), anyCase: true},
+	reflect.TypeOf(objects.PolicyCommand("")):      {values: enumValues(objects.PolicyCommands()), anyCase: true},
+	reflect.TypeOf(objects.IdentityGeneration("")): {values: enumValues([]objects.IdentityGeneration{objects.IdentityGenerationAlways, objects.IdentityGenerationByDefault})},
+	reflect.TypeOf(objects.RowLevelSecurity("")):   {values: []string{string(objects.RowLevelSecurityEnabled), string(objects.RowLevelSecurityForced), "disabled"}, anyCase: true},
+}
+
+// schemaFieldEnums restricts plain string fields, keyed by struct and field
+// name. For lists the values apply to the items.
+var schemaFieldEnums = map[string]schemaEnum{
+	"DefaultPrivilege.On": {values: objects.DefaultPrivilegeObjects(), anyCase: true},
+	"Publication.Publish": {values: objects.PublicationOperations(), anyCase: true},
+}
+
+func enumValues[T ~string](values []T) []string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = string(v)
+	}
+	return strs
+}
+
+// JSONSchema returns the JSON Schema of the desired state YAML, generated from
+// the types it is decoded into. Keys are checked strictly as when decoding,
+// fields tagged jsonschema:"required" are required, and the string types that
+// only take certain values list them.
+func JSONSchema() ([]byte, error) {
+	g := &schemaGenerator{definitions: map[string]interface{}{}}
+	root, err := g.object(reflect.TypeOf(Request{}))
+	if err != nil {
+		return nil, err
+	}
+
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["title"] = "terramigrate desired state"
+	root["definitions"] = g.definitions
+
+	schema, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not encode JSON schema: %v", err)
+	}
+	return append(schema, '\n'), nil
+}
+
+type schemaGenerator struct {
+	definitions map[string]interface{}
+}
+
+// object returns the schema of a struct, with the fields of inlined structs
+// merged in.
+func (g *schemaGenerator) object(t reflect.Type) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	required := []string{}
+	if err := g.fields(t, properties, &required); err != nil {
+		return nil, err
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+func (g *schemaGenerator) fields(t reflect.Type, properties map[string]interface{}, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("yaml")
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if strings.Contains(options, "inline") {
+			if err := g.fields(field.Type, properties, required); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		schema, err := g.schema(field.Type)
+		if err != nil {
+			return fmt.Errorf("could not describe field %s.%s: %v", t.Name(), field.Name, err)
+		}
+		if enum, ok := schemaFieldEnums[t.Name()+"."+field.Name]; ok {
+			if items, ok := schema["items"].(map[string]interface{}); ok {
+				items["enum"] = enum.spellings()
+			} else {
+				schema["enum"] = enum.spellings()
+			}
+		}
+		properties[name] = schema
+		if field.Tag.Get("jsonschema") == "required" {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// schema returns the schema of a field type. Structs are described once under
+// definitions and referred to. Lists, maps and pointers may be left empty with
+// null, as export writes them, but list items may not be null.
+func (g *schemaGenerator) schema(t reflect.Type) (map[string]interface{}, error) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullable(schema), nil
+	case reflect.Struct:
+		if _, ok := g.definitions[t.Name()]; !ok {
+			g.definitions[t.Name()] = nil
+			definition, err := g.object(t)
+			if err != nil {
+				return nil, err
+			}
+			g.definitions[t.Name()] = definition
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}, nil
+	case reflect.Slice:
+		elem := t.Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		items, err := g.schema(elem)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": items}, nil
+	case reflect.Map:
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": []string{"object", "null"}, "additionalProperties": values}, nil
+	case reflect.String:
+		if t.PkgPath() == "" {
+			return map[string]interface{}{"type": "string"}, nil
+		}
+		enum, ok := schemaEnums[t]
+		if !ok {
+			return nil, fmt.Errorf("no values are listed for type %s", t)
+		}
+		return map[string]interface{}{"type": "string", "enum": enum.spellings()}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// spellings returns the values of the enum, in both cases when the case does
+// not matter.
+func (e schemaEnum) spellings() []string {
+	if !e.anyCase {
+		return e.values
+	}
+	spellings := []string{}
+	for _, v := range e.values {
+		for _, spelling := range []string{v, strings.ToLower(v), strings.ToUpper(v)} {
+			if !containsString(spellings, spelling) {
+				spellings = append(spellings, spelling)
+			}
+		}
+	}
+	return spellings
+}
+
+// nullable allows null in place of the value the schema describes.
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	switch types := schema["type"].(type) {
+	case string:
+		schema["type"] = []string{types, "null"}
+	case []string:
+		if !containsString(types, "null") {
+			schema["type"] = append(types, "null")
+		}
+	default:
+		return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+	}
+	return schema
+}
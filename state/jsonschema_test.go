@@ -0,0 +1,259 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func loadJSONSchema(t *testing.T) map[string]interface{} {
+	t.Helper()
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("could not generate JSON schema: %v", err)
+	}
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("could not decode JSON schema: %v", err)
+	}
+	return schema
+}
+
+func schemaProperty(t *testing.T, schema map[string]interface{}, definition, property string) map[string]interface{} {
+	t.Helper()
+	def, ok := schema["definitions"].(map[string]interface{})[definition].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected definition %s", definition)
+	}
+	prop, ok := def["properties"].(map[string]interface{})[property].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected property %s.%s", definition, property)
+	}
+	return prop
+}
+
+func TestJSONSchema_Enums(t *testing.T) {
+	schema := loadJSONSchema(t)
+
+	tests := []struct {
+		definition, property string
+		values               []string
+	}{
+		{"Constraint", "type", []string{"PRIMARY KEY", "UNIQUE", "FOREIGN KEY", "CHECK", "EXCLUDE"}},
+		{"Constraint", "on_delete", []string{"NO ACTION", "RESTRICT", "CASCADE", "SET NULL", "SET DEFAULT"}},
+		{"Index", "algorithm", []string{"btree", "BTREE", "gin", "GIN", "brin"}},
+		{"DefaultPrivilege", "on", []string{"TABLES", "tables", "SEQUENCES"}},
+	}
+	for _, tt := range tests {
+		enum, ok := schemaProperty(t, schema, tt.definition, tt.property)["enum"].([]interface{})
+		if !ok {
+			t.Errorf("expected %s.%s to list its values", tt.definition, tt.property)
+			continue
+		}
+		for _, value := range tt.values {
+			found := false
+			for _, v := range enum {
+				found = found || v == value
+			}
+			if !found {
+				t.Errorf("expected %s.%s to accept %q, got %v", tt.definition, tt.property, value, enum)
+			}
+		}
+	}
+
+	publish := schemaProperty(t, schema, "Publication", "publish")["items"].(map[string]interface{})
+	if _, ok := publish["enum"]; !ok {
+		t.Errorf("expected the publish items to list their values, got %v", publish)
+	}
+}
+
+func TestJSONSchema_Required(t *testing.T) {
+	schema := loadJSONSchema(t)
+	definitions := schema["definitions"].(map[string]interface{})
+
+	for definition, want := range map[string][]interface{}{
+		"Column":     {"name", "type"},
+		"Constraint": {"name", "type"},
+		"Table":      {"name"},
+		"Namespace":  {"name"},
+	} {
+		got := definitions[definition].(map[string]interface{})["required"]
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %s to require %v, got %v", definition, want, got)
+		}
+	}
+	if _, ok := definitions["Table"].(map[string]interface{})["properties"].(map[string]interface{})["columns"]; !ok {
+		t.Error("expected Table to describe its columns")
+	}
+	if _, ok := schema["required"]; ok {
+		t.Errorf("expected no top-level key to be required, got %v", schema["required"])
+	}
+}
+
+func TestJSONSchema_InlinedAndNullable(t *testing.T) {
+	schema := loadJSONSchema(t)
+
+	// The sequence options and index keys are inlined into their parents.
+	for _, key := range [][2]string{{"Sequence", "increment"}, {"Sequence", "owned_by"}, {"Index", "columns"}} {
+		schemaProperty(t, schema, key[0], key[1])
+	}
+
+	// An absent reference may be written as null.
+	reference := schemaProperty(t, schema, "Constraint", "reference")
+	if !strings.Contains(mustMarshal(t, reference), `"type":"null"`) {
+		t.Errorf("expected reference to accept null, got %v", reference)
+	}
+}
+
+func TestJSONSchema_UnlistedType(t *testing.T) {
+	type unlisted string
+	g := &schemaGenerator{definitions: map[string]interface{}{}}
+	if _, err := g.schema(reflect.TypeOf(unlisted(""))); err == nil || !strings.Contains(err.Error(), "no values are listed") {
+		t.Errorf("expected an error for a type without values, got %v", err)
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestJSONSchema_AcceptsExport(t *testing.T) {
+	schema := loadJSONSchema(t)
+	examples, err := filepath.Glob(filepath.Join("..", "examples", "*.yaml"))
+	if err != nil || len(examples) == 0 {
+		t.Fatalf("could not find the examples: %v", err)
+	}
+
+	for _, example := range examples {
+		req, err := LoadYAML(example)
+		if err != nil {
+			t.Fatalf("could not load %s: %v", example, err)
+		}
+		s := &State{Database: req.Database()}
+		for _, templates := range []bool{false, true} {
+			path := filepath.Join(t.TempDir(), "exported.yaml")
+			if err := s.Request(templates).WriteYAML(path); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var exported interface{}
+			if err := yaml.Unmarshal(data, &exported); err != nil {
+				t.Fatal(err)
+			}
+			for _, problem := range validateJSONSchema(schema, schema, exported, "") {
+				t.Errorf("export of %s does not match the schema: %s", filepath.Base(example), problem)
+			}
+		}
+	}
+}
+
+// validateJSONSchema checks value against the parts of JSON Schema the
+// generated schema uses and returns the problems found.
+func validateJSONSchema(root, schema map[string]interface{}, value interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		definition := root["definitions"].(map[string]interface{})[strings.TrimPrefix(ref, "#/definitions/")]
+		return validateJSONSchema(root, definition.(map[string]interface{}), value, path)
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		problems := []string{}
+		for _, s := range anyOf {
+			found := validateJSONSchema(root, s.(map[string]interface{}), value, path)
+			if len(found) == 0 {
+				return nil
+			}
+			problems = append(problems, found...)
+		}
+		return problems
+	}
+
+	if types, ok := schema["type"]; ok && !jsonTypeMatches(types, value) {
+		return []string{fmt.Sprintf("%s: %v is not of type %v", path, value, types)}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && value != nil {
+		found := false
+		for _, v := range enum {
+			found = found || v == value
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %q is not one of %v", path, value, enum)}
+		}
+	}
+
+	problems := []string{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, item := range v {
+			if property, ok := properties[key].(map[string]interface{}); ok {
+				problems = append(problems, validateJSONSchema(root, property, item, path+"."+key)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				problems = append(problems, validateJSONSchema(root, additional, item, path+"."+key)...)
+			} else if schema["additionalProperties"] == false {
+				problems = append(problems, fmt.Sprintf("%s: unknown key %s", path, key))
+			}
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := v[key.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing key %s", path, key))
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				problems = append(problems, validateJSONSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+func jsonTypeMatches(types interface{}, value interface{}) bool {
+	names := []interface{}{types}
+	if list, ok := types.([]interface{}); ok {
+		names = list
+	}
+	for _, name := range names {
+		switch value.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case int:
+			if name == "integer" {
+				return true
+			}
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package state
+
+import (
+	"fmt"
+	"stijntratsaertit/terramigrate/objects"
+)
+
+// sources remembers the file each merged object was declared in, so that a
+// conflict can name both files, and collects the conflicts found.
+type sources struct {
+	files     map[string]string
+	positions objects.Positions
+	conflicts objects.ValidationErrors
+}
+
+func newSources(positions objects.Positions) *sources {
+	return &sources{files: map[string]string{}, positions: positions}
+}
+
+// claim records that object, declared by decl in file, is merged. When the
+// object was declared before, the conflict is recorded, located at decl, and
+// claim returns false.
+func (s *sources) claim(object string, decl interface{}, file string) bool {
+	other, ok := s.files[object]
+	if !ok {
+		s.files[object] = file
+		return true
+	}
+
+	err := fmt.Errorf("%s is declared in both %s and %s", object, other, file)
+	if other == file {
+		err = fmt.Errorf("%s is declared twice in %s", object, file)
+	}
+	position, ok := s.positions[decl]
+	if !ok {
+		position = objects.Position{File: file}
+	}
+	s.conflicts = append(s.conflicts, &objects.ValidationError{Position: position, Err: err})
+	return false
+}
+
+// merge adds the objects of a request read from file to r. Namespaces may be
+// spread over several files, but every object, and every setting of a
+// namespace, must be declared in one file only. Lists that are left out stay
+// unmanaged unless another file declares them, if only as empty.
+func (r *Request) merge(from *Request, file string, seen *sources) {
+	if from.Roles != nil && r.Roles == nil {
+		r.Roles = []*objects.Role{}
+	}
+	for _, role := range from.Roles {
+		if seen.claim("role "+role.Name, role, file) {
+			r.Roles = append(r.Roles, role)
+		}
+	}
+
+	if from.Extensions != nil && r.Extensions == nil {
+		r.Extensions = []*objects.Extension{}
+	}
+	for _, ext := range from.Extensions {
+		if seen.claim("extension "+ext.Name, ext, file) {
+			r.Extensions = append(r.Extensions, ext)
+		}
+	}
+
+	for _, template := range from.Templates {
+		if seen.claim("template "+template.Name, template, file) {
+			r.Templates = append(r.Templates, template)
+		}
+	}
+
+	if from.Publications != nil && r.Publications == nil {
+		r.Publications = []*objects.Publication{}
+	}
+	for _, pub := range from.Publications {
+		if seen.claim("publication "+pub.Name, pub, file) {
+			r.Publications = append(r.Publications, pub)
+		}
+	}
+
+	if from.Subscriptions != nil && r.Subscriptions == nil {
+		r.Subscriptions = []*objects.Subscription{}
+	}
+	for _, sub := range from.Subscriptions {
+		if seen.claim("subscription "+sub.Name, sub, file) {
+			r.Subscriptions = append(r.Subscriptions, sub)
+		}
+	}
+
+	for _, ns := range from.Namespaces {
+		var target *objects.Namespace
+		for _, existing := range r.Namespaces {
+			if existing.Name == ns.Name {
+				target = existing
+				break
+			}
+		}
+		if target == nil {
+			target = &objects.Namespace{Name: ns.Name}
+			r.Namespaces = append(r.Namespaces, target)
+		}
+		mergeNamespace(target, ns, file, seen)
+	}
+}
+
+func mergeNamespace(target, from *objects.Namespace, file string, seen *sources) {
+	if from.Comment != nil && seen.claim("comment of schema "+from.Name, from, file) {
+		target.Comment = from.Comment
+	}
+	if from.Grants != nil && seen.claim("grants of schema "+from.Name, from, file) {
+		target.Grants = from.Grants
+	}
+	if from.DefaultPrivileges != nil && seen.claim("default privileges of schema "+from.Name, from, file) {
+		target.DefaultPrivileges = from.DefaultPrivileges
+	}
+
+	for _, domain := range from.Domains {
+		if seen.claim(fmt.Sprintf("type %s.%s", from.Name, domain.Name), domain, file) {
+			target.Domains = append(target.Domains, domain)
+		}
+	}
+	for _, typ := range from.CompositeTypes {
+		if seen.claim(fmt.Sprintf("type %s.%s", from.Name, typ.Name), typ, file) {
+			target.CompositeTypes = append(target.CompositeTypes, typ)
+		}
+	}
+	for _, table := range from.Tables {
+		if seen.claim(fmt.Sprintf("table %s.%s", from.Name, table.Name), table, file) {
+			target.Tables = append(target.Tables, table)
+		}
+	}
+	for _, seq := range from.Sequences {
+		if seen.claim(fmt.Sprintf("sequence %s.%s", from.Name, seq.Name), seq, file) {
+			target.Sequences = append(target.Sequences, seq)
+		}
+	}
+	for _, function := range from.Functions {
+		if seen.claim("function "+function.Signature(from.Name), function, file) {
+			target.Functions = append(target.Functions, function)
+		}
+	}
+}
@@ -1,46 +1,203 @@
 package state
 
 import (
+	"bytes"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"stijntratsaertit/terramigrate/objects"
+	"strings"
 
-	"gopkg.in/yaml.v2"
+	"go.yaml.in/yaml/v3"
 )
 
 type Request struct {
-	Namespaces []*objects.Namespace `yaml:"namespaces"`
+	Roles         []*objects.Role         `yaml:"roles,omitempty"`
+	Extensions    []*objects.Extension    `yaml:"extensions,omitempty"`
+	Templates     []*objects.Template     `yaml:"templates,omitempty"`
+	Namespaces    []*objects.Namespace    `yaml:"namespaces"`
+	Publications  []*objects.Publication  `yaml:"publications,omitempty"`
+	Subscriptions []*objects.Subscription `yaml:"subscriptions,omitempty"`
+
+	positions objects.Positions
+}
+
+// Database returns the desired database described by the request.
+func (r *Request) Database() *objects.Database {
+	return &objects.Database{Roles: r.Roles, Extensions: r.Extensions, Namespaces: r.Namespaces, Publications: r.Publications, Subscriptions: r.Subscriptions, Positions: r.positions}
 }
 
+// LoadYAML reads the desired state from path, which is a single file, a
+// directory whose YAML files are all read, or a glob. The files are merged in
+// lexical order.
 func LoadYAML(path string) (*Request, error) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil, fmt.Errorf("file %s does not exist", path)
+	files, err := sourceFiles(path)
+	if err != nil {
+		return nil, err
 	}
 
-	yamlFile, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("could not read file %s: %v", path, err)
+	req := &Request{positions: objects.Positions{}}
+	seen := newSources(req.positions)
+	problems := objects.ValidationErrors{}
+	for _, file := range files {
+		yamlFile, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read file %s: %v", file, err)
+		}
+
+		fragment := &Request{}
+		if errs := decodeYAML(yamlFile, file, fragment, req.positions); len(errs) > 0 {
+			problems = append(problems, errs...)
+			continue
+		}
+		req.merge(fragment, file, seen)
+	}
+	if problems = append(problems, seen.conflicts...); len(problems) > 0 {
+		return nil, problems
 	}
 
-	req := &Request{}
-	err = yaml.Unmarshal(yamlFile, req)
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal yaml: %v", err)
+	for _, err := range []error{
+		objects.ExpandTemplates(req.Templates, req.Namespaces, req.positions),
+		objects.NormalizeNamespaces(req.Namespaces, req.positions),
+	} {
+		if errs, ok := err.(objects.ValidationErrors); ok {
+			problems = append(problems, errs...)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, problems
 	}
 
 	return req, nil
 }
 
+// sourceFiles resolves the path of the desired state to the files it is made
+// of. Directories, including those matched by a glob, are searched for .yaml
+// and .yml files recursively.
+func sourceFiles(path string) ([]string, error) {
+	paths := []string{path}
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %s: %v", path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", path)
+		}
+		paths = matches
+	}
+
+	files := []string{}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file %s does not exist", p)
+		} else if err != nil {
+			return nil, fmt.Errorf("could not read file %s: %v", p, err)
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+
+		found := 0
+		err = filepath.WalkDir(p, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ext := filepath.Ext(file); !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, file)
+				found++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not read directory %s: %v", p, err)
+		}
+		if found == 0 {
+			return nil, fmt.Errorf("no YAML files found in %s", p)
+		}
+	}
+	return files, nil
+}
+
+// Request returns the state in the form of a desired state file. With
+// templates set, the leading columns tables share are factored out into
+// templates.
+func (s *State) Request(templates bool) *Request {
+	req := &Request{
+		Roles:         s.Database.Roles,
+		Extensions:    s.Database.Extensions,
+		Namespaces:    s.Database.Namespaces,
+		Publications:  s.Database.Publications,
+		Subscriptions: s.Database.Subscriptions,
+	}
+	if templates {
+		req.Templates, req.Namespaces = objects.FactorTemplates(req.Namespaces)
+	}
+	return req
+}
+
 func (s *State) ExportYAML(path string) error {
-	yamlFile, err := yaml.Marshal(Request{Namespaces: s.Database.Namespaces})
-	if err != nil {
+	return s.Request(false).WriteYAML(path)
+}
+
+// WriteYAML writes the request to a desired state file.
+func (r *Request) WriteYAML(path string) error {
+	var yamlFile bytes.Buffer
+	encoder := yaml.NewEncoder(&yamlFile)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("could not marshal yaml: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
 		return fmt.Errorf("could not marshal yaml: %v", err)
 	}
 
-	err = os.WriteFile(path, yamlFile, 0644)
+	err := os.WriteFile(path, yamlFile.Bytes(), 0644)
 	if err != nil {
 		return fmt.Errorf("could not write file %s: %v", path, err)
 	}
 
 	return nil
 }
+
+// WriteYAMLSplit writes the request into dir the way LoadYAML reads it back:
+// database-wide objects in database.yaml, every namespace without its tables
+// in <namespace>/namespace.yaml and every table in
+// <namespace>/tables/<table>.yaml. The directory must be empty or not exist,
+// so that no stale file is read back.
+func (r *Request) WriteYAMLSplit(dir string) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("directory %s is not empty", dir)
+	}
+
+	database := &Request{Roles: r.Roles, Extensions: r.Extensions, Templates: r.Templates, Publications: r.Publications, Subscriptions: r.Subscriptions}
+	if err := writeYAMLFile(database, filepath.Join(dir, "database.yaml")); err != nil {
+		return err
+	}
+
+	for _, ns := range r.Namespaces {
+		namespace := *ns
+		namespace.Tables = nil
+		if err := writeYAMLFile(&Request{Namespaces: []*objects.Namespace{&namespace}}, filepath.Join(dir, ns.Name, "namespace.yaml")); err != nil {
+			return err
+		}
+
+		for _, table := range ns.Tables {
+			fragment := &Request{Namespaces: []*objects.Namespace{{Name: ns.Name, Tables: []*objects.Table{table}}}}
+			if err := writeYAMLFile(fragment, filepath.Join(dir, ns.Name, "tables", table.Name+".yaml")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeYAMLFile(r *Request, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create directory %s: %v", filepath.Dir(path), err)
+	}
+	return r.WriteYAML(path)
+}
@@ -11,6 +11,12 @@ type State struct {
 
 func (s *State) String() string {
 	result := fmt.Sprintf("database: %v\n", s.Database.Name)
+	if len(s.Database.Extensions) != 0 {
+		result += "extensions:\n"
+		for _, ext := range s.Database.Extensions {
+			result += fmt.Sprintf("  - %v\n", ext.String())
+		}
+	}
 	if s.Database.Namespaces != nil {
 		result += "namespaces:\n"
 		for _, ns := range s.Database.Namespaces {
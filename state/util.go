@@ -3,6 +3,7 @@ package state
 import (
 	"fmt"
 	"stijntratsaertit/terramigrate/objects"
+	"strings"
 )
 
 func columnDefaultAction(col *objects.Column) string {
@@ -20,3 +21,209 @@ func columnNullableAction(col *objects.Column) string {
 		return "SET NOT NULL"
 	}
 }
+
+func columnIdentityChanges(existing, desired *objects.ColumnIdentity, dataType string) []string {
+	changes := []string{}
+	if desired.GetGeneration() != existing.GetGeneration() {
+		changes = append(changes, fmt.Sprintf("SET GENERATED %s", desired.GetGeneration()))
+	}
+	for _, clause := range desired.ChangedClauses(existing.SequenceOptions, dataType) {
+		changes = append(changes, "SET "+clause)
+	}
+	return changes
+}
+
+// onlyDeferralDiffers reports whether two foreign keys differ in nothing but
+// when they are checked, which ALTER CONSTRAINT can change in place.
+func onlyDeferralDiffers(existing, desired *objects.Constraint) bool {
+	if desired.Type != objects.ConstraintTypeForeignKey || desired.Equal(existing) {
+		return false
+	}
+	deferred := *desired
+	deferred.Deferrable, deferred.InitiallyDeferred = existing.Deferrable, existing.InitiallyDeferred
+	return deferred.Equal(existing)
+}
+
+// commentAction returns the COMMENT ON action changing the comment of target,
+// e.g. "TABLE public.users", from the existing to the desired one. A nil
+// desired comment is not managed, and an empty one means none.
+func commentAction(target string, existing, desired *string) []string {
+	if desired == nil || (existing != nil && *existing == *desired) || (existing == nil && *desired == "") {
+		return nil
+	}
+	value := "NULL"
+	if *desired != "" {
+		value = objects.QuoteLiteral(*desired)
+	}
+	return []string{fmt.Sprintf("COMMENT ON %s IS %s;", target, value)}
+}
+
+func constraintCommentTarget(namespace, table string, c *objects.Constraint) string {
+	return fmt.Sprintf("CONSTRAINT %s ON %s", objects.QuoteIdent(c.Name), objects.QualifiedName(namespace, table))
+}
+
+// grantActions returns the REVOKE and GRANT actions turning the existing
+// grants on an object into the desired ones. on is the object as written
+// after ON, e.g. "TABLE public.users", and prefix precedes every action, as
+// ALTER DEFAULT PRIVILEGES does. Nil desired grants are not managed.
+func grantActions(prefix, objectType, on string, existing, desired []*objects.Grant) []string {
+	if desired == nil {
+		return nil
+	}
+
+	actions := []string{}
+	have := objects.GrantedPrivileges(objectType, existing)
+	want := objects.GrantedPrivileges(objectType, desired)
+	for _, role := range objects.GrantRoles(have, want) {
+		if revoke := privilegesMissing(have[role], want[role]); len(revoke) > 0 {
+			actions = append(actions, fmt.Sprintf("%sREVOKE %s ON %s FROM %s;", prefix, strings.Join(revoke, ", "), on, granteeSQL(role)))
+		}
+		if grant := privilegesMissing(want[role], have[role]); len(grant) > 0 {
+			actions = append(actions, fmt.Sprintf("%sGRANT %s ON %s TO %s;", prefix, strings.Join(grant, ", "), on, granteeSQL(role)))
+		}
+	}
+	return actions
+}
+
+// privilegesMissing returns the privileges of from that are not in other.
+func privilegesMissing(from, other []string) []string {
+	missing := []string{}
+	for _, p := range from {
+		if !containsString(other, p) {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+func granteeSQL(role string) string {
+	if role == "PUBLIC" {
+		return role
+	}
+	return objects.QuoteIdent(role)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// functionArgumentsEqual compares two argument type lists, ignoring case and
+// whitespace.
+func functionArgumentsEqual(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), ""), strings.Join(strings.Fields(b), ""))
+}
+
+// policyAlterable reports whether ALTER POLICY can turn the existing policy
+// into the desired one: it cannot change the command or kind of a policy, nor
+// remove one of its expressions.
+func policyAlterable(existing, desired *objects.Policy) bool {
+	if existing.GetCommand() != desired.GetCommand() || existing.Restrictive != desired.Restrictive {
+		return false
+	}
+	return (existing.Using == "" || desired.Using != "") && (existing.WithCheck == "" || desired.WithCheck != "")
+}
+
+// rowLevelSecurityActions returns the actions switching row level security of
+// table from the existing mode to the desired one.
+func rowLevelSecurityActions(table string, existing, desired objects.RowLevelSecurity) []string {
+	actions := []string{}
+	if existing == desired {
+		return actions
+	}
+	if existing == objects.RowLevelSecurityForced {
+		actions = append(actions, fmt.Sprintf("ALTER TABLE %s NO FORCE ROW LEVEL SECURITY;", table))
+	}
+	if existing == objects.RowLevelSecurityDisabled {
+		actions = append(actions, fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY;", table))
+	} else if desired == objects.RowLevelSecurityDisabled {
+		actions = append(actions, fmt.Sprintf("ALTER TABLE %s DISABLE ROW LEVEL SECURITY;", table))
+	}
+	if desired == objects.RowLevelSecurityForced {
+		actions = append(actions, fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY;", table))
+	}
+	return actions
+}
+
+// getNamespace returns the namespace with the given name, or nil.
+func getNamespace(namespaces []*objects.Namespace, name string) *objects.Namespace {
+	for _, ns := range namespaces {
+		if ns.Name == name {
+			return ns
+		}
+	}
+	return nil
+}
+
+// getTable returns the table of the namespace with the given name, or nil.
+func getTable(namespace *objects.Namespace, name string) *objects.Table {
+	if namespace == nil {
+		return nil
+	}
+	for _, t := range namespace.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// getColumn returns the column of the table with the given name, or nil.
+func getColumn(table *objects.Table, name string) *objects.Column {
+	if table == nil {
+		return nil
+	}
+	for _, c := range table.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// partitionOf returns the table of the namespace that has a partition with
+// the given name, or nil.
+func partitionOf(namespace *objects.Namespace, name string) *objects.Table {
+	if namespace == nil {
+		return nil
+	}
+	for _, t := range namespace.Tables {
+		if t.GetPartition(name) != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// droppedTables returns the qualified names of the existing tables and
+// partitions that are neither a table nor a partition in the desired state.
+func droppedTables(existing, desired []*objects.Namespace) map[string]bool {
+	dropped := map[string]bool{}
+	for _, existingNs := range existing {
+		var desiredNs *objects.Namespace
+		for _, ns := range desired {
+			if ns.Name == existingNs.Name {
+				desiredNs = ns
+				break
+			}
+		}
+
+		names := []string{}
+		for _, t := range existingNs.Tables {
+			names = append(names, t.Name)
+			for _, p := range t.Partitions {
+				names = append(names, p.Name)
+			}
+		}
+		for _, name := range names {
+			if getTable(desiredNs, name) == nil && partitionOf(desiredNs, name) == nil {
+				dropped[fmt.Sprintf("%s.%s", existingNs.Name, name)] = true
+			}
+		}
+	}
+	return dropped
+}